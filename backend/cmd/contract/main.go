@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"mock-api-backend/internal/config"
+	"mock-api-backend/internal/contract"
+	"mock-api-backend/internal/infrastructure/storage"
+	"mock-api-backend/internal/usecase"
+)
+
+// paramFlags collects repeated -param name=value flags.
+type paramFlags []string
+
+func (p *paramFlags) String() string { return strings.Join(*p, ", ") }
+
+func (p *paramFlags) Set(v string) error {
+	if name, _, ok := strings.Cut(v, "="); !ok || name == "" {
+		return fmt.Errorf("param must be in the form name=value")
+	}
+	*p = append(*p, v)
+	return nil
+}
+
+// headerFlags collects repeated -header "Name: value" flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlags) Set(v string) error {
+	if !strings.Contains(v, ":") {
+		return fmt.Errorf("header must be in the form \"Name: value\"")
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run verifies the mocks and returns the exit code: 1 if any mock drifted
+// or the check failed, 2 for bad usage.
+func run() int {
+	userID := flag.String("user", "", "user ID whose mocks are verified (required)")
+	baseURL := flag.String("base-url", "", "base URL of the real provider (required)")
+	format := flag.String("format", "json", "report format: json or junit")
+	out := flag.String("out", "", "write the report to this file instead of stdout")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout for each provider request")
+	var headers headerFlags
+	flag.Var(&headers, "header", "extra request header, e.g. \"Authorization: Bearer ...\" (repeatable)")
+	var params paramFlags
+	flag.Var(&params, "param", "path parameter value, e.g. \"id=42\" for /users/:id (repeatable; default "+contract.DefaultParamValue+")")
+	flag.Parse()
+
+	if *userID == "" || *baseURL == "" {
+		flag.Usage()
+		return 2
+	}
+	if *format != "json" && *format != "junit" {
+		log.Printf("Unknown format %q", *format)
+		return 2
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	repo, closeRepo, err := storage.Open(cfg)
+	if err != nil {
+		log.Printf("Failed to open storage: %v", err)
+		return 1
+	}
+	defer closeRepo()
+
+	ctx := context.Background()
+	service := usecase.NewMockService(repo)
+
+	mocks, err := service.GetMocks(ctx, *userID)
+	if err != nil {
+		log.Printf("Failed to load mocks: %v", err)
+		return 1
+	}
+
+	verifier := contract.NewVerifier(*baseURL, &http.Client{Timeout: *timeout})
+	for _, h := range headers {
+		name, value, _ := strings.Cut(h, ":")
+		verifier.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, p := range params {
+		name, value, _ := strings.Cut(p, "=")
+		verifier.Params[name] = value
+	}
+
+	report := verifier.Verify(ctx, mocks)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Printf("Failed to create report file: %v", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "junit" {
+		err = contract.WriteJUnit(w, report)
+	} else {
+		err = contract.WriteJSON(w, report)
+	}
+	if err != nil {
+		log.Printf("Failed to write report: %v", err)
+		return 1
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d mocks drifted from %s\n", failed, len(report.Results), *baseURL)
+		return 1
+	}
+	return 0
+}
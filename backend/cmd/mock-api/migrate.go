@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+
+	"mock-api-backend/internal/config"
+	"mock-api-backend/internal/infrastructure/migrate"
+	"mock-api-backend/internal/infrastructure/storage"
+)
+
+func runMigrate(args []string) error {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: mock-api migrate [flags] up|down|status|baseline")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.Storage, "storage", cfg.Storage, "storage backend: postgres or sqlite")
+	fs.StringVar(&cfg.DataPath, "data", cfg.DataPath, "database file for sqlite storage")
+	steps := fs.Int("steps", 1, "number of migrations to revert with down")
+	version := fs.Int("version", 0, "last migration to record as applied with baseline")
+	fs.Parse(args)
+	// Flags may also follow the action.
+	action := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if action == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	m, release, err := storage.OpenMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		applied, err := m.Up(ctx)
+		report("Applied", applied)
+		return err
+	case "down":
+		reverted, err := m.Down(ctx, *steps)
+		report("Reverted", reverted)
+		return err
+	case "baseline":
+		if *version <= 0 {
+			return errors.New("baseline needs -version")
+		}
+		recorded, err := m.Baseline(ctx, *version)
+		report("Recorded", recorded)
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATE")
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", st.Version, st.Name, state)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+}
+
+func report(verb string, migrations []migrate.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("Nothing to do")
+		return
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", verb, m.Version, m.Name)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"mock-api-backend/internal/app"
+	"mock-api-backend/internal/config"
+)
+
+func runServe(args []string) error {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return err
+	}
+
+	// Standalone use defaults to a local file rather than Postgres, plain
+	// HTTP, and managing mocks on the port being served.
+	if os.Getenv("STORAGE") == "" {
+		cfg.Storage = config.StorageBolt
+	}
+	if os.Getenv("SCHEME") == "" {
+		cfg.Scheme = "http"
+	}
+	managementDomain := cfg.ManagementDomain
+	if os.Getenv("MANAGEMENT_DOMAIN") == "" {
+		managementDomain = ""
+	}
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&cfg.Storage, "storage", cfg.Storage, "storage backend: memory, bolt, sqlite or postgres")
+	fs.StringVar(&cfg.DataPath, "data", cfg.DataPath, "database file for bolt or sqlite storage")
+	fs.StringVar(&cfg.Port, "port", cfg.Port, "port to listen on")
+	fs.StringVar(&cfg.MetricsPort, "metrics-port", cfg.MetricsPort, "serve Prometheus metrics at /metrics on this port (default off)")
+	fs.BoolVar(&cfg.MigrateOnStart, "migrate", cfg.MigrateOnStart, "apply pending schema migrations before serving")
+	fs.StringVar(&managementDomain, "management-domain", managementDomain, "host of the management API (default localhost:<port>)")
+	fs.Parse(args)
+
+	cfg.ManagementDomain = managementDomain
+	if cfg.ManagementDomain == "" {
+		cfg.ManagementDomain = "localhost:" + cfg.Port
+	}
+	return app.Serve(cfg)
+}
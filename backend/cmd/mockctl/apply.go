@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type planStep struct {
+	Action  string   `json:"action"`
+	ID      string   `json:"id"`
+	Method  string   `json:"method"`
+	Path    string   `json:"path"`
+	Match   string   `json:"match"`
+	Changes []string `json:"changes"`
+}
+
+type applyResult struct {
+	Applied bool           `json:"applied"`
+	DryRun  bool           `json:"dry_run"`
+	Summary map[string]int `json:"summary"`
+	Steps   []planStep     `json:"steps"`
+}
+
+func runApply(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := fs.String("f", "", "manifest file (YAML or JSON), or - for stdin")
+	prune := fs.Bool("prune", false, "delete mocks that are not in the manifest")
+	dryRun := fs.Bool("dry-run", false, "print the plan without changing anything")
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if *file == "" {
+		return fmt.Errorf("apply: -f is required")
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+	return applyManifest(c, *file, *prune, *dryRun)
+}
+
+// applyManifest uploads a manifest file to the apply endpoint and prints the
+// resulting plan.
+func applyManifest(c *client, file string, prune, dryRun bool) error {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/yaml"
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".json" {
+		contentType = "application/json"
+	}
+
+	q := url.Values{}
+	q.Set("prune", fmt.Sprint(prune))
+	q.Set("dry_run", fmt.Sprint(dryRun))
+
+	var result applyResult
+	if err := c.do("POST", "/api/mocks/apply?"+q.Encode(), contentType, data, &result); err != nil {
+		return err
+	}
+
+	printPlan(result)
+	return nil
+}
+
+func printPlan(result applyResult) {
+	symbols := map[string]string{"create": "+", "update": "~", "delete": "-", "unchanged": " "}
+	for _, st := range result.Steps {
+		line := fmt.Sprintf("%s %s %s%s", symbols[st.Action], st.Method, st.Path, st.Match)
+		if len(st.Changes) > 0 {
+			line += " (" + strings.Join(st.Changes, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		result.Summary["create"], result.Summary["update"], result.Summary["delete"], result.Summary["unchanged"])
+	if result.DryRun {
+		fmt.Println("Dry run: no changes were applied.")
+	} else if result.Applied {
+		fmt.Println("Applied.")
+	}
+}
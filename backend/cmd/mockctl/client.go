@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// serverAliases are shorthands accepted by -server.
+var serverAliases = map[string]string{
+	"local":  "http://localhost:8080",
+	"hosted": "https://tuanla.cloud",
+}
+
+const defaultServer = "local"
+
+// client talks to the management API as a single user, identified either by
+// an API key or by a user ID sent as the user_id cookie.
+type client struct {
+	server string
+	userID string
+	apiKey string
+	http   *http.Client
+}
+
+type clientOptions struct {
+	server  *string
+	userID  *string
+	apiKey  *string
+	timeout *time.Duration
+}
+
+// clientFlags registers the connection flags shared by every command.
+// Unset flags fall back to MOCKCTL_* environment variables and then to the
+// config saved by 'mockctl login'.
+func clientFlags(fs *flag.FlagSet) *clientOptions {
+	return &clientOptions{
+		server:  fs.String("server", os.Getenv("MOCKCTL_SERVER"), "management API URL, or local/hosted (env MOCKCTL_SERVER)"),
+		userID:  fs.String("user", os.Getenv("MOCKCTL_USER"), "user ID to act as (env MOCKCTL_USER)"),
+		apiKey:  fs.String("api-key", os.Getenv("MOCKCTL_API_KEY"), "API key to authenticate with (env MOCKCTL_API_KEY)"),
+		timeout: fs.Duration("timeout", 30*time.Second, "request timeout"),
+	}
+}
+
+// connect builds a client without requiring credentials.
+func (o *clientOptions) connect() (*client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	c := &client{
+		server: firstNonEmpty(*o.server, cfg.Server, defaultServer),
+		userID: *o.userID,
+		apiKey: *o.apiKey,
+		http:   &http.Client{Timeout: *o.timeout},
+	}
+	if c.userID == "" && c.apiKey == "" {
+		c.userID, c.apiKey = cfg.UserID, cfg.APIKey
+	}
+	if alias, ok := serverAliases[c.server]; ok {
+		c.server = alias
+	}
+	c.server = strings.TrimSuffix(c.server, "/")
+	return c, nil
+}
+
+// client builds a client and requires credentials.
+func (o *clientOptions) client() (*client, error) {
+	c, err := o.connect()
+	if err != nil {
+		return nil, err
+	}
+	if c.userID == "" && c.apiKey == "" {
+		return nil, fmt.Errorf("not logged in: run 'mockctl login', or pass -user or -api-key")
+	}
+	return c, nil
+}
+
+// do sends a request and decodes a JSON response into out. Non-2xx
+// responses are returned as errors carrying the server's message.
+func (c *client) do(method, path, contentType string, body []byte, out any) error {
+	req, err := http.NewRequest(method, c.server+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case c.apiKey != "":
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	case c.userID != "":
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: c.userID})
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, problemMessage(resp.Header.Get("Content-Type"), data))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// problemMessage formats an error response. Problem details are reduced to
+// their detail and code; other bodies are used as is.
+func problemMessage(contentType string, data []byte) string {
+	var p struct {
+		Detail string `json:"detail"`
+		Code   string `json:"code"`
+	}
+	if !strings.HasPrefix(contentType, "application/problem+json") || json.Unmarshal(data, &p) != nil || p.Code == "" {
+		return strings.TrimSpace(string(data))
+	}
+	return p.Detail + " (" + p.Code + ")"
+}
+
+// doJSON sends in as a JSON body.
+func (c *client) doJSON(method, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.do(method, path, "application/json", body, out)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
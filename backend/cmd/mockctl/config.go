@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// storedConfig is saved by 'mockctl login' and used as the fallback for the
+// connection flags.
+type storedConfig struct {
+	Server string `json:"server,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+func configPath() (string, error) {
+	if p := os.Getenv("MOCKCTL_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mockctl", "config.json"), nil
+}
+
+func loadConfig() (storedConfig, error) {
+	var cfg storedConfig
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, json.Unmarshal(data, &cfg)
+}
+
+func saveConfig(cfg storedConfig) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// corsPolicy mirrors the management API's CORS policy for served mocks.
+type corsPolicy struct {
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty"`
+	ExposedHeaders   []string `json:"exposed_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty"`
+	MaxAge           int      `json:"max_age,omitempty"`
+}
+
+// runCORS shows or changes the CORS policy of your served mocks:
+//
+//	mockctl cors show
+//	mockctl cors set -origins https://app.example -methods GET,POST -credentials
+//	mockctl cors off
+func runCORS(args []string) error {
+	fs := flag.NewFlagSet("cors", flag.ExitOnError)
+	origins := fs.String("origins", "", `allowed origins, comma-separated; "*" allows any`)
+	methods := fs.String("methods", "", "methods allowed in preflight answers, comma-separated")
+	headers := fs.String("headers", "", "request headers allowed in preflight answers, comma-separated")
+	expose := fs.String("expose", "", "response headers exposed to scripts, comma-separated")
+	credentials := fs.Bool("credentials", false, "allow credentials")
+	maxAge := fs.Int("max-age", 0, "seconds browsers may cache a preflight answer")
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	usage := fmt.Errorf("usage: mockctl cors [flags] show | set | off")
+	if fs.NArg() != 1 {
+		return usage
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Policy *corsPolicy `json:"policy"`
+	}
+	switch fs.Arg(0) {
+	case "show":
+		if err := c.do("GET", "/api/cors", "", nil, &resp); err != nil {
+			return err
+		}
+	case "set":
+		policy := corsPolicy{
+			AllowedOrigins:   splitList(*origins),
+			AllowedMethods:   splitList(*methods),
+			AllowedHeaders:   splitList(*headers),
+			ExposedHeaders:   splitList(*expose),
+			AllowCredentials: *credentials,
+			MaxAge:           *maxAge,
+		}
+		if err := c.doJSON("PUT", "/api/cors", policy, &resp); err != nil {
+			return err
+		}
+	case "off":
+		if err := c.do("DELETE", "/api/cors", "", nil, &resp); err != nil {
+			return err
+		}
+	default:
+		return usage
+	}
+
+	if *output == "json" {
+		return printJSON(resp.Policy)
+	}
+	p := resp.Policy
+	if p == nil {
+		fmt.Println("served mocks use the server's CORS settings")
+		return nil
+	}
+	fmt.Println("origins:    ", strings.Join(p.AllowedOrigins, ", "))
+	fmt.Println("methods:    ", strings.Join(p.AllowedMethods, ", "))
+	fmt.Println("headers:    ", strings.Join(p.AllowedHeaders, ", "))
+	fmt.Println("expose:     ", strings.Join(p.ExposedHeaders, ", "))
+	fmt.Println("credentials:", p.AllowCredentials)
+	fmt.Println("max age:    ", p.MaxAge)
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping blanks. It never
+// returns nil, so an empty -origins allows no origin.
+func splitList(v string) []string {
+	out := []string{}
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
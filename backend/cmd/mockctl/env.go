@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+type environment struct {
+	Name      string `json:"name"`
+	Active    bool   `json:"active"`
+	Overrides []struct {
+		MockID string `json:"mock_id"`
+	} `json:"overrides"`
+}
+
+// runEnv lists environments or switches the active one:
+//
+//	mockctl env list
+//	mockctl env use errors
+//	mockctl env off
+func runEnv(args []string) error {
+	fs := flag.NewFlagSet("env", flag.ExitOnError)
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	usage := fmt.Errorf("usage: mockctl env [flags] list | use <name> | off")
+	if fs.NArg() == 0 {
+		return usage
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	switch action := fs.Arg(0); {
+	case action == "list" && fs.NArg() == 1:
+		var envs []environment
+		if err := c.do("GET", "/api/environments", "", nil, &envs); err != nil {
+			return err
+		}
+		if *output == "json" {
+			return printJSON(envs)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tACTIVE\tOVERRIDES")
+		for _, env := range envs {
+			active := ""
+			if env.Active {
+				active = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", env.Name, active, len(env.Overrides))
+		}
+		return tw.Flush()
+
+	case action == "use" && fs.NArg() == 2:
+		req := map[string]string{"name": fs.Arg(1)}
+		if err := c.doJSON("PUT", "/api/environments/active", req, nil); err != nil {
+			return err
+		}
+		fmt.Println("serving environment", fs.Arg(1))
+		return nil
+
+	case action == "off" && fs.NArg() == 1:
+		if err := c.do("DELETE", "/api/environments/active", "", nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("serving the mocks' own responses")
+		return nil
+	}
+	return usage
+}
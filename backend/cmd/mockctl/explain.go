@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// explanation mirrors the management API's answer to POST
+// /api/mocks/explain.
+type explanation struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Matched    *mock  `json:"matched"`
+	Candidates []struct {
+		MockID     string `json:"mock_id"`
+		Method     string `json:"method"`
+		Path       string `json:"path"`
+		Match      string `json:"match"`
+		Mismatches []struct {
+			Message string `json:"message"`
+		} `json:"mismatches"`
+	} `json:"candidates"`
+	Mocks int `json:"mocks"`
+}
+
+// runExplain shows which mock would serve a request, and why the closest
+// others would not:
+//
+//	mockctl explain -method POST -path '/users/1?verbose=1' -H 'Accept: application/json'
+func runExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	method := fs.String("method", "GET", "HTTP method")
+	path := fs.String("path", "", "request path, optionally with a query string")
+	var headers, cookies ruleFlags
+	fs.Var(&headers, "H", `a request header as "Name: value"; repeatable`)
+	fs.Var(&cookies, "cookie", `a request cookie as "name=value"; repeatable`)
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if *path == "" {
+		return fmt.Errorf("-path is required")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	req := map[string]any{"method": strings.ToUpper(*method), "path": *path}
+	h := map[string]string{}
+	for _, v := range headers {
+		name, value, ok := strings.Cut(v, ":")
+		if !ok {
+			return fmt.Errorf("header %q must be written as Name: value", v)
+		}
+		h[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	req["headers"] = h
+	ck := map[string]string{}
+	for _, v := range cookies {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("cookie %q must be written as name=value", v)
+		}
+		ck[name] = value
+	}
+	req["cookies"] = ck
+
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+	var exp explanation
+	if err := c.doJSON("POST", "/api/mocks/explain", req, &exp); err != nil {
+		return err
+	}
+	if *output == "json" {
+		return printJSON(exp)
+	}
+
+	if m := exp.Matched; m != nil {
+		fmt.Printf("%s %s is served by %s %s %s%s\n", exp.Method, exp.Path, m.ID, m.Method, m.Path, m.Match.String())
+	} else {
+		fmt.Printf("%s %s matches none of your %d mocks\n", exp.Method, exp.Path, exp.Mocks)
+	}
+	for _, cand := range exp.Candidates {
+		fmt.Printf("  %s %s %s%s\n", cand.MockID, cand.Method, cand.Path, cand.Match)
+		for _, m := range cand.Mismatches {
+			fmt.Printf("    %s\n", m.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func runLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	c, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	// Without credentials, ask the server for a fresh identity and key.
+	if c.userID == "" && c.apiKey == "" {
+		var resp struct {
+			UserID string `json:"user_id"`
+			APIKey string `json:"api_key"`
+		}
+		if err := c.do("POST", "/api/keys", "", nil, &resp); err != nil {
+			return fmt.Errorf("failed to obtain an API key (pass -user to log in with a user ID): %w", err)
+		}
+		c.userID, c.apiKey = resp.UserID, resp.APIKey
+	}
+
+	// Check the credentials before saving them.
+	if _, err := listMocks(c); err != nil {
+		return err
+	}
+
+	cfg := storedConfig{Server: c.server, APIKey: c.apiKey}
+	if c.apiKey == "" {
+		cfg.UserID = c.userID
+	}
+	path, err := saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	who := c.userID
+	if who == "" {
+		who = "API key"
+	}
+	fmt.Printf("Logged in to %s as %s (saved to %s)\n", c.server, who, path)
+	return nil
+}
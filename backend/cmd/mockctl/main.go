@@ -0,0 +1,60 @@
+// Command mockctl drives the mock management API from a terminal.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"login", "save the server and credentials used by other commands", runLogin},
+	{"create", "create a mock", runCreate},
+	{"list", "list your mocks", runList},
+	{"update", "change fields of a mock", runUpdate},
+	{"delete", "delete mocks by ID", runDelete},
+	{"import", "create or update mocks from a manifest file", runImport},
+	{"export", "write your mocks as a manifest file", runExport},
+	{"apply", "make the server's mocks match a YAML or JSON manifest", runApply},
+	{"explain", "show which mock serves a request, and why others do not", runExplain},
+	{"tail", "show recent requests to your mocks", runTail},
+	{"env", "list environments or switch the active one", runEnv},
+	{"cors", "show or change the CORS policy of your served mocks", runCORS},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "mockctl:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "mockctl: unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: mockctl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'mockctl <command> -h' for command flags.")
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mock mirrors the management API's mock representation.
+type mock struct {
+	ID            string          `json:"id"`
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	Status        int             `json:"status"`
+	ResponseBody  string          `json:"response_body"`
+	RequestSchema json.RawMessage `json:"request_schema,omitempty"`
+	RateLimit     json.RawMessage `json:"rate_limit,omitempty"`
+	Match         *matchRules     `json:"match,omitempty"`
+	CORS          json.RawMessage `json:"cors,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	HitCount      int             `json:"hit_count"`
+	CurlCommand   string          `json:"curl_command,omitempty"`
+}
+
+// mockRequest is the body accepted by create and update.
+type mockRequest struct {
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	Status        int             `json:"status"`
+	ResponseBody  string          `json:"response_body"`
+	RequestSchema json.RawMessage `json:"request_schema,omitempty"`
+	RateLimit     json.RawMessage `json:"rate_limit,omitempty"`
+	Match         *matchRules     `json:"match,omitempty"`
+	CORS          json.RawMessage `json:"cors,omitempty"`
+}
+
+// matchRules are the conditions beyond method and path that a request
+// must meet to be served by a mock.
+type matchRules struct {
+	Query   []matchRule `json:"query,omitempty"`
+	Headers []matchRule `json:"headers,omitempty"`
+	Cookies []matchRule `json:"cookies,omitempty"`
+}
+
+type matchRule struct {
+	Name  string `json:"name"`
+	Op    string `json:"op,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// String formats the rules to follow a path, as the server does, e.g.
+// "?status=open&!debug header[Accept*=json]", or "" when there are none.
+func (m *matchRules) String() string {
+	if m == nil {
+		return ""
+	}
+	var b strings.Builder
+	if len(m.Query) > 0 {
+		b.WriteString("?" + formatRules(m.Query, "&"))
+	}
+	if len(m.Headers) > 0 {
+		b.WriteString(" header[" + formatRules(m.Headers, ", ") + "]")
+	}
+	if len(m.Cookies) > 0 {
+		b.WriteString(" cookie[" + formatRules(m.Cookies, ", ") + "]")
+	}
+	return b.String()
+}
+
+func formatRules(rules []matchRule, sep string) string {
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		switch r.Op {
+		case "contains":
+			parts[i] = r.Name + "*=" + r.Value
+		case "regex":
+			parts[i] = r.Name + "~" + r.Value
+		case "present":
+			parts[i] = r.Name
+		case "absent":
+			parts[i] = "!" + r.Name
+		default:
+			parts[i] = r.Name + "=" + r.Value
+		}
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, sep)
+}
+
+// mockFields registers the flags describing a mock's definition.
+type mockFields struct {
+	method, path, body, bodyFile, schemaFile, rateLimit, matchQuery, cors *string
+	status                                                                *int
+	matchHeaders, matchCookies                                            ruleFlags
+}
+
+func addMockFields(fs *flag.FlagSet) *mockFields {
+	f := &mockFields{
+		method:     fs.String("method", "", "HTTP method"),
+		path:       fs.String("path", "", "request path, e.g. /users"),
+		status:     fs.Int("status", 0, "response status code"),
+		body:       fs.String("body", "", "response body"),
+		bodyFile:   fs.String("body-file", "", "read the response body from a file"),
+		schemaFile: fs.String("schema-file", "", "read the request schema (JSON) from a file"),
+		rateLimit:  fs.String("rate-limit", "", `rate limit as REQUESTS/PERIOD, e.g. 10/1m; append ",user" to share it across your mocks, or "off" to remove it`),
+		cors:       fs.String("cors", "", `CORS headers to send instead of the server's, as JSON, e.g. '{"allow_origin": ""}' to omit Access-Control-Allow-Origin; "off" removes the override`),
+		matchQuery: fs.String("match-query", "", `query parameters a request must have, joined by &: name=value, name*=substring, name~regex, name (present) or !name (absent); "off" removes them`),
+	}
+	fs.Var(&f.matchHeaders, "match-header", `a header a request must have, written like a -match-query rule, e.g. "Accept*=json" or "!Authorization"; repeatable, "off" removes them`)
+	fs.Var(&f.matchCookies, "match-cookie", `a cookie a request must have, written like a -match-query rule; repeatable, "off" removes them`)
+	return f
+}
+
+// ruleFlags collects the rules of a repeatable flag.
+type ruleFlags []string
+
+func (r *ruleFlags) String() string { return strings.Join(*r, ", ") }
+
+func (r *ruleFlags) Set(v string) error {
+	*r = append(*r, v)
+	return nil
+}
+
+// rules parses the collected rules; "off" anywhere removes them all.
+func (r ruleFlags) rules() []matchRule {
+	var out []matchRule
+	for _, v := range r {
+		if v == "off" {
+			return nil
+		}
+		out = append(out, parseMatchRule(v))
+	}
+	return out
+}
+
+// apply overlays the flags that were set onto req.
+func (f *mockFields) apply(fs *flag.FlagSet, req *mockRequest) error {
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch fl.Name {
+		case "method":
+			req.Method = strings.ToUpper(*f.method)
+		case "path":
+			req.Path = *f.path
+		case "status":
+			req.Status = *f.status
+		case "body":
+			req.ResponseBody = *f.body
+		case "body-file":
+			var data []byte
+			data, err = os.ReadFile(*f.bodyFile)
+			req.ResponseBody = string(data)
+		case "schema-file":
+			var data []byte
+			data, err = os.ReadFile(*f.schemaFile)
+			req.RequestSchema = data
+		case "rate-limit":
+			req.RateLimit, err = parseRateLimit(*f.rateLimit)
+		case "cors":
+			req.CORS, err = parseCORS(*f.cors)
+		case "match-query":
+			req.Match = withRules(req.Match, func(m *matchRules) { m.Query = parseMatchQuery(*f.matchQuery) })
+		case "match-header":
+			req.Match = withRules(req.Match, func(m *matchRules) { m.Headers = f.matchHeaders.rules() })
+		case "match-cookie":
+			req.Match = withRules(req.Match, func(m *matchRules) { m.Cookies = f.matchCookies.rules() })
+		}
+	})
+	return err
+}
+
+// withRules returns a copy of m changed by set, or nil if no rules remain.
+func withRules(m *matchRules, set func(*matchRules)) *matchRules {
+	var out matchRules
+	if m != nil {
+		out = *m
+	}
+	set(&out)
+	if len(out.Query)+len(out.Headers)+len(out.Cookies) == 0 {
+		return nil
+	}
+	return &out
+}
+
+// parseCORS checks a -cors value. "off" returns nil, which removes the
+// override.
+func parseCORS(v string) (json.RawMessage, error) {
+	if v == "off" {
+		return nil, nil
+	}
+	if !json.Valid([]byte(v)) {
+		return nil, fmt.Errorf("-cors must be a JSON object or \"off\"")
+	}
+	return json.RawMessage(v), nil
+}
+
+// parseRateLimit turns "10/1m" or "100/1h,user" into a rate_limit value.
+// "off" returns nil, which removes the limit.
+func parseRateLimit(v string) (json.RawMessage, error) {
+	if v == "off" {
+		return nil, nil
+	}
+	spec, scope, _ := strings.Cut(v, ",")
+	requests, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid -rate-limit %q: want REQUESTS/PERIOD, e.g. 10/1m", v)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -rate-limit %q: %w", v, err)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -rate-limit %q: %w", v, err)
+	}
+	if d%time.Second != 0 {
+		return nil, fmt.Errorf("invalid -rate-limit %q: period must be whole seconds", v)
+	}
+	return json.Marshal(map[string]any{
+		"requests":       n,
+		"period_seconds": int(d / time.Second),
+		"scope":          scope,
+	})
+}
+
+// parseMatchQuery turns "status=open&page~[0-9]+&!debug" into query rules.
+// "off" and "" return nil, which removes them.
+func parseMatchQuery(v string) []matchRule {
+	if v == "off" || v == "" {
+		return nil
+	}
+	var rules []matchRule
+	for _, part := range strings.Split(strings.TrimPrefix(v, "?"), "&") {
+		rules = append(rules, parseMatchRule(part))
+	}
+	return rules
+}
+
+// parseMatchRule parses name=value, name*=substring, name~regex, name or
+// !name.
+func parseMatchRule(v string) matchRule {
+	if name, ok := strings.CutPrefix(v, "!"); ok {
+		return matchRule{Name: name, Op: "absent"}
+	}
+	i := strings.IndexAny(v, "=~")
+	switch {
+	case i < 0:
+		return matchRule{Name: v, Op: "present"}
+	case v[i] == '~':
+		return matchRule{Name: v[:i], Op: "regex", Value: v[i+1:]}
+	case i > 0 && v[i-1] == '*':
+		return matchRule{Name: v[:i-1], Op: "contains", Value: v[i+1:]}
+	}
+	return matchRule{Name: v[:i], Value: v[i+1:]}
+}
+
+func runCreate(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	fields := addMockFields(fs)
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	req := mockRequest{Method: "GET", Status: 200}
+	if err := fields.apply(fs, &req); err != nil {
+		return err
+	}
+
+	var created mock
+	if err := c.doJSON("POST", "/api/mocks", req, &created); err != nil {
+		return err
+	}
+	return printMocks(*output, []mock{created})
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	mocks, err := listMocks(c)
+	if err != nil {
+		return err
+	}
+	return printMocks(*output, mocks)
+}
+
+func runUpdate(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	fields := addMockFields(fs)
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: mockctl update [flags] <id>")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	// The API replaces the whole definition, so start from the current one.
+	current, err := findMock(c, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	req := mockRequest{
+		Method:        current.Method,
+		Path:          current.Path,
+		Status:        current.Status,
+		ResponseBody:  current.ResponseBody,
+		RequestSchema: current.RequestSchema,
+		RateLimit:     current.RateLimit,
+		Match:         current.Match,
+		CORS:          current.CORS,
+	}
+	if err := fields.apply(fs, &req); err != nil {
+		return err
+	}
+
+	var updated mock
+	if err := c.doJSON("PUT", "/api/mocks/"+url.PathEscape(current.ID), req, &updated); err != nil {
+		return err
+	}
+	return printMocks(*output, []mock{updated})
+}
+
+func runDelete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: mockctl delete [flags] <id>...")
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range fs.Args() {
+		if err := c.do("DELETE", "/api/mocks/"+url.PathEscape(id), "", nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("deleted", id)
+	}
+	return nil
+}
+
+func listMocks(c *client) ([]mock, error) {
+	var mocks []mock
+	if err := c.do("GET", "/api/mocks", "", nil, &mocks); err != nil {
+		return nil, err
+	}
+	return mocks, nil
+}
+
+func findMock(c *client, id string) (*mock, error) {
+	mocks, err := listMocks(c)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mocks {
+		if mocks[i].ID == id {
+			return &mocks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("mock %s not found", id)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+type hit struct {
+	Seq    int64     `json:"seq"`
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Status int       `json:"status"`
+	MockID string    `json:"mock_id,omitempty"`
+}
+
+func runTail(args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	follow := fs.Bool("f", false, "keep polling for new hits")
+	interval := fs.Duration("interval", 2*time.Second, "poll interval with -f")
+	limit := fs.Int("n", 20, "number of recent hits to show first")
+	output := outputFlag(fs)
+	opts := clientFlags(fs)
+	fs.Parse(args)
+
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	var cursor int64
+	first := true
+	for {
+		path := fmt.Sprintf("/api/hits?after=%d", cursor)
+		if first {
+			path += fmt.Sprintf("&limit=%d", *limit)
+		}
+		var resp struct {
+			Hits   []hit `json:"hits"`
+			Cursor int64 `json:"cursor"`
+		}
+		if err := c.do("GET", path, "", nil, &resp); err != nil {
+			return err
+		}
+		if err := printHits(*output, resp.Hits, first); err != nil {
+			return err
+		}
+		cursor = resp.Cursor
+		first = false
+
+		if !*follow {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+func printHits(format string, hits []hit, header bool) error {
+	if format == "json" {
+		for _, h := range hits {
+			if err := printJSON(h); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if header {
+		fmt.Fprintln(tw, "TIME\tMETHOD\tPATH\tSTATUS\tMOCK")
+	}
+	for _, h := range hits {
+		mockID := h.MockID
+		if mockID == "" {
+			mockID = "(no match)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", h.Time.Local().Format(time.TimeOnly), h.Method, truncate(h.Path, 60), h.Status, mockID)
+	}
+	return tw.Flush()
+}
@@ -3,21 +3,40 @@
 package main
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/syumai/workers"
 
+	"mock-api-backend/internal/config"
 	"mock-api-backend/internal/domain"
 	mockhttp "mock-api-backend/internal/infrastructure/http"
 	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/infrastructure/tracing"
 	"mock-api-backend/internal/usecase"
 
 	"github.com/syumai/workers/cloudflare"
 )
 
 func main() {
+	// Workers Logs parse JSON lines, so that is the default format here.
+	logFormat := getenv("LOG_FORMAT")
+	if logFormat == "" {
+		logFormat = config.LogFormatJSON
+	}
+	logger, err := config.NewLogger(os.Stdout, getenv("LOG_LEVEL"), logFormat)
+	if err != nil {
+		panic(err)
+	}
+	slog.SetDefault(logger)
+
+	tp, err := setupTracing()
+	if err != nil {
+		panic(err)
+	}
+
 	// Initialize D1 Repository
 	d1Repo, err := repository.NewD1MockRepository("DB")
 	if err != nil {
@@ -27,8 +46,21 @@ func main() {
 	// Bind interface to implementation
 	var mockRepo domain.MockRepository = d1Repo
 
+	timeouts, err := config.LoadTimeouts(getenv)
+	if err != nil {
+		panic(err)
+	}
+	mockRepo = repository.WithTimeouts(mockRepo, timeouts.Query, timeouts.Transaction)
+
 	// Initialize service
-	service := usecase.NewMockService(mockRepo)
+	service := usecase.NewMockService(tracing.Repository(mockRepo))
+	service.SetRateLimitStore(d1Repo.RateLimitStore())
+	service.SetHitStore(d1Repo.HitStore())
+	quotas, err := config.LoadQuotas(getenv)
+	if err != nil {
+		panic(err)
+	}
+	service.SetQuotas(usecase.Quotas(quotas))
 
 	// Get configuration from environment
 	scheme := cloudflare.Getenv("SCHEME")
@@ -45,27 +77,41 @@ func main() {
 
 	// Initialize handler with config
 	handler := mockhttp.NewMockHandler(service, scheme, managementDomain)
+	if secret := cloudflare.Getenv("API_KEY_SECRET"); secret != "" && secret != "<undefined>" {
+		handler.EnableAPIKeys(secret)
+	}
+	// Workers have no remote address; Cloudflare passes the client's in
+	// this header, replacing any the client sent.
+	handler.TrustClientIPHeader("CF-Connecting-IP")
 
 	// Create routers
 	managementRouter := mockhttp.NewManagementRouter(handler, allowedOrigins)
 	servingRouter := mockhttp.NewServingRouter(handler, allowedOrigins)
 
-	// Create main handler that dispatches based on Host header
-	mainHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Println("Host: ", r.Host)
-		fmt.Println("AllowedOrigins: ", allowedOrigins)
-		// If Host matches MANAGEMENT_DOMAIN, send to Management router
-		if r.Host == managementDomain {
-			managementRouter.ServeHTTP(w, r)
-			return
-		}
-
-		// Otherwise, send to Serving router
-		servingRouter.ServeHTTP(w, r)
-	})
+	// Dispatch on the Host header, and trace and log every request
+	mainHandler := tracing.Middleware(mockhttp.NewHostRouter(managementDomain, managementRouter, servingRouter))
 
 	// Start the worker
-	workers.Serve(mainHandler)
+	var workerHandler http.Handler = mockhttp.TimeoutMiddleware(timeouts.Request, mainHandler)
+	migrateOnStart, err := config.LoadMigrateOnStart(getenv)
+	if err != nil {
+		panic(err)
+	}
+	if migrateOnStart {
+		workerHandler = migrateOnce(d1Repo.MigrationConn(), workerHandler)
+	}
+	if tp != nil {
+		workerHandler = flushSpans(tp, workerHandler)
+	}
+	workers.Serve(mockhttp.LoggingMiddleware(logger, workerHandler))
+}
+
+// getenv returns a Worker variable, or "" when it is not set.
+func getenv(name string) string {
+	if v := cloudflare.Getenv(name); v != "<undefined>" {
+		return v
+	}
+	return ""
 }
 
 func parseAllowedOrigins(raw string) []string {
@@ -0,0 +1,50 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	mockhttp "mock-api-backend/internal/infrastructure/http"
+	"mock-api-backend/internal/infrastructure/migrate"
+	sqlfiles "mock-api-backend/sql"
+)
+
+// migrateOnce applies pending D1 migrations before the first request is
+// handled. D1 can't be queried before the worker starts serving, so this
+// runs lazily. A failed attempt is retried on the next request.
+func migrateOnce(conn migrate.Conn, next http.Handler) http.Handler {
+	var mu sync.Mutex
+	done := false
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if !done {
+			if err := migrateD1(r.Context(), conn); err != nil {
+				mu.Unlock()
+				slog.Error("migration failed", "error", err)
+				mockhttp.WriteProblem(w, r, http.StatusServiceUnavailable, "migration_failed", "Database migration failed")
+				return
+			}
+			done = true
+		}
+		mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func migrateD1(ctx context.Context, conn migrate.Conn) error {
+	migrations, err := migrate.Load(sqlfiles.SQLiteMigrations())
+	if err != nil {
+		return err
+	}
+	applied, err := migrate.New(conn, migrations).Up(ctx)
+	for _, m := range applied {
+		slog.Info("applied migration", "version", m.Version, "name", m.Name)
+	}
+	return err
+}
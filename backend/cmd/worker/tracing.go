@@ -0,0 +1,42 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/syumai/workers/cloudflare"
+	"github.com/syumai/workers/cloudflare/fetch"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"mock-api-backend/internal/config"
+	"mock-api-backend/internal/infrastructure/tracing"
+)
+
+// setupTracing exports spans through the Workers fetch API when an OTLP
+// endpoint is configured. It returns nil when exporting is disabled.
+func setupTracing() (*sdktrace.TracerProvider, error) {
+	cfg, err := config.LoadTracing(getenv)
+	if err != nil {
+		return nil, err
+	}
+	client := fetch.NewClient().HTTPClient(fetch.RedirectModeFollow)
+	return tracing.Setup(context.Background(), tracing.Config(cfg), otlptracehttp.WithHTTPClient(client))
+}
+
+// flushSpans exports the spans of each request once the response has been
+// sent. An isolate may be suspended between requests, so spans can't wait
+// for the batcher's timer.
+func flushSpans(tp *sdktrace.TracerProvider, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		cloudflare.WaitUntil(func() {
+			if err := tp.ForceFlush(context.Background()); err != nil {
+				slog.Error("trace export failed", "error", err)
+			}
+		})
+	})
+}
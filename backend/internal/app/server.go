@@ -0,0 +1,203 @@
+// Package app runs the HTTP server shared by the server binaries.
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"mock-api-backend/internal/config"
+	mockhttp "mock-api-backend/internal/infrastructure/http"
+	"mock-api-backend/internal/infrastructure/metrics"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/infrastructure/storage"
+	"mock-api-backend/internal/infrastructure/tracing"
+	"mock-api-backend/internal/usecase"
+)
+
+// Serve opens the configured storage and serves the management and serving
+// APIs on cfg.Port until the process is interrupted.
+func Serve(cfg *config.Config) error {
+	logger, err := config.NewLogger(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	quotas, err := config.LoadQuotas(os.Getenv)
+	if err != nil {
+		return err
+	}
+	timeouts, err := config.LoadTimeouts(os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	tracingCfg, err := config.LoadTracing(os.Getenv)
+	if err != nil {
+		return err
+	}
+	tp, err := tracing.Setup(context.Background(), tracing.Config(tracingCfg))
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	if tp != nil {
+		logger.Info("exporting traces", "endpoint", tracingCfg.Endpoint)
+		defer func() {
+			if err := tp.Shutdown(context.Background()); err != nil {
+				logger.Error("trace exporter shutdown failed", "error", err)
+			}
+		}()
+	}
+
+	// Initialize repository
+	mockRepo, closeRepo, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open %s storage: %w", cfg.Storage, err)
+	}
+	defer closeRepo()
+	repo := repository.WithTimeouts(mockRepo, timeouts.Query, timeouts.Transaction)
+	m := metrics.New(repo.Stats)
+
+	// Initialize service
+	service := usecase.NewMockService(m.Repository(tracing.Repository(repo)))
+	service.SetQuotas(usecase.Quotas(quotas))
+
+	// Initialize handler with config
+	handler := mockhttp.NewMockHandler(service, cfg.Scheme, cfg.ManagementDomain)
+	if cfg.APIKeySecret != "" {
+		handler.EnableAPIKeys(cfg.APIKeySecret)
+	}
+	if cfg.ClientIPHeader != "" {
+		handler.TrustClientIPHeader(cfg.ClientIPHeader)
+	}
+	handler.ObserveMatches(m.ObserveMatch)
+
+	// Create routers
+	managementRouter := m.Middleware("management", mockhttp.NewManagementRouter(handler, cfg.AllowedOrigins))
+	servingRouter := m.Middleware("serving", mockhttp.NewServingRouter(handler, cfg.AllowedOrigins))
+
+	// Dispatch on the Host header, and trace, log and time out every request
+	mainHandler := tracing.Middleware(mockhttp.NewHostRouter(cfg.ManagementDomain, managementRouter, servingRouter))
+
+	// Requests are handled with contexts derived from baseCtx, which is
+	// cancelled when shutdown gives up waiting for them.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	// Create HTTP server
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           mockhttp.LoggingMiddleware(logger, mockhttp.TimeoutMiddleware(timeouts.Request, mainHandler)),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Setup graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	// Start server in a goroutine
+	serveErr := make(chan error, 1)
+
+	// The admin server exposes metrics on a port of its own, so they are
+	// not reachable through the public domains.
+	var adminServer *http.Server
+	if cfg.MetricsPort != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		adminServer = &http.Server{
+			Addr:     ":" + cfg.MetricsPort,
+			Handler:  mux,
+			ErrorLog: server.ErrorLog,
+		}
+		go func() {
+			logger.Info("serving metrics", "port", cfg.MetricsPort)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("metrics: %w", err)
+			}
+		}()
+	}
+
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	cleanupDone := make(chan struct{})
+	go func() {
+		defer close(cleanupDone)
+		cleanupExpired(cleanupCtx, service, m, cfg.CleanupInterval)
+	}()
+
+	go func() {
+		logger.Info("starting server",
+			"port", cfg.Port,
+			"storage", cfg.Storage,
+			"management_domain", cfg.ManagementDomain)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	// Wait for interrupt signal or a failure to listen
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-sigChan:
+	}
+	logger.Info("shutting down server", "timeout", timeouts.Shutdown)
+
+	// Graceful shutdown: stop accepting requests and let in-flight ones
+	// finish, for at most the shutdown timeout.
+	ctx := context.Background()
+	if timeouts.Shutdown > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeouts.Shutdown)
+		defer cancel()
+	}
+	stopCleanup()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("server shutdown timed out; closing remaining connections", "error", err)
+		cancelRequests()
+		server.Close()
+	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			adminServer.Close()
+		}
+	}
+	<-cleanupDone
+
+	logger.Info("server stopped")
+	return nil
+}
+
+// cleanupExpired deletes expired mocks every interval until ctx is done,
+// which also abandons a cleanup in progress.
+func cleanupExpired(ctx context.Context, service *usecase.MockService, m *metrics.Metrics, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		deleted, err := service.CleanupExpired(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		m.ObserveCleanup(deleted, err)
+		if err != nil {
+			slog.Error("expired mock cleanup failed", "error", err)
+		} else if deleted > 0 {
+			slog.Info("deleted expired mocks", "count", deleted)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 type DatabaseConfig struct {
@@ -13,15 +15,44 @@ type DatabaseConfig struct {
 	DBName   string
 }
 
+// Storage backends selectable with STORAGE.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageBolt     = "bolt"
+	StorageSQLite   = "sqlite"
+)
+
 type Config struct {
 	Port             string
 	Scheme           string
 	ManagementDomain string
 	AllowedOrigins   []string
-	Database         DatabaseConfig
+	APIKeySecret     string
+	// ClientIPHeader names the header a trusted proxy sets to the client's
+	// address, such as X-Real-IP; when empty the connection's is used.
+	ClientIPHeader string
+	// Storage is one of the Storage* backends. DataPath is the database
+	// file used by file-backed storage.
+	Storage  string
+	DataPath string
+	// MigrateOnStart applies pending schema migrations when storage opens.
+	MigrateOnStart bool
+	Database       DatabaseConfig
+	// LogLevel and LogFormat configure the server's structured logs; see
+	// NewLogger.
+	LogLevel  string
+	LogFormat string
+	// MetricsPort serves Prometheus metrics at /metrics when set. It is
+	// meant to be reachable only internally.
+	MetricsPort string
+	// CleanupInterval is how often expired mocks are deleted.
+	CleanupInterval time.Duration
 }
 
-func NewConfig() *Config {
+// NewConfig reads the server configuration from the environment. It fails
+// only on values that can't be parsed.
+func NewConfig() (*Config, error) {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -42,6 +73,25 @@ func NewConfig() *Config {
 		allowedOrigins = splitAndTrim(envOrigins)
 	}
 
+	storage := os.Getenv("STORAGE")
+	if storage == "" {
+		storage = StoragePostgres
+	}
+	dataPath := os.Getenv("DATA_PATH")
+	if dataPath == "" {
+		dataPath = "mock-api.db"
+	}
+
+	migrateOnStart, err := LoadMigrateOnStart(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanupInterval := time.Minute
+	if d, err := time.ParseDuration(os.Getenv("CLEANUP_INTERVAL")); err == nil && d > 0 {
+		cleanupInterval = d
+	}
+
 	dbHost := os.Getenv("DB_HOST")
 	if dbHost == "" {
 		dbHost = "localhost"
@@ -65,6 +115,15 @@ func NewConfig() *Config {
 		Scheme:           scheme,
 		ManagementDomain: managementDomain,
 		AllowedOrigins:   allowedOrigins,
+		APIKeySecret:     os.Getenv("API_KEY_SECRET"),
+		ClientIPHeader:   os.Getenv("CLIENT_IP_HEADER"),
+		Storage:          storage,
+		DataPath:         dataPath,
+		MigrateOnStart:   migrateOnStart,
+		LogLevel:         os.Getenv("LOG_LEVEL"),
+		LogFormat:        os.Getenv("LOG_FORMAT"),
+		MetricsPort:      os.Getenv("METRICS_PORT"),
+		CleanupInterval:  cleanupInterval,
 		Database: DatabaseConfig{
 			Host:     dbHost,
 			Port:     dbPort,
@@ -72,7 +131,7 @@ func NewConfig() *Config {
 			Password: dbPassword,
 			DBName:   dbName,
 		},
-	}
+	}, nil
 }
 
 func splitAndTrim(raw string) []string {
@@ -85,3 +144,28 @@ func splitAndTrim(raw string) []string {
 	}
 	return out
 }
+
+// LoadMigrateOnStart reads MIGRATE_ON_START through getenv. Migrations run
+// by default, so a fresh database just works; set it to false to apply them
+// with mock-api migrate instead.
+func LoadMigrateOnStart(getenv func(string) string) (bool, error) {
+	raw := quotaValue(getenv, "MIGRATE_ON_START")
+	if raw == "" {
+		return true, nil
+	}
+	v, err := parseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("MIGRATE_ON_START %w", err)
+	}
+	return v, nil
+}
+
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be true or false, got %q", raw)
+}
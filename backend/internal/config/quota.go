@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Quotas bound what a single user may store and send. Zero values and nil
+// rates mean no limit.
+type Quotas struct {
+	MaxMocks        int
+	MaxBodyBytes    int
+	MaxRequestBytes int64
+	MaxHitsPerMock  int
+	ManagementRate  *domain.RateLimit
+	ServingRate     *domain.RateLimit
+}
+
+// LoadQuotas reads the QUOTA_* variables through getenv, which is
+// os.Getenv for the server and the Worker's environment for the worker.
+// Rates are written as REQUESTS/PERIOD, such as 60/1m.
+func LoadQuotas(getenv func(string) string) (Quotas, error) {
+	var q Quotas
+	var err error
+	if q.MaxMocks, err = quotaInt(getenv, "QUOTA_MAX_MOCKS"); err != nil {
+		return q, err
+	}
+	if q.MaxBodyBytes, err = quotaInt(getenv, "QUOTA_MAX_BODY_BYTES"); err != nil {
+		return q, err
+	}
+	requestBytes, err := quotaInt(getenv, "QUOTA_MAX_REQUEST_BYTES")
+	if err != nil {
+		return q, err
+	}
+	q.MaxRequestBytes = int64(requestBytes)
+	if q.MaxHitsPerMock, err = quotaInt(getenv, "QUOTA_MAX_HITS_PER_MOCK"); err != nil {
+		return q, err
+	}
+	if q.ManagementRate, err = quotaRate(getenv, "QUOTA_MANAGEMENT_RATE"); err != nil {
+		return q, err
+	}
+	if q.ServingRate, err = quotaRate(getenv, "QUOTA_SERVING_RATE"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// quotaValue returns the variable's value, treating the Worker's
+// "<undefined>" as unset.
+func quotaValue(getenv func(string) string, name string) string {
+	v := strings.TrimSpace(getenv(name))
+	if v == "<undefined>" {
+		return ""
+	}
+	return v
+}
+
+func quotaInt(getenv func(string) string, name string) (int, error) {
+	v := quotaValue(getenv, name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, v)
+	}
+	return n, nil
+}
+
+func quotaRate(getenv func(string) string, name string) (*domain.RateLimit, error) {
+	v := quotaValue(getenv, name)
+	if v == "" {
+		return nil, nil
+	}
+	requests, period, ok := strings.Cut(v, "/")
+	n, err := strconv.Atoi(requests)
+	if !ok || err != nil || n < 1 {
+		return nil, fmt.Errorf("%s must be REQUESTS/PERIOD, such as 60/1m, got %q", name, v)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d < time.Second || d%time.Second != 0 {
+		return nil, fmt.Errorf("%s must have a period of whole seconds, such as 60/1m, got %q", name, v)
+	}
+	return &domain.RateLimit{Requests: n, PeriodSeconds: int(d / time.Second)}, nil
+}
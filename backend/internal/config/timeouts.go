@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default timeouts. A request may wait out an override's delay of up to
+// 30 seconds, so requests get longer than that.
+const (
+	DefaultQueryTimeout       = 5 * time.Second
+	DefaultTransactionTimeout = 15 * time.Second
+	DefaultRequestTimeout     = time.Minute
+	DefaultShutdownTimeout    = 30 * time.Second
+)
+
+// Timeouts bound how long operations may run. Zero means no limit.
+type Timeouts struct {
+	// Query bounds each repository call.
+	Query time.Duration
+	// Transaction bounds a whole repository transaction, including the
+	// calls made inside it.
+	Transaction time.Duration
+	// Request bounds the handling of an HTTP request.
+	Request time.Duration
+	// Shutdown is how long in-flight requests may take to finish once the
+	// server is asked to stop, before their connections are closed.
+	Shutdown time.Duration
+}
+
+// LoadTimeouts reads QUERY_TIMEOUT, TX_TIMEOUT, REQUEST_TIMEOUT and
+// SHUTDOWN_TIMEOUT through getenv. Values are durations such as 5s; unset
+// variables take the defaults and 0 disables a timeout.
+func LoadTimeouts(getenv func(string) string) (Timeouts, error) {
+	t := Timeouts{
+		Query:       DefaultQueryTimeout,
+		Transaction: DefaultTransactionTimeout,
+		Request:     DefaultRequestTimeout,
+		Shutdown:    DefaultShutdownTimeout,
+	}
+	for _, v := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"QUERY_TIMEOUT", &t.Query},
+		{"TX_TIMEOUT", &t.Transaction},
+		{"REQUEST_TIMEOUT", &t.Request},
+		{"SHUTDOWN_TIMEOUT", &t.Shutdown},
+	} {
+		raw := quotaValue(getenv, v.name)
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return t, fmt.Errorf("%s must be a duration such as 5s, got %q", v.name, raw)
+		}
+		*v.dst = d
+	}
+	return t, nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultServiceName names the service in exported spans unless
+// OTEL_SERVICE_NAME is set.
+const DefaultServiceName = "mock-api"
+
+// Tracing selects where spans are exported. An empty Endpoint disables
+// exporting.
+type Tracing struct {
+	// Endpoint is the full OTLP/HTTP traces URL.
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+}
+
+// LoadTracing reads the standard OpenTelemetry exporter variables through
+// getenv: OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, or OTEL_EXPORTER_OTLP_ENDPOINT
+// with /v1/traces appended, OTEL_EXPORTER_OTLP_HEADERS as comma-separated
+// key=value pairs, and OTEL_SERVICE_NAME.
+func LoadTracing(getenv func(string) string) (Tracing, error) {
+	t := Tracing{
+		Endpoint:    quotaValue(getenv, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		ServiceName: quotaValue(getenv, "OTEL_SERVICE_NAME"),
+	}
+	if t.Endpoint == "" {
+		if base := quotaValue(getenv, "OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			t.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if t.Endpoint != "" {
+		if u, err := url.Parse(t.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return t, fmt.Errorf("OTLP traces endpoint must be an http or https URL, got %q", t.Endpoint)
+		}
+	}
+	if t.ServiceName == "" {
+		t.ServiceName = DefaultServiceName
+	}
+
+	if raw := quotaValue(getenv, "OTEL_EXPORTER_OTLP_HEADERS"); raw != "" {
+		t.Headers = make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return t, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS must be key=value pairs, got %q", pair)
+			}
+			// Values are percent-encoded, as the OpenTelemetry
+			// specification requires.
+			decoded, err := url.PathUnescape(strings.TrimSpace(value))
+			if err != nil {
+				return t, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %s: %w", key, err)
+			}
+			t.Headers[key] = decoded
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML so CI systems can display drift
+// as failing test cases.
+func WriteJUnit(w io.Writer, r *Report) error {
+	suite := junitTestSuite{
+		Name:      "contract " + r.BaseURL,
+		Tests:     len(r.Results),
+		Timestamp: r.GeneratedAt.Format("2006-01-02T15:04:05"),
+	}
+
+	var total float64
+	for _, res := range r.Results {
+		seconds := res.Duration.Seconds()
+		total += seconds
+
+		tc := junitTestCase{
+			Name:      res.Method + " " + res.Path,
+			ClassName: "mock." + res.MockID,
+			Time:      fmt.Sprintf("%.3f", seconds),
+		}
+		switch {
+		case res.Error != "":
+			suite.Errors++
+			tc.Error = &junitMessage{Message: res.Error, Body: res.Error}
+		case len(res.Drifts) > 0:
+			suite.Failures++
+			lines := make([]string, len(res.Drifts))
+			for i, d := range res.Drifts {
+				lines[i] = d.String()
+			}
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d drift(s) from mock", len(res.Drifts)),
+				Body:    strings.Join(lines, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,156 @@
+package contract
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"mock-api-backend/internal/domain"
+)
+
+// DefaultParamValue fills path parameters that Verifier.Params has no value
+// for.
+const DefaultParamValue = "1"
+
+// newRequest builds the request that replays mock: path parameters are
+// filled in from params, and query parameters, headers and cookies are set
+// so that the mock's match rules hold.
+func newRequest(mock *domain.MockAPI, baseURL string, params map[string]string) (*http.Request, error) {
+	segments := strings.Split(mock.Path, "/")
+	for i, seg := range segments {
+		if name, ok := pathParam(seg); ok {
+			value, ok := params[name]
+			if !ok {
+				value = DefaultParamValue
+			}
+			segments[i] = url.PathEscape(value)
+		}
+	}
+
+	req, err := http.NewRequest(mock.Method, baseURL+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if mock.Match.Empty() {
+		return req, nil
+	}
+
+	query := req.URL.Query()
+	for _, rule := range mock.Match.Query {
+		if value, ok, err := sampleValue(rule); err != nil {
+			return nil, fmt.Errorf("query %s: %w", rule.Name, err)
+		} else if ok {
+			query.Set(rule.Name, value)
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+	for _, rule := range mock.Match.Headers {
+		if value, ok, err := sampleValue(rule); err != nil {
+			return nil, fmt.Errorf("header %s: %w", rule.Name, err)
+		} else if ok {
+			req.Header.Set(rule.Name, value)
+		}
+	}
+	for _, rule := range mock.Match.Cookies {
+		if value, ok, err := sampleValue(rule); err != nil {
+			return nil, fmt.Errorf("cookie %s: %w", rule.Name, err)
+		} else if ok {
+			req.AddCookie(&http.Cookie{Name: rule.Name, Value: value})
+		}
+	}
+	return req, nil
+}
+
+// pathParam returns the name of a ":id" or "{id}" path segment.
+func pathParam(segment string) (string, bool) {
+	if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+		return segment[1:], true
+	}
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(segment) > 2 {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// sampleValue returns a value satisfying rule, or false when the rule asks
+// for the value to be absent.
+func sampleValue(rule domain.MatchRule) (string, bool, error) {
+	switch rule.Operator() {
+	case domain.MatchAbsent:
+		return "", false, nil
+	case domain.MatchPresent:
+		return DefaultParamValue, true, nil
+	case domain.MatchRegex:
+		value, err := sampleMatching(rule.Value)
+		return value, err == nil, err
+	}
+	return rule.Value, true, nil
+}
+
+// sampleMatching returns a string that the whole of pattern matches, as
+// the mock matcher anchors it.
+func sampleMatching(pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeSample(&b, re.Simplify())
+	sample := b.String()
+	if ok, _ := regexp.MatchString(`^(?:`+pattern+`)$`, sample); !ok {
+		return "", fmt.Errorf("no sample value matches %q", pattern)
+	}
+	return sample, nil
+}
+
+// writeSample writes the shortest string re matches, taking the first
+// alternative and the lowest character of each class. Assertions such as
+// word boundaries are ignored; sampleMatching checks the result.
+func writeSample(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) > 0 {
+			b.WriteRune(classSample(re.Rune))
+		}
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		b.WriteByte('a')
+	case syntax.OpCapture, syntax.OpPlus:
+		writeSample(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for range re.Min {
+			writeSample(b, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeSample(b, sub)
+		}
+	case syntax.OpAlternate:
+		writeSample(b, re.Sub[0])
+	}
+}
+
+// classSample picks a printable character from a character class given as
+// ranges, preferring letters and digits.
+func classSample(ranges []rune) rune {
+	for i := 0; i < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r < ranges[i]+128; r++ {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+		}
+	}
+	for i := 0; i < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r < ranges[i]+128; r++ {
+			if unicode.IsPrint(r) && r != ' ' {
+				return r
+			}
+		}
+	}
+	return ranges[0]
+}
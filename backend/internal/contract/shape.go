@@ -0,0 +1,101 @@
+package contract
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// compareShape compares the structure of two decoded JSON documents. Values
+// only need to agree on type; a null in the mock matches anything. Arrays are
+// compared through their first element.
+func compareShape(pointer string, expected, actual any) []Drift {
+	if expected == nil {
+		return nil
+	}
+
+	expectedType, actualType := jsonType(expected), jsonType(actual)
+	if expectedType != actualType {
+		return []Drift{{
+			Kind:     DriftBody,
+			Pointer:  pointerOrRoot(pointer),
+			Expected: expectedType,
+			Actual:   actualType,
+		}}
+	}
+
+	var drifts []Drift
+	switch e := expected.(type) {
+	case map[string]any:
+		a := actual.(map[string]any)
+		for _, key := range sortedKeys(e) {
+			child := pointer + "/" + escapePointer(key)
+			av, ok := a[key]
+			if !ok {
+				drifts = append(drifts, Drift{
+					Kind:     DriftBody,
+					Pointer:  child,
+					Expected: jsonType(e[key]),
+					Actual:   "missing",
+				})
+				continue
+			}
+			drifts = append(drifts, compareShape(child, e[key], av)...)
+		}
+		for _, key := range sortedKeys(a) {
+			if _, ok := e[key]; !ok {
+				drifts = append(drifts, Drift{
+					Kind:     DriftBody,
+					Pointer:  pointer + "/" + escapePointer(key),
+					Expected: "missing",
+					Actual:   jsonType(a[key]),
+				})
+			}
+		}
+	case []any:
+		a := actual.([]any)
+		if len(e) > 0 && len(a) > 0 {
+			drifts = append(drifts, compareShape(pointer+"/"+strconv.Itoa(0), e[0], a[0])...)
+		}
+	}
+	return drifts
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func pointerOrRoot(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
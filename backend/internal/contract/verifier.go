@@ -0,0 +1,198 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// maxResponseSize caps how much of a provider response is read.
+const maxResponseSize = 10 << 20
+
+// Drift kinds reported by the verifier.
+const (
+	DriftStatus = "status"
+	DriftHeader = "header"
+	DriftBody   = "body"
+)
+
+// Drift is one difference between a mock and the real provider.
+type Drift struct {
+	Kind     string `json:"kind"`
+	Pointer  string `json:"pointer,omitempty"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (d Drift) String() string {
+	if d.Pointer != "" {
+		return fmt.Sprintf("%s %s: expected %s, got %s", d.Kind, d.Pointer, d.Expected, d.Actual)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", d.Kind, d.Expected, d.Actual)
+}
+
+// Result is the outcome of replaying a single mock against the provider.
+type Result struct {
+	MockID         string        `json:"mock_id"`
+	Method         string        `json:"method"`
+	Path           string        `json:"path"`
+	ExpectedStatus int           `json:"expected_status"`
+	ActualStatus   int           `json:"actual_status,omitempty"`
+	Drifts         []Drift       `json:"drifts,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	Duration       time.Duration `json:"duration_ns"`
+}
+
+// OK reports whether the provider answered and matched the mock.
+func (r Result) OK() bool {
+	return r.Error == "" && len(r.Drifts) == 0
+}
+
+// Report is the drift report for a set of mocks.
+type Report struct {
+	BaseURL     string    `json:"base_url"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Results     []Result  `json:"results"`
+}
+
+// Failed counts results that drifted or could not be checked.
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.OK() {
+			n++
+		}
+	}
+	return n
+}
+
+// Verifier replays mocks against a real provider and compares the responses.
+type Verifier struct {
+	BaseURL string
+	Client  *http.Client
+	// Headers are added to every request, e.g. provider credentials. A
+	// mock's header match rules take precedence.
+	Headers http.Header
+	// Params are the values of path parameters by name, e.g. "id" for
+	// /users/:id. Parameters without one are sent as DefaultParamValue.
+	Params map[string]string
+}
+
+func NewVerifier(baseURL string, client *http.Client) *Verifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Verifier{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Client:  client,
+		Headers: make(http.Header),
+		Params:  make(map[string]string),
+	}
+}
+
+// Verify replays every mock in order and returns the drift report.
+func (v *Verifier) Verify(ctx context.Context, mocks []*domain.MockAPI) *Report {
+	report := &Report{
+		BaseURL:     v.BaseURL,
+		GeneratedAt: time.Now().UTC(),
+		Results:     make([]Result, 0, len(mocks)),
+	}
+	for _, mock := range mocks {
+		report.Results = append(report.Results, v.verifyOne(ctx, mock))
+	}
+	return report
+}
+
+func (v *Verifier) verifyOne(ctx context.Context, mock *domain.MockAPI) (res Result) {
+	res = Result{
+		MockID:         mock.ID,
+		Method:         mock.Method,
+		Path:           mock.Path,
+		ExpectedStatus: mock.Status,
+	}
+
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start) }()
+
+	req, err := newRequest(mock, v.BaseURL, v.Params)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	req = req.WithContext(ctx)
+	for k, vs := range v.Headers {
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+		for _, val := range vs {
+			req.Header.Add(k, val)
+		}
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to read response: %v", err)
+		return res
+	}
+
+	res.ActualStatus = resp.StatusCode
+	if resp.StatusCode != mock.Status {
+		res.Drifts = append(res.Drifts, Drift{
+			Kind:     DriftStatus,
+			Expected: fmt.Sprint(mock.Status),
+			Actual:   fmt.Sprint(resp.StatusCode),
+		})
+	}
+
+	var expected any
+	if err := json.Unmarshal([]byte(mock.ResponseBody), &expected); err != nil {
+		// Mock bodies that aren't JSON have no shape to compare.
+		return res
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		res.Drifts = append(res.Drifts, Drift{
+			Kind:     DriftHeader,
+			Pointer:  "Content-Type",
+			Expected: "application/json",
+			Actual:   quoteOrNone(resp.Header.Get("Content-Type")),
+		})
+	}
+
+	var actual any
+	if err := json.Unmarshal(body, &actual); err != nil {
+		res.Drifts = append(res.Drifts, Drift{
+			Kind:     DriftBody,
+			Expected: "JSON",
+			Actual:   "non-JSON body",
+		})
+		return res
+	}
+	res.Drifts = append(res.Drifts, compareShape("", expected, actual)...)
+	return res
+}
+
+func quoteOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
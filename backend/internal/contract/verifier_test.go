@@ -0,0 +1,129 @@
+package contract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+)
+
+// provider is a fake real API: GET /users/{id} answers a user when the
+// request carries what the mocks' match rules ask for.
+func provider(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"` + r.PathValue("id") + `","name":"Ada","tags":["admin"]}`))
+	})
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("session")
+		if r.URL.Query().Get("q") != "ada" || r.Header.Get("X-Api-Version") == "" || cookie == nil ||
+			!regexp.MustCompile(`^v[0-9]+$`).MatchString(r.URL.Query().Get("v")) || r.URL.Query().Has("debug") {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`[{"id":"1"}]`))
+	})
+	mux.HandleFunc("GET /text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(`{"ok":true}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVerify(t *testing.T) {
+	srv := provider(t)
+
+	tests := []struct {
+		name   string
+		mock   *domain.MockAPI
+		params map[string]string
+		want   []Drift
+	}{
+		{
+			name: "path parameter",
+			mock: &domain.MockAPI{Method: "GET", Path: "/users/:id", Status: 200,
+				ResponseBody: `{"id":"7","name":"Grace","tags":["ops"]}`},
+		},
+		{
+			name:   "braced path parameter with a value",
+			mock:   &domain.MockAPI{Method: "GET", Path: "/users/{id}", Status: 200, ResponseBody: `{"id":"42"}`},
+			params: map[string]string{"id": "42"},
+			want: []Drift{
+				{Kind: DriftBody, Pointer: "/name", Expected: "missing", Actual: "string"},
+				{Kind: DriftBody, Pointer: "/tags", Expected: "missing", Actual: "array"},
+			},
+		},
+		{
+			name: "match rules",
+			mock: &domain.MockAPI{Method: "GET", Path: "/search", Status: 200, ResponseBody: `[{"id":"2"}]`,
+				Match: &domain.MatchRules{
+					Query: []domain.MatchRule{
+						{Name: "q", Value: "ada"},
+						{Name: "v", Op: domain.MatchRegex, Value: `v[0-9]+`},
+						{Name: "debug", Op: domain.MatchAbsent},
+					},
+					Headers: []domain.MatchRule{{Name: "X-Api-Version", Op: domain.MatchPresent}},
+					Cookies: []domain.MatchRule{{Name: "session", Op: domain.MatchContains, Value: "abc"}},
+				}},
+		},
+		{
+			name: "status",
+			mock: &domain.MockAPI{Method: "GET", Path: "/missing", Status: 200, ResponseBody: "not JSON"},
+			want: []Drift{{Kind: DriftStatus, Expected: "200", Actual: "404"}},
+		},
+		{
+			name: "content type",
+			mock: &domain.MockAPI{Method: "GET", Path: "/text", Status: 200, ResponseBody: `{"ok":false}`},
+			want: []Drift{{Kind: DriftHeader, Pointer: "Content-Type", Expected: "application/json", Actual: "text/plain"}},
+		},
+		{
+			name: "body shape",
+			mock: &domain.MockAPI{Method: "GET", Path: "/users/1", Status: 200,
+				ResponseBody: `{"id":1,"name":"Ada","tags":[{"name":"admin"}]}`},
+			want: []Drift{
+				{Kind: DriftBody, Pointer: "/id", Expected: "number", Actual: "string"},
+				{Kind: DriftBody, Pointer: "/tags/0", Expected: "object", Actual: "string"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(srv.URL, srv.Client())
+			for name, value := range tt.params {
+				v.Params[name] = value
+			}
+			report := v.Verify(context.Background(), []*domain.MockAPI{tt.mock})
+			res := report.Results[0]
+			if res.Error != "" {
+				t.Fatalf("error: %s", res.Error)
+			}
+			if !reflect.DeepEqual(res.Drifts, tt.want) {
+				t.Errorf("drifts = %v, want %v", res.Drifts, tt.want)
+			}
+			if wantFailed := min(len(tt.want), 1); report.Failed() != wantFailed {
+				t.Errorf("Failed() = %d, want %d", report.Failed(), wantFailed)
+			}
+		})
+	}
+}
+
+func TestSampleMatching(t *testing.T) {
+	for _, pattern := range []string{`v[0-9]+`, `(json|xml)`, `application/.*\+json`, `\d{3}-[A-Z]{2}`, `a?b*c`} {
+		sample, err := sampleMatching(pattern)
+		if err != nil {
+			t.Errorf("sampleMatching(%q): %v", pattern, err)
+			continue
+		}
+		if !regexp.MustCompile(`^(?:` + pattern + `)$`).MatchString(sample) {
+			t.Errorf("sampleMatching(%q) = %q, which does not match", pattern, sample)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import "slices"
+
+// CORSOverride replaces the CORS headers of a mock's responses, and of the
+// answers to preflight requests for its path and method, e.g. to make a
+// browser reject them on purpose. A nil field keeps the header the server
+// would send; an empty string, false or a negative max age removes it.
+type CORSOverride struct {
+	AllowOrigin      *string `json:"allow_origin,omitempty"`
+	AllowMethods     *string `json:"allow_methods,omitempty"`
+	AllowHeaders     *string `json:"allow_headers,omitempty"`
+	ExposeHeaders    *string `json:"expose_headers,omitempty"`
+	AllowCredentials *bool   `json:"allow_credentials,omitempty"`
+	MaxAge           *int    `json:"max_age,omitempty"`
+}
+
+// Clone returns a deep copy of c.
+func (c *CORSOverride) Clone() *CORSOverride {
+	if c == nil {
+		return nil
+	}
+	return &CORSOverride{
+		AllowOrigin:      clonePtr(c.AllowOrigin),
+		AllowMethods:     clonePtr(c.AllowMethods),
+		AllowHeaders:     clonePtr(c.AllowHeaders),
+		ExposeHeaders:    clonePtr(c.ExposeHeaders),
+		AllowCredentials: clonePtr(c.AllowCredentials),
+		MaxAge:           clonePtr(c.MaxAge),
+	}
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// CORSPolicy is a user's CORS policy for served mocks, replacing the
+// server's allowed origins. It is applied as written, without the fixes a
+// careful server would make, so a broken policy, e.g. "*" with
+// credentials, fails in browsers as it would in the API being mocked.
+type CORSPolicy struct {
+	// AllowedOrigins are the origins CORS headers are sent to. "*" allows
+	// any origin and is sent as "*"; other origins are echoed.
+	AllowedOrigins []string `json:"allowed_origins"`
+	// AllowedMethods and AllowedHeaders answer preflight requests.
+	AllowedMethods   []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty"`
+	ExposedHeaders   []string `json:"exposed_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty"`
+	// MaxAge is how long, in seconds, browsers may cache a preflight
+	// answer; 0 omits the header.
+	MaxAge int `json:"max_age,omitempty"`
+}
+
+// Clone returns a deep copy of p.
+func (p *CORSPolicy) Clone() *CORSPolicy {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	clone.AllowedOrigins = slices.Clone(p.AllowedOrigins)
+	clone.AllowedMethods = slices.Clone(p.AllowedMethods)
+	clone.AllowedHeaders = slices.Clone(p.AllowedHeaders)
+	clone.ExposedHeaders = slices.Clone(p.ExposedHeaders)
+	return &clone
+}
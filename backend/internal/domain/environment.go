@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// MockOverride replaces parts of a mock's response while its environment is
+// in effect. Zero fields keep the mock's own response.
+type MockOverride struct {
+	UserID      string `json:"user_id"`
+	Environment string `json:"environment"`
+	MockID      string `json:"mock_id"`
+	// Status replaces the mock's status unless it is 0.
+	Status int `json:"status,omitempty"`
+	// ResponseBody replaces the mock's body unless it is nil.
+	ResponseBody *string `json:"response_body,omitempty"`
+	// DelayMs delays the response by that many milliseconds.
+	DelayMs   int       `json:"delay_ms,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,8 +1,59 @@
 package domain
 
-import "errors"
+import "strings"
+
+// Error is a domain error with a stable code. Clients should match on the
+// code rather than the message, which may change.
+//
+// The Err* values are the kinds of error. Wrap one with fmt.Errorf and %w
+// to add detail, or use Invalid to list fields; either way errors.Is
+// matches the kind and errors.As recovers the code and fields.
+type Error struct {
+	Code    string
+	Message string
+	// Fields lists the invalid fields of a validation error.
+	Fields []FieldError
+}
+
+// FieldError says why one field of the input was rejected. Field is the
+// JSON name of the field, with dots and indexes for nested fields.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return e.Message + ": " + strings.Join(parts, "; ")
+}
+
+// Is reports whether target is an Error of the same kind.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Invalid returns an error of kind listing the invalid fields.
+func Invalid(kind *Error, fields ...FieldError) *Error {
+	return &Error{Code: kind.Code, Message: kind.Message, Fields: fields}
+}
 
 var (
-	ErrMockAlreadyExists = errors.New("mock endpoint already exists")
-	ErrMockNotFound      = errors.New("mock endpoint not found")
+	ErrMockAlreadyExists    = &Error{Code: "mock_already_exists", Message: "mock endpoint already exists"}
+	ErrMockNotFound         = &Error{Code: "mock_not_found", Message: "mock endpoint not found"}
+	ErrInvalidRequestSchema = &Error{Code: "invalid_request_schema", Message: "invalid request schema"}
+	ErrBulkRejected         = &Error{Code: "bulk_rejected", Message: "bulk operation rejected"}
+	ErrRevisionNotFound     = &Error{Code: "revision_not_found", Message: "mock revision not found"}
+	ErrInvalidEnvironment   = &Error{Code: "invalid_environment", Message: "invalid environment"}
+	ErrOverrideNotFound     = &Error{Code: "override_not_found", Message: "mock override not found"}
+	ErrInvalidRateLimit     = &Error{Code: "invalid_rate_limit", Message: "invalid rate limit"}
+	ErrQuotaExceeded        = &Error{Code: "quota_exceeded", Message: "quota exceeded"}
+	ErrPayloadTooLarge      = &Error{Code: "payload_too_large", Message: "payload too large"}
+	ErrValidation           = &Error{Code: "validation_failed", Message: "validation failed"}
 )
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Hit records one request received by the serving endpoint. MockID is empty
+// when no mock matched.
+type Hit struct {
+	Seq    int64     `json:"seq"`
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Status int       `json:"status"`
+	MockID string    `json:"mock_id,omitempty"`
+	// Environment is the environment whose override was served, if any.
+	Environment string `json:"environment,omitempty"`
+}
+
+// HitsPerUser is how many recent hits a HitStore keeps for each user, at
+// least.
+const HitsPerUser = 200
+
+// HitStore keeps the recent serving hits of each user, for tailing.
+type HitStore interface {
+	// Record stores a hit, assigning its sequence number, and its time if
+	// it has none. Sequence numbers only grow.
+	Record(ctx context.Context, userID string, hit Hit) error
+	// Since returns up to limit of the user's hits with a sequence number
+	// greater than after, oldest first. A limit of 0 returns all of them.
+	Since(ctx context.Context, userID string, after int64, limit int) ([]Hit, error)
+}
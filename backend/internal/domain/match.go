@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Operators of a MatchRule.
+const (
+	MatchEquals   = "equals"
+	MatchContains = "contains"
+	MatchRegex    = "regex"
+	MatchPresent  = "present"
+	MatchAbsent   = "absent"
+)
+
+// MatchRules narrow the requests a mock answers beyond its method and
+// path. Every rule must hold for the mock to be served. Header names are
+// case-insensitive and stored in canonical form, e.g. X-Api-Version.
+type MatchRules struct {
+	Query   []MatchRule `json:"query,omitempty"`
+	Headers []MatchRule `json:"headers,omitempty"`
+	Cookies []MatchRule `json:"cookies,omitempty"`
+}
+
+// MatchRule is a condition on one named value of a request, such as a
+// query parameter. Op is one of the Match* operators and defaults to
+// equals; Value is the string or regular expression compared with.
+type MatchRule struct {
+	Name  string `json:"name"`
+	Op    string `json:"op,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// Operator returns the rule's operator, filling in the default.
+func (r MatchRule) Operator() string {
+	if r.Op == "" {
+		return MatchEquals
+	}
+	return r.Op
+}
+
+// String formats the rule like a query parameter: name=value for equals,
+// name*=value for contains, name~value for regex, name for present and
+// !name for absent.
+func (r MatchRule) String() string {
+	switch r.Operator() {
+	case MatchContains:
+		return r.Name + "*=" + r.Value
+	case MatchRegex:
+		return r.Name + "~" + r.Value
+	case MatchPresent:
+		return r.Name
+	case MatchAbsent:
+		return "!" + r.Name
+	}
+	return r.Name + "=" + r.Value
+}
+
+// Empty reports whether there are no rules, which matches every request.
+func (m *MatchRules) Empty() bool {
+	return m == nil || m.Count() == 0
+}
+
+// Count returns the number of rules.
+func (m *MatchRules) Count() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.Query) + len(m.Headers) + len(m.Cookies)
+}
+
+// String formats the rules canonically, in sorted order, so that two sets
+// of rules matching the same requests format the same. It reads as a
+// suffix of the mock's path, e.g. "?q=a header[Accept*=json]", and is ""
+// when there are no rules.
+func (m *MatchRules) String() string {
+	if m.Empty() {
+		return ""
+	}
+	var b strings.Builder
+	if len(m.Query) > 0 {
+		b.WriteString("?" + formatRules(m.Query, "&"))
+	}
+	if len(m.Headers) > 0 {
+		b.WriteString(" header[" + formatRules(m.Headers, ", ") + "]")
+	}
+	if len(m.Cookies) > 0 {
+		b.WriteString(" cookie[" + formatRules(m.Cookies, ", ") + "]")
+	}
+	return b.String()
+}
+
+func formatRules(rules []MatchRule, sep string) string {
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		parts[i] = r.String()
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, sep)
+}
+
+// Key returns an encoding of the rules that, unlike String, differs for any
+// two sets of rules that differ other than in order or in leaving the
+// operator to its default. It is "" when there are no rules.
+func (m *MatchRules) Key() string {
+	if m.Empty() {
+		return ""
+	}
+	key, _ := json.Marshal([][]MatchRule{canonicalRules(m.Query), canonicalRules(m.Headers), canonicalRules(m.Cookies)})
+	return string(key)
+}
+
+// canonicalRules returns rules sorted, with their operators filled in.
+func canonicalRules(rules []MatchRule) []MatchRule {
+	out := make([]MatchRule, len(rules))
+	for i, r := range rules {
+		r.Op = r.Operator()
+		out[i] = r
+	}
+	slices.SortFunc(out, func(a, b MatchRule) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Op, b.Op), cmp.Compare(a.Value, b.Value))
+	})
+	return out
+}
+
+// Clone returns a deep copy of m.
+func (m *MatchRules) Clone() *MatchRules {
+	if m == nil {
+		return nil
+	}
+	return &MatchRules{
+		Query:   slices.Clone(m.Query),
+		Headers: slices.Clone(m.Headers),
+		Cookies: slices.Clone(m.Cookies),
+	}
+}
+
+// MatchCandidate is a mock considered for a request that was not served,
+// with the reasons why. A candidate whose only mismatch is its precedence
+// matched but was outranked by a more specific mock.
+type MatchCandidate struct {
+	MockID     string          `json:"mock_id"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Match      string          `json:"match,omitempty"`
+	Mismatches []MatchMismatch `json:"mismatches"`
+}
+
+// MatchMismatch is one reason a mock did not match. Part is method, path,
+// query, header, cookie or precedence; Name is the query parameter, header
+// or cookie concerned.
+type MatchMismatch struct {
+	Part    string `json:"part"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
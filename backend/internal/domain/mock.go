@@ -1,17 +1,47 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type MockAPI struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	Path         string    `json:"path"`
-	Method       string    `json:"method"`
-	Status       int       `json:"status"`
-	ResponseBody string    `json:"response_body"`
-	CreatedAt    time.Time `json:"created_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	HitCount     int       `json:"hit_count"`
+	ID            string         `json:"id"`
+	UserID        string         `json:"user_id"`
+	Path          string         `json:"path"`
+	Method        string         `json:"method"`
+	Status        int            `json:"status"`
+	ResponseBody  string         `json:"response_body"`
+	RequestSchema *RequestSchema `json:"request_schema,omitempty"`
+	RateLimit     *RateLimit     `json:"rate_limit,omitempty"`
+	Match         *MatchRules    `json:"match,omitempty"`
+	CORS          *CORSOverride  `json:"cors,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	HitCount      int            `json:"hit_count"`
+}
+
+// RequestSchema holds the JSON Schemas an incoming request must satisfy
+// before the mock response is served. Every part is optional.
+type RequestSchema struct {
+	Body    json.RawMessage `json:"body,omitempty"`
+	Query   json.RawMessage `json:"query,omitempty"`
+	Headers json.RawMessage `json:"headers,omitempty"`
+	// ErrorStatus is returned for non-conforming requests: 400 (default) or 422.
+	ErrorStatus int `json:"error_status,omitempty"`
+}
+
+// SchemaViolation describes one place where a request did not conform to
+// its mock's RequestSchema.
+type SchemaViolation struct {
+	Location string `json:"location"`
+	Pointer  string `json:"pointer"`
+	Message  string `json:"message"`
+}
+
+// MockStats counts mocks across all users. Expired mocks are those past
+// their ExpiresAt that cleanup has not removed yet.
+type MockStats struct {
+	Active  int
+	Expired int
 }
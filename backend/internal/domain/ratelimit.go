@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rate limit scopes.
+const (
+	// RateLimitScopeMock gives each mock its own bucket.
+	RateLimitScopeMock = "mock"
+	// RateLimitScopeUser shares one bucket between every user-scoped mock
+	// of a user, i.e. the whole serving namespace.
+	RateLimitScopeUser = "user"
+)
+
+// RateLimit is a token bucket applied when a mock is served. The bucket
+// holds Requests tokens and refills from empty in PeriodSeconds.
+type RateLimit struct {
+	Requests      int    `json:"requests"`
+	PeriodSeconds int    `json:"period_seconds"`
+	Scope         string `json:"scope,omitempty"`
+}
+
+// Rate returns the refill rate in tokens per second.
+func (l RateLimit) Rate() float64 {
+	return float64(l.Requests) / float64(l.PeriodSeconds)
+}
+
+// Decide reports the outcome of a take that left tokens in the bucket.
+func (l RateLimit) Decide(tokens float64, allowed bool) RateLimitDecision {
+	d := RateLimitDecision{
+		Allowed:   allowed,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     seconds((float64(l.Requests) - tokens) / l.Rate()),
+	}
+	if !allowed {
+		d.RetryAfter = seconds((1 - tokens) / l.Rate())
+	}
+	return d
+}
+
+func seconds(s float64) time.Duration {
+	return max(0, time.Duration(s*float64(time.Second)))
+}
+
+// RateLimitDecision is the outcome of taking a token from a bucket.
+type RateLimitDecision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available, when denied.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// RateLimitStore keeps the token buckets of rate-limited mocks. Take
+// removes one token from the bucket at key, which starts full, and must be
+// atomic per key.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitDecision, error)
+}
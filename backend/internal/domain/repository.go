@@ -1,11 +1,46 @@
 package domain
 
+import "context"
+
 type MockRepository interface {
-	Save(mock *MockAPI) error
-	GetByUser(userID string) ([]*MockAPI, error)
-	GetByPathAndMethod(userID, path, method string) (*MockAPI, error)
-	Update(mock *MockAPI) error
-	IncrementHitCount(id string) error
-	DeleteExpired() error
-	Delete(userID, id string) error
+	Save(ctx context.Context, mock *MockAPI) error
+	GetByUser(ctx context.Context, userID string) ([]*MockAPI, error)
+	Update(ctx context.Context, mock *MockAPI) error
+	IncrementHitCount(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context) error
+	// Stats counts the mocks of every user.
+	Stats(ctx context.Context) (MockStats, error)
+	// Delete also removes the mock's revisions and overrides, as does
+	// DeleteExpired.
+	Delete(ctx context.Context, userID, id string) error
+	// AddRevision appends a revision. Revisions are never changed.
+	AddRevision(ctx context.Context, rev *MockRevision) error
+	// GetRevisions returns the user's revisions of a mock, oldest first.
+	GetRevisions(ctx context.Context, userID, mockID string) ([]*MockRevision, error)
+	// SaveOverride creates or replaces the override for its user,
+	// environment and mock.
+	SaveOverride(ctx context.Context, o *MockOverride) error
+	// GetOverride returns nil, nil when there is no such override.
+	GetOverride(ctx context.Context, userID, environment, mockID string) (*MockOverride, error)
+	// GetOverrides returns all of the user's overrides, ordered by
+	// environment and then mock ID.
+	GetOverrides(ctx context.Context, userID string) ([]*MockOverride, error)
+	DeleteOverride(ctx context.Context, userID, environment, mockID string) error
+	// DeleteEnvironment removes every override in the environment.
+	DeleteEnvironment(ctx context.Context, userID, environment string) error
+	// SetActiveEnvironment selects the environment served by default; ""
+	// goes back to the mocks' own responses.
+	SetActiveEnvironment(ctx context.Context, userID, environment string) error
+	// GetActiveEnvironment returns "" when no environment is active.
+	GetActiveEnvironment(ctx context.Context, userID string) (string, error)
+	// SetCORSPolicy replaces the user's CORS policy for served mocks; nil
+	// goes back to the server's.
+	SetCORSPolicy(ctx context.Context, userID string, policy *CORSPolicy) error
+	// GetCORSPolicy returns nil, nil when the user has no policy.
+	GetCORSPolicy(ctx context.Context, userID string) (*CORSPolicy, error)
+	// WithinTx runs fn against a repository whose writes are applied
+	// atomically: all of them when fn returns nil, none of them otherwise.
+	// Backends without interactive transactions may defer writes until fn
+	// returns, so fn must not rely on reading its own writes.
+	WithinTx(ctx context.Context, fn func(repo MockRepository) error) error
 }
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Revision actions.
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionRestore = "restore"
+)
+
+// MockRevision is an immutable snapshot of a mock's editable fields, taken
+// every time the mock is created, updated or restored. Numbers start at 1
+// and increase per mock.
+type MockRevision struct {
+	MockID        string         `json:"mock_id"`
+	Number        int            `json:"number"`
+	UserID        string         `json:"user_id"`
+	Author        string         `json:"author"`
+	Action        string         `json:"action"`
+	Path          string         `json:"path"`
+	Method        string         `json:"method"`
+	Status        int            `json:"status"`
+	ResponseBody  string         `json:"response_body"`
+	RequestSchema *RequestSchema `json:"request_schema,omitempty"`
+	RateLimit     *RateLimit     `json:"rate_limit,omitempty"`
+	Match         *MatchRules    `json:"match,omitempty"`
+	CORS          *CORSOverride  `json:"cors,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
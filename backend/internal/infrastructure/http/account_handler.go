@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"mock-api-backend/internal/domain"
+)
+
+// CreateAPIKey issues an API key for the current user.
+func (h *MockHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	if h.apiKeys == nil {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "API keys are not enabled")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"user_id": userID,
+		"api_key": h.apiKeys.issue(userID),
+	})
+}
+
+// ListHits returns the user's recent serving hits. Pass the last seen
+// cursor as ?after= to receive only newer hits.
+func (h *MockHandler) ListHits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	hits, err := h.service.RecentHits(r.Context(), userID, after, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if hits == nil {
+		hits = []domain.Hit{}
+	}
+	cursor := after
+	if len(hits) > 0 {
+		cursor = hits[len(hits)-1].Seq
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"hits":   hits,
+		"cursor": cursor,
+	})
+}
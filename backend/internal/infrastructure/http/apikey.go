@@ -0,0 +1,45 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// apiKeyPrefix marks tokens issued by apiKeys.
+const apiKeyPrefix = "mk_"
+
+// apiKeys issues and verifies stateless API keys. A key embeds the user ID
+// and an HMAC of it, so verification needs no storage and works the same in
+// the server and the worker as long as they share the secret.
+type apiKeys struct {
+	secret []byte
+}
+
+func (k *apiKeys) issue(userID string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID))
+	return apiKeyPrefix + payload + "." + k.sign(payload)
+}
+
+// verify returns the user ID a key was issued for.
+func (k *apiKeys) verify(key string) (string, bool) {
+	payload, sig, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), ".")
+	if !ok || !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+	if !hmac.Equal([]byte(sig), []byte(k.sign(payload))) {
+		return "", false
+	}
+	userID, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(userID) == 0 {
+		return "", false
+	}
+	return string(userID), true
+}
+
+func (k *apiKeys) sign(payload string) string {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
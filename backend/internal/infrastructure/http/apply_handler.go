@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"mock-api-backend/internal/manifest"
+	"mock-api-backend/internal/usecase"
+)
+
+// maxManifestSize caps the size of an uploaded manifest.
+const maxManifestSize = 5 << 20
+
+type planStepResponse struct {
+	Action  string   `json:"action"`
+	ID      string   `json:"id,omitempty"`
+	Method  string   `json:"method"`
+	Path    string   `json:"path"`
+	Match   string   `json:"match,omitempty"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+type applyResponse struct {
+	Applied bool               `json:"applied"`
+	DryRun  bool               `json:"dry_run"`
+	Summary map[string]int     `json:"summary"`
+	Steps   []planStepResponse `json:"steps"`
+}
+
+// ApplyMocks makes the user's mocks match a YAML or JSON manifest. Query
+// parameters: prune=true deletes mocks missing from the manifest, and
+// dry_run=true only returns the plan.
+func (h *MockHandler) ApplyMocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	prune, _ := strconv.ParseBool(r.URL.Query().Get("prune"))
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxManifestSize))
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	m, err := manifest.Parse(data)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+	inputs, err := m.Inputs()
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	author := getAuthor(r)
+	for i := range inputs {
+		inputs[i].Author = author
+	}
+
+	plan, err := h.service.Apply(r.Context(), userID, inputs, prune, dryRun)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := applyResponse{
+		Applied: plan.Applied,
+		DryRun:  dryRun,
+		Summary: map[string]int{
+			usecase.PlanCreate:    plan.Count(usecase.PlanCreate),
+			usecase.PlanUpdate:    plan.Count(usecase.PlanUpdate),
+			usecase.PlanDelete:    plan.Count(usecase.PlanDelete),
+			usecase.PlanUnchanged: plan.Count(usecase.PlanUnchanged),
+		},
+		Steps: make([]planStepResponse, len(plan.Steps)),
+	}
+	for i, st := range plan.Steps {
+		resp.Steps[i] = planStepResponse{
+			Action:  st.Action,
+			ID:      st.ID,
+			Method:  st.Method,
+			Path:    st.Path,
+			Match:   st.Match,
+			Changes: st.Changes,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
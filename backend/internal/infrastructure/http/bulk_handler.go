@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+type bulkRequest struct {
+	Operations []struct {
+		Action string      `json:"action"`
+		ID     string      `json:"id,omitempty"`
+		Mock   mockRequest `json:"mock"`
+	} `json:"operations"`
+}
+
+type bulkResultResponse struct {
+	Index  int    `json:"index"`
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Code and Errors describe Error like the fields of a problem.
+	Code   string              `json:"code,omitempty"`
+	Errors []domain.FieldError `json:"errors,omitempty"`
+	Mock   *domain.MockAPI     `json:"mock,omitempty"`
+}
+
+// bulkProblem reports a rejected batch with the result of every operation.
+type bulkProblem struct {
+	problem
+	Applied bool                 `json:"applied"`
+	Results []bulkResultResponse `json:"results"`
+}
+
+// BulkMocks applies many creates, updates and deletes atomically. A
+// rejected batch changes nothing and reports the failing operations.
+func (h *MockHandler) BulkMocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	var req bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	author := getAuthor(r)
+	ops := make([]usecase.BulkOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = usecase.BulkOperation{
+			Action: op.Action,
+			ID:     op.ID,
+			Mock:   op.Mock.input(),
+		}
+		ops[i].Mock.Author = author
+	}
+
+	results, err := h.service.ApplyBulk(r.Context(), userID, ops)
+	if err != nil && !errors.Is(err, domain.ErrBulkRejected) {
+		writeError(w, r, err)
+		return
+	}
+	if err != nil && results == nil {
+		writeProblem(w, r, http.StatusBadRequest, domain.ErrBulkRejected.Code, err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	responses := make([]bulkResultResponse, len(results))
+	for i, res := range results {
+		responses[i] = bulkResultResponse{
+			Index:  res.Index,
+			Action: res.Action,
+			ID:     res.ID,
+			Status: res.Status,
+			Mock:   res.Mock,
+		}
+		if res.Err == nil {
+			continue
+		}
+		responses[i].Error = res.Err.Error()
+		var de *domain.Error
+		if errors.As(res.Err, &de) {
+			responses[i].Code = de.Code
+			responses[i].Errors = de.Fields
+		}
+		// Conflicts take precedence over quotas, and quotas over other
+		// invalid operations.
+		switch {
+		case errors.Is(res.Err, domain.ErrMockAlreadyExists) || errors.Is(res.Err, domain.ErrMockNotFound):
+			status = http.StatusConflict
+		case status == http.StatusConflict:
+		case quotaStatus(res.Err) != 0:
+			status = quotaStatus(res.Err)
+		case status == http.StatusOK:
+			status = http.StatusBadRequest
+		}
+	}
+
+	if err != nil {
+		if status == http.StatusOK {
+			status = http.StatusConflict
+		}
+		sendProblem(w, status, bulkProblem{
+			problem: newProblem(r, status, domain.ErrBulkRejected.Code, err.Error()),
+			Results: responses,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"applied": true,
+		"results": responses,
+	})
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+// servingCORS sets the CORS headers of served mocks from the user's CORS
+// policy, or as corsHeaders does for allowedOrigins when there is none.
+func (h *MockHandler) servingCORS(allowedOrigins []string) func(http.Handler) http.Handler {
+	fallback := corsHeaders(allowedOrigins)
+	return func(next http.Handler) http.Handler {
+		withFallback := fallback(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Origin") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, known := lookupUserIDFromSubdomain(r)
+			if !known {
+				withFallback.ServeHTTP(w, r)
+				return
+			}
+			policy, err := h.service.ServingCORSPolicy(r.Context(), userID)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if policy == nil {
+				withFallback.ServeHTTP(w, r)
+				return
+			}
+			applyCORSPolicy(w, r, policy)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// applyCORSPolicy sets the CORS headers of a response to a cross-origin
+// request as the policy says, without correcting it: "*" is sent as is,
+// even with credentials.
+func applyCORSPolicy(w http.ResponseWriter, r *http.Request, p *domain.CORSPolicy) {
+	origin := r.Header.Get("Origin")
+	allowAll := slices.Contains(p.AllowedOrigins, "*")
+	if !allowAll {
+		w.Header().Add("Vary", "Origin")
+		if !slices.Contains(p.AllowedOrigins, origin) {
+			return
+		}
+	}
+
+	if allowAll {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(p.ExposedHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+	}
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return
+	}
+	if len(p.AllowedMethods) > 0 {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+	}
+	if len(p.AllowedHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+	}
+	if p.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(p.MaxAge))
+	}
+}
+
+// writeOptions answers an OPTIONS request that no OPTIONS mock matches
+// with the methods mocked for its path in Allow. A CORS preflight gets the
+// CORS override of the mock it asks about, if any. It returns the status
+// written, or false without writing anything when no mock has the path.
+func (h *MockHandler) writeOptions(w http.ResponseWriter, r *http.Request, userID string, req usecase.Request) (int, bool) {
+	route, err := h.service.GetRoute(r.Context(), userID, req.Path)
+	if err != nil {
+		return writeError(w, r, err), true
+	}
+	if len(route.Mocks) == 0 {
+		return 0, false
+	}
+
+	w.Header().Set("Allow", strings.Join(route.Methods, ", "))
+	if method := r.Header.Get("Access-Control-Request-Method"); method != "" {
+		if mock := route.Preflight(strings.ToUpper(method)); mock != nil {
+			applyCORSOverride(w, r, mock.CORS)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return http.StatusNoContent, true
+}
+
+// applyCORSOverride replaces the CORS headers of a response to a
+// cross-origin request as the override says.
+func applyCORSOverride(w http.ResponseWriter, r *http.Request, c *domain.CORSOverride) {
+	if c == nil || r.Header.Get("Origin") == "" {
+		return
+	}
+	set := func(name string, value *string) {
+		switch {
+		case value == nil:
+		case *value == "":
+			w.Header().Del(name)
+		default:
+			w.Header().Set(name, *value)
+		}
+	}
+	set("Access-Control-Allow-Origin", c.AllowOrigin)
+	set("Access-Control-Allow-Methods", c.AllowMethods)
+	set("Access-Control-Allow-Headers", c.AllowHeaders)
+	set("Access-Control-Expose-Headers", c.ExposeHeaders)
+	if c.AllowCredentials != nil {
+		if *c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Del("Access-Control-Allow-Credentials")
+		}
+	}
+	if c.MaxAge != nil {
+		if *c.MaxAge >= 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(*c.MaxAge))
+		} else {
+			w.Header().Del("Access-Control-Max-Age")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mock-api-backend/internal/domain"
+)
+
+// corsPolicyResponse reports a user's CORS policy for served mocks. Policy
+// is null while the server's allowed origins apply.
+type corsPolicyResponse struct {
+	Policy *domain.CORSPolicy `json:"policy"`
+}
+
+// GetCORSPolicy returns the user's CORS policy for served mocks.
+func (h *MockHandler) GetCORSPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	policy, err := h.service.GetCORSPolicy(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(corsPolicyResponse{Policy: policy})
+}
+
+// SetCORSPolicy replaces the user's CORS policy for served mocks with the
+// one in the body, or goes back to the server's on DELETE.
+func (h *MockHandler) SetCORSPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	var policy *domain.CORSPolicy
+	if r.Method != http.MethodDelete {
+		policy = &domain.CORSPolicy{}
+		if err := json.NewDecoder(r.Body).Decode(policy); err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.service.SetCORSPolicy(r.Context(), userID, policy); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(corsPolicyResponse{Policy: policy})
+}
@@ -0,0 +1,178 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+func TestServeMockOptions(t *testing.T) {
+	service := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	origin, methods := "https://app.test", "GET, PUT"
+	maxAge := 600
+	createMock(t, service, usecase.MockInput{Path: "/items/:id", Method: "GET", Status: 200, ResponseBody: `{}`})
+	createMock(t, service, usecase.MockInput{
+		Path: "/items/:id", Method: "PUT", Status: 200, ResponseBody: `{}`,
+		CORS: &domain.CORSOverride{AllowOrigin: &origin, AllowMethods: &methods, MaxAge: &maxAge},
+	})
+	createMock(t, service, usecase.MockInput{Path: "/items/7", Method: "DELETE", Status: 204})
+	createMock(t, service, usecase.MockInput{Path: "/items", Method: "POST", Status: 201, ResponseBody: `{}`})
+	router := newTestRouter(service)
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		status int
+		want   map[string]string // headers, "" for absent
+	}{
+		{
+			name:   "methods mocked for the path",
+			path:   "/items/7",
+			status: http.StatusNoContent,
+			want:   map[string]string{"Allow": "DELETE, GET, HEAD, OPTIONS, PUT", "Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "methods of other paths are left out",
+			path:   "/items/8",
+			status: http.StatusNoContent,
+			want:   map[string]string{"Allow": "GET, HEAD, OPTIONS, PUT"},
+		},
+		{
+			name:   "a preflight gets the override of the mock it asks about",
+			path:   "/items/8",
+			header: http.Header{"Origin": {"https://other.test"}, "Access-Control-Request-Method": {"put"}},
+			status: http.StatusNoContent,
+			want: map[string]string{
+				"Allow":                        "GET, HEAD, OPTIONS, PUT",
+				"Access-Control-Allow-Origin":  origin,
+				"Access-Control-Allow-Methods": methods,
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "a preflight for a method without an override",
+			path:   "/items/8",
+			header: http.Header{"Origin": {"https://other.test"}, "Access-Control-Request-Method": {"GET"}},
+			status: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin": "https://other.test",
+				"Access-Control-Max-Age":      "",
+			},
+		},
+		{
+			name:   "a path nothing is mocked for",
+			path:   "/users",
+			status: http.StatusNotFound,
+			want:   map[string]string{"Allow": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodOptions, "http://u1.api.test"+tt.path, tt.header)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			for name, want := range tt.want {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// countedPolicies is a repository that counts reads of CORS policies.
+type countedPolicies struct {
+	domain.MockRepository
+	reads int
+}
+
+func (r *countedPolicies) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	r.reads++
+	return r.MockRepository.GetCORSPolicy(ctx, userID)
+}
+
+func TestServingCORSPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *domain.CORSPolicy
+		origin string
+		want   map[string]string // headers, "" for absent
+	}{
+		{
+			name:   "the server's settings without a policy",
+			origin: "https://app.test",
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.test",
+				"Access-Control-Allow-Credentials": "true",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "a listed origin is echoed",
+			policy: &domain.CORSPolicy{AllowedOrigins: []string{"https://a.test", "https://app.test"}, ExposedHeaders: []string{"X-Total"}},
+			origin: "https://app.test",
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.test",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Expose-Headers":    "X-Total",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "other origins get no CORS headers",
+			policy: &domain.CORSPolicy{AllowedOrigins: []string{"https://a.test"}},
+			origin: "https://app.test",
+			want:   map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		{
+			name:   "* is sent unchanged, even with credentials",
+			policy: &domain.CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			origin: "https://app.test",
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "true",
+				"Vary":                             "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &countedPolicies{MockRepository: repository.NewInMemoryMockRepository()}
+			service := usecase.NewMockService(repo)
+			createMock(t, service, usecase.MockInput{Path: "/items", Method: "GET", Status: 200, ResponseBody: `[]`})
+			if tt.policy != nil {
+				if err := service.SetCORSPolicy(context.Background(), "u1", tt.policy); err != nil {
+					t.Fatal(err)
+				}
+			}
+			router := newTestRouter(service)
+
+			for range 3 {
+				rec := serve(router, http.MethodGet, "http://u1.api.test/items", http.Header{"Origin": {tt.origin}})
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+				}
+				for name, want := range tt.want {
+					if got := rec.Header().Get(name); got != want {
+						t.Errorf("%s = %q, want %q", name, got, want)
+					}
+				}
+			}
+			// The policy set is served without reading it back, and a
+			// missing one is read once.
+			want := 1
+			if tt.policy != nil {
+				want = 0
+			}
+			if repo.reads != want {
+				t.Errorf("policy read %d times, want %d", repo.reads, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+// EnvironmentHeader selects the environment for one served request,
+// overriding the user's active environment.
+const EnvironmentHeader = "X-Mock-Env"
+
+type environmentResponse struct {
+	Name      string                 `json:"name"`
+	Active    bool                   `json:"active"`
+	Overrides []*domain.MockOverride `json:"overrides"`
+}
+
+// overrideRequest is the JSON body accepted by SetOverride. Omitted fields
+// keep the mock's own response.
+type overrideRequest struct {
+	Status       int     `json:"status,omitempty"`
+	ResponseBody *string `json:"response_body,omitempty"`
+	DelayMs      int     `json:"delay_ms,omitempty"`
+}
+
+// ListEnvironments returns the user's environments with their overrides.
+func (h *MockHandler) ListEnvironments(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	envs, err := h.service.ListEnvironments(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	responses := make([]environmentResponse, len(envs))
+	for i, env := range envs {
+		responses[i] = environmentResponse{Name: env.Name, Active: env.Active, Overrides: env.Overrides}
+		if responses[i].Overrides == nil {
+			responses[i].Overrides = []*domain.MockOverride{}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responses)
+}
+
+// SetActiveEnvironment switches every served mock to the environment named
+// in the body, or back to the mocks' own responses on DELETE.
+func (h *MockHandler) SetActiveEnvironment(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if r.Method != http.MethodDelete {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+		if req.Name == "" {
+			writeError(w, r, domain.Invalid(domain.ErrValidation, domain.FieldError{Field: "name", Message: "is required"}))
+			return
+		}
+	}
+
+	if err := h.service.ActivateEnvironment(r.Context(), userID, req.Name); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"active": req.Name})
+}
+
+// environmentPath splits /api/environments/{name}[/overrides/{mockID}].
+func environmentPath(path string) (name, mockID string, ok bool) {
+	rest := strings.TrimPrefix(path, "/api/environments/")
+	name, rest, found := strings.Cut(rest, "/")
+	if name == "" {
+		return "", "", false
+	}
+	if !found {
+		return name, "", true
+	}
+	mockID, found = strings.CutPrefix(rest, "overrides/")
+	return name, mockID, found && mockID != "" && !strings.Contains(mockID, "/")
+}
+
+// SetOverride creates or replaces the override of a mock in an environment.
+func (h *MockHandler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	name, mockID, ok := environmentPath(r.URL.Path)
+	if !ok || mockID == "" {
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "Expected /api/environments/{name}/overrides/{mock-id}")
+		return
+	}
+
+	var req overrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	o, err := h.service.SetOverride(r.Context(), userID, name, mockID, usecase.OverrideInput{
+		Status:       req.Status,
+		ResponseBody: req.ResponseBody,
+		DelayMs:      req.DelayMs,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(o)
+}
+
+// DeleteEnvironment removes one override, or with no mock ID in the path,
+// the whole environment.
+func (h *MockHandler) DeleteEnvironment(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	name, mockID, ok := environmentPath(r.URL.Path)
+	if !ok {
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "Expected /api/environments/{name}[/overrides/{mock-id}]")
+		return
+	}
+
+	var err error
+	if mockID != "" {
+		err = h.service.DeleteOverride(r.Context(), userID, name, mockID)
+	} else {
+		err = h.service.DeleteEnvironment(r.Context(), userID, name)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Deleted successfully"})
+}
@@ -1,18 +1,63 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"mock-api-backend/internal/domain"
 	"mock-api-backend/internal/usecase"
 )
 
+// maxServedBodySize caps how much of a served request body is read for
+// schema validation.
+const maxServedBodySize = 1 << 20
+
 type MockHandler struct {
 	service          *usecase.MockService
 	scheme           string
 	managementDomain string
+	apiKeys          *apiKeys
+	// clientIPHeader names the header a trusted proxy sets to the client's
+	// address, or is "" to use the connection's.
+	clientIPHeader string
+	// onMatch is told whether each served request matched a mock.
+	onMatch func(result string)
+}
+
+// mockRequest is the JSON body accepted by CreateMock and UpdateMock.
+type mockRequest struct {
+	Path          string                `json:"path"`
+	Method        string                `json:"method"`
+	Status        int                   `json:"status"`
+	ResponseBody  string                `json:"response_body"`
+	RequestSchema *domain.RequestSchema `json:"request_schema,omitempty"`
+	RateLimit     *domain.RateLimit     `json:"rate_limit,omitempty"`
+	Match         *domain.MatchRules    `json:"match,omitempty"`
+	CORS          *domain.CORSOverride  `json:"cors,omitempty"`
+}
+
+func (req mockRequest) input() usecase.MockInput {
+	return usecase.MockInput{
+		Path:          req.Path,
+		Method:        req.Method,
+		Status:        req.Status,
+		ResponseBody:  req.ResponseBody,
+		RequestSchema: req.RequestSchema,
+		RateLimit:     req.RateLimit,
+		Match:         req.Match,
+		CORS:          req.CORS,
+	}
 }
 
 func NewMockHandler(service *usecase.MockService, scheme, managementDomain string) *MockHandler {
@@ -23,42 +68,50 @@ func NewMockHandler(service *usecase.MockService, scheme, managementDomain strin
 	}
 }
 
+// ObserveMatches calls fn with "hit" or "miss" for every serving request
+// that gets as far as looking up a mock, e.g. to count them in metrics.
+func (h *MockHandler) ObserveMatches(fn func(result string)) {
+	h.onMatch = fn
+}
+
+// EnableAPIKeys lets users authenticate with API keys signed by secret, as
+// an alternative to the user_id cookie.
+func (h *MockHandler) EnableAPIKeys(secret string) {
+	h.apiKeys = &apiKeys{secret: []byte(secret)}
+}
+
+// TrustClientIPHeader takes the client address used for quotas from the
+// named header, which the proxy in front of the server sets, such as
+// CF-Connecting-IP on Cloudflare. Only trust a header the proxy overwrites:
+// clients can send any header they like.
+func (h *MockHandler) TrustClientIPHeader(name string) {
+	h.clientIPHeader = name
+}
+
 func (h *MockHandler) CreateMock(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w, r)
 		return
 	}
 
-	var req struct {
-		Path         string `json:"path"`
-		Method       string `json:"method"`
-		Status       int    `json:"status"`
-		ResponseBody string `json:"response_body"`
-	}
+	var req mockRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if req.Path == "" || req.Method == "" || req.Status == 0 || req.ResponseBody == "" {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		writeBodyError(w, r, err)
 		return
 	}
 
-	mock, err := h.service.CreateMock(userID, req.Path, req.Method, req.ResponseBody, req.Status)
+	in := req.input()
+	in.Author = getAuthor(r)
+	mock, err := h.service.CreateMock(r.Context(), userID, in)
 	if err != nil {
-		if err.Error() == "mock endpoint already exists" {
-			http.Error(w, "Endpoint already exists", http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -69,50 +122,34 @@ func (h *MockHandler) CreateMock(w http.ResponseWriter, r *http.Request) {
 
 func (h *MockHandler) UpdateMock(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w, r)
 		return
 	}
 
 	id := strings.TrimPrefix(r.URL.Path, "/api/mocks/")
 	if id == "" {
-		http.Error(w, "ID is required", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "ID is required")
 		return
 	}
 
-	var req struct {
-		Path         string `json:"path"`
-		Method       string `json:"method"`
-		Status       int    `json:"status"`
-		ResponseBody string `json:"response_body"`
-	}
+	var req mockRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if req.Path == "" || req.Method == "" || req.Status == 0 || req.ResponseBody == "" {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		writeBodyError(w, r, err)
 		return
 	}
 
-	mock, err := h.service.UpdateMock(userID, id, req.Path, req.Method, req.ResponseBody, req.Status)
+	in := req.input()
+	in.Author = getAuthor(r)
+	mock, err := h.service.UpdateMock(r.Context(), userID, id, in)
 	if err != nil {
-		if err.Error() == "mock endpoint already exists" {
-			http.Error(w, "Endpoint already exists", http.StatusConflict)
-			return
-		}
-		if err.Error() == "mock endpoint not found" {
-			http.Error(w, "Mock not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -122,52 +159,60 @@ func (h *MockHandler) UpdateMock(w http.ResponseWriter, r *http.Request) {
 
 func (h *MockHandler) ListMocks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w, r)
 		return
 	}
 
-	mocks, err := h.service.GetMocks(userID)
+	mocks, err := h.service.GetMocks(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	// Create response with curl commands
 	type MockResponse struct {
-		ID           string `json:"id"`
-		UserID       string `json:"user_id"`
-		Path         string `json:"path"`
-		Method       string `json:"method"`
-		Status       int    `json:"status"`
-		ResponseBody string `json:"response_body"`
-		CreatedAt    string `json:"created_at"`
-		ExpiresAt    string `json:"expires_at"`
-		HitCount     int    `json:"hit_count"`
-		CurlCommand  string `json:"curl_command"`
+		ID            string                `json:"id"`
+		UserID        string                `json:"user_id"`
+		Path          string                `json:"path"`
+		Method        string                `json:"method"`
+		Status        int                   `json:"status"`
+		ResponseBody  string                `json:"response_body"`
+		RequestSchema *domain.RequestSchema `json:"request_schema,omitempty"`
+		RateLimit     *domain.RateLimit     `json:"rate_limit,omitempty"`
+		Match         *domain.MatchRules    `json:"match,omitempty"`
+		CORS          *domain.CORSOverride  `json:"cors,omitempty"`
+		CreatedAt     string                `json:"created_at"`
+		ExpiresAt     string                `json:"expires_at"`
+		HitCount      int                   `json:"hit_count"`
+		CurlCommand   string                `json:"curl_command"`
 	}
 
 	responses := make([]MockResponse, len(mocks))
 	for i, mock := range mocks {
 		url := fmt.Sprintf("%s://%s.%s%s", h.scheme, userID, h.managementDomain, mock.Path)
-		curlCommand := fmt.Sprintf(`curl -X %s "%s"`, mock.Method, url)
+		curlCommand := curlCommand(mock.Method, url, mock.Match)
 
 		responses[i] = MockResponse{
-			ID:           mock.ID,
-			UserID:       mock.UserID,
-			Path:         mock.Path,
-			Method:       mock.Method,
-			Status:       mock.Status,
-			ResponseBody: mock.ResponseBody,
-			CreatedAt:    mock.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			ExpiresAt:    mock.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
-			HitCount:     mock.HitCount,
-			CurlCommand:  curlCommand,
+			ID:            mock.ID,
+			UserID:        mock.UserID,
+			Path:          mock.Path,
+			Method:        mock.Method,
+			Status:        mock.Status,
+			ResponseBody:  mock.ResponseBody,
+			RequestSchema: mock.RequestSchema,
+			RateLimit:     mock.RateLimit,
+			Match:         mock.Match,
+			CORS:          mock.CORS,
+			CreatedAt:     mock.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ExpiresAt:     mock.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+			HitCount:      mock.HitCount,
+			CurlCommand:   curlCommand,
 		}
 	}
 
@@ -175,31 +220,61 @@ func (h *MockHandler) ListMocks(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(responses)
 }
 
+// curlCommand returns a curl command requesting url. Values required by
+// equals and contains rules are sent, so the command is answered by the
+// mock unless it also has regex or present rules.
+func curlCommand(method, url string, rules *domain.MatchRules) string {
+	var args []string
+	if rules != nil {
+		query := neturl.Values{}
+		for _, r := range sendable(rules.Query) {
+			query.Add(r.Name, r.Value)
+		}
+		if len(query) > 0 {
+			url += "?" + query.Encode()
+		}
+		for _, r := range sendable(rules.Headers) {
+			args = append(args, fmt.Sprintf("-H %q", r.Name+": "+r.Value))
+		}
+		for _, r := range sendable(rules.Cookies) {
+			args = append(args, fmt.Sprintf("-b %q", r.Name+"="+r.Value))
+		}
+	}
+	return strings.Join(append([]string{"curl", "-X", method}, append(args, strconv.Quote(url))...), " ")
+}
+
+// sendable returns the rules whose value satisfies them.
+func sendable(rules []domain.MatchRule) []domain.MatchRule {
+	var out []domain.MatchRule
+	for _, r := range rules {
+		if op := r.Operator(); op == domain.MatchEquals || op == domain.MatchContains {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
 func (h *MockHandler) DeleteMock(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w, r)
 		return
 	}
 
 	id := strings.TrimPrefix(r.URL.Path, "/api/mocks/")
 	if id == "" {
-		http.Error(w, "ID is required", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "ID is required")
 		return
 	}
 
-	err := h.service.DeleteMock(userID, id)
+	err := h.service.DeleteMock(r.Context(), userID, id)
 	if err != nil {
-		if err.Error() == "mock endpoint not found" {
-			http.Error(w, "Mock not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -208,7 +283,12 @@ func (h *MockHandler) DeleteMock(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MockHandler) ServeMock(w http.ResponseWriter, r *http.Request) {
-	userID := getUserIDFromSubdomain(r)
+	userID, known := lookupUserIDFromSubdomain(r)
+	if !known {
+		// No user, so no mocks to serve and no quota to spend.
+		writeProblem(w, r, http.StatusNotFound, domain.ErrMockNotFound.Code, "No user's mocks are served at "+r.Host)
+		return
+	}
 
 	path := r.URL.Path
 	if !strings.HasPrefix(path, "/") {
@@ -217,18 +297,177 @@ func (h *MockHandler) ServeMock(w http.ResponseWriter, r *http.Request) {
 
 	method := r.Method
 
-	mock, err := h.service.GetMockForServing(userID, path, method)
+	hit := domain.Hit{Method: method, Path: path}
+	// match is "hit" or "miss" once the mock has been looked up.
+	var match string
+	defer func() {
+		attrs := []slog.Attr{slog.String("user_id", userID)}
+		if err := h.service.RecordHit(context.WithoutCancel(r.Context()), userID, hit); err != nil {
+			attrs = append(attrs, slog.String("hit_error", err.Error()))
+		}
+		spanAttrs := []attribute.KeyValue{attribute.String("user.id", userID)}
+		if match != "" {
+			attrs = append(attrs, slog.String("match", match))
+			spanAttrs = append(spanAttrs, attribute.String("mock.match", match))
+			if h.onMatch != nil {
+				h.onMatch(match)
+			}
+		}
+		if hit.MockID != "" {
+			attrs = append(attrs, slog.String("mock_id", hit.MockID))
+			spanAttrs = append(spanAttrs, attribute.String("mock.id", hit.MockID))
+		}
+		if hit.Environment != "" {
+			attrs = append(attrs, slog.String("environment", hit.Environment))
+			spanAttrs = append(spanAttrs, attribute.String("mock.environment", hit.Environment))
+		}
+		logAttrs(r, attrs...)
+		traceAttrs(r, spanAttrs...)
+	}()
+
+	quota, err := h.service.TakeRequestQuota(r.Context(), usecase.QuotaServing, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		hit.Status = writeError(w, r, err)
+		return
+	}
+	if quota != nil && !quota.Allowed {
+		hit.Status = http.StatusTooManyRequests
+		writeRateLimited(w, r, h.service.Quotas().ServingRate, quota, "Too many requests to this subdomain")
+		return
+	}
+
+	req := usecase.Request{
+		Method:  method,
+		Path:    path,
+		Query:   r.URL.Query(),
+		Header:  r.Header,
+		Cookies: cookieValues(r),
+	}
+	mock, err := h.service.GetMockForServing(r.Context(), userID, req)
+	if err != nil {
+		hit.Status = writeError(w, r, err)
 		return
 	}
 
+	if mock == nil && method == http.MethodOptions {
+		if status, ok := h.writeOptions(w, r, userID, req); ok {
+			hit.Status = status
+			return
+		}
+	}
 	if mock == nil {
-		http.Error(w, "Mock not found", http.StatusNotFound)
+		match = "miss"
+		hit.Status = http.StatusNotFound
+		h.writeNoMatch(w, r, userID, req)
 		return
 	}
+	match = "hit"
+	hit.MockID = mock.ID
+	if debugRequested(r) {
+		w.Header().Set(MatchHeader, hitSummary(mock))
+	}
+	applyCORSOverride(w, r, mock.CORS)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(mock.Status)
-	w.Write([]byte(mock.ResponseBody))
+	limit, err := h.service.TakeRateLimit(r.Context(), mock)
+	if err != nil {
+		hit.Status = writeError(w, r, err)
+		return
+	}
+	if limit != nil {
+		setRateLimitHeaders(w, mock.RateLimit, limit)
+		if !limit.Allowed {
+			hit.Status = http.StatusTooManyRequests
+			writeRateLimited(w, r, mock.RateLimit, limit, "Rate limit exceeded")
+			return
+		}
+	}
+
+	if mock.RequestSchema != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxServedBodySize))
+		if err != nil {
+			hit.Status = http.StatusBadRequest
+			writeProblem(w, r, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		violations, err := h.service.ValidateRequest(mock, body, r.URL.Query(), r.Header)
+		if err != nil {
+			hit.Status = writeError(w, r, err)
+			return
+		}
+		if len(violations) > 0 {
+			status := mock.RequestSchema.ErrorStatus
+			if status == 0 {
+				status = http.StatusBadRequest
+			}
+			hit.Status = status
+			sendProblem(w, status, schemaProblem{
+				problem:    newProblem(r, status, codeSchemaMismatch, "Request does not match the mock's schema"),
+				Violations: violations,
+			})
+			return
+		}
+	}
+
+	resp, err := h.service.ResolveResponse(r.Context(), userID, r.Header.Get(EnvironmentHeader), mock)
+	if err != nil {
+		hit.Status = writeError(w, r, err)
+		return
+	}
+	hit.Environment = resp.Environment
+	if resp.Delay > 0 {
+		timer := time.NewTimer(resp.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			// Either the client gave up, and nothing is written, or the
+			// request timed out.
+			if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+				hit.Status = writeError(w, r, r.Context().Err())
+			}
+			return
+		}
+	}
+
+	// Only requests that get the mock's response count toward its hits.
+	if err := h.service.CountHit(context.WithoutCancel(r.Context()), mock); err != nil {
+		logAttrs(r, slog.String("hit_count_error", err.Error()))
+	}
+	hit.Status = resp.Status
+	if resp.Environment != "" {
+		w.Header().Set(EnvironmentHeader, resp.Environment)
+	}
+	w.Header().Set("Content-Type", usecase.ResponseContentType(resp.Body))
+	w.WriteHeader(resp.Status)
+	if method != http.MethodHead {
+		w.Write([]byte(resp.Body))
+	}
+}
+
+// cookieValues returns the values of each cookie sent with r.
+func cookieValues(r *http.Request) map[string][]string {
+	cookies := r.Cookies()
+	values := make(map[string][]string, len(cookies))
+	for _, c := range cookies {
+		values[c.Name] = append(values[c.Name], c.Value)
+	}
+	return values
+}
+
+// schemaProblem reports the violations of a mock's request schema.
+type schemaProblem struct {
+	problem
+	Violations []domain.SchemaViolation `json:"violations"`
+}
+
+// setRateLimitHeaders reports the state of a mock's token bucket in the
+// X-RateLimit-* headers. Reset is in seconds until the bucket is full.
+func setRateLimitHeaders(w http.ResponseWriter, l *domain.RateLimit, d *domain.RateLimitDecision) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.Requests))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
 }
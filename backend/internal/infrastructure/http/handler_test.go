@@ -0,0 +1,145 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"mock-api-backend/internal/domain"
+	mockhttp "mock-api-backend/internal/infrastructure/http"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+// testDomain is the management domain of the routers under test; mocks are
+// served from its subdomains, such as u1.api.test.
+const testDomain = "api.test"
+
+// newTestRouter returns the server's router over service.
+func newTestRouter(service *usecase.MockService) http.Handler {
+	handler := mockhttp.NewMockHandler(service, "http", testDomain)
+	return mockhttp.NewHostRouter(testDomain,
+		mockhttp.NewManagementRouter(handler, []string{"*"}),
+		mockhttp.NewServingRouter(handler, []string{"*"}))
+}
+
+// serve sends a request for url to router. header may be nil.
+func serve(router http.Handler, method, url string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// createMock creates a mock for user u1.
+func createMock(t *testing.T, service *usecase.MockService, in usecase.MockInput) *domain.MockAPI {
+	t.Helper()
+	mock, err := service.CreateMock(context.Background(), "u1", in)
+	if err != nil {
+		t.Fatalf("CreateMock: %v", err)
+	}
+	return mock
+}
+
+func TestServeMockHead(t *testing.T) {
+	service := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	createMock(t, service, usecase.MockInput{Path: "/users/:id", Method: "GET", Status: 200, ResponseBody: `{"id":"7"}`})
+	createMock(t, service, usecase.MockInput{Path: "/notes", Method: "GET", Status: 200, ResponseBody: "plain"})
+	createMock(t, service, usecase.MockInput{Path: "/notes", Method: "HEAD", Status: 204})
+	router := newTestRouter(service)
+	debug := http.Header{mockhttp.MockDebugHeader: {"true"}}
+
+	get := serve(router, http.MethodGet, "http://u1.api.test/users/7", debug)
+	head := serve(router, http.MethodHead, "http://u1.api.test/users/7", debug)
+	if get.Code != http.StatusOK || head.Code != http.StatusOK {
+		t.Fatalf("status = GET %d, HEAD %d, want 200", get.Code, head.Code)
+	}
+	if !maps.EqualFunc(get.Header(), head.Header(), slices.Equal) {
+		t.Errorf("HEAD headers = %v, want GET's %v", head.Header(), get.Header())
+	}
+	if head.Header().Get(mockhttp.MatchHeader) == "" || head.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("HEAD headers = %v, want the match and JSON content type", head.Header())
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD body = %q, want none", head.Body)
+	}
+
+	// A HEAD mock is preferred to the GET mock.
+	if head := serve(router, http.MethodHead, "http://u1.api.test/notes", nil); head.Code != http.StatusNoContent {
+		t.Errorf("HEAD /notes status = %d, want the HEAD mock's 204", head.Code)
+	}
+}
+
+// takenBuckets is a rate limit store that allows everything and records
+// the buckets taken from.
+type takenBuckets []string
+
+func (b *takenBuckets) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error) {
+	*b = append(*b, key)
+	return domain.RateLimitDecision{Allowed: true, Remaining: limit.Requests}, nil
+}
+
+func TestServeMockUnknownHost(t *testing.T) {
+	service := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	service.SetQuotas(usecase.Quotas{ServingRate: &domain.RateLimit{Requests: 10, PeriodSeconds: 60}})
+	var taken takenBuckets
+	service.SetRateLimitStore(&taken)
+	router := newTestRouter(service)
+
+	// localhost names no user, and there is no cookie to name one.
+	rec := serve(router, http.MethodGet, "http://localhost/users", http.Header{"Origin": {"https://app.test"}})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	var body struct {
+		Detail string `json:"detail"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Detail != "No user's mocks are served at localhost" {
+		t.Errorf("detail = %q", body.Detail)
+	}
+	if len(taken) != 0 {
+		t.Errorf("took from buckets %q, want none", taken)
+	}
+}
+
+func TestServeMockCountsServedHits(t *testing.T) {
+	service := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	createMock(t, service, usecase.MockInput{
+		Path: "/search", Method: "GET", Status: 200, ResponseBody: "[]",
+		RateLimit:     &domain.RateLimit{Requests: 2, PeriodSeconds: 60},
+		RequestSchema: &domain.RequestSchema{Query: json.RawMessage(`{"type":"object","required":["q"]}`)},
+	})
+	router := newTestRouter(service)
+
+	for _, tt := range []struct {
+		url  string
+		want int
+	}{
+		{"http://u1.api.test/search", http.StatusBadRequest},
+		{"http://u1.api.test/search?q=go", http.StatusOK},
+		{"http://u1.api.test/search?q=go", http.StatusTooManyRequests},
+	} {
+		if rec := serve(router, http.MethodGet, tt.url, nil); rec.Code != tt.want {
+			t.Fatalf("GET %s: status %d, want %d", tt.url, rec.Code, tt.want)
+		}
+	}
+
+	mocks, err := service.GetMocks(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mocks[0].HitCount != 1 {
+		t.Errorf("hit count = %d, want only the served request", mocks[0].HitCount)
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader carries a request's ID. An incoming ID is kept, so a
+// request can be followed across services; otherwise one is generated.
+// Either way it is echoed back on the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming request IDs, which end up in logs.
+const maxRequestIDLength = 128
+
+type requestLogKey struct{}
+
+// requestLog collects what handlers learn about a request, such as the
+// user and the mock served, for the line logged when it completes.
+type requestLog struct {
+	id    string
+	attrs []slog.Attr
+}
+
+// RequestID returns the ID of the request being handled with ctx, or "".
+func RequestID(ctx context.Context) string {
+	if l, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		return l.id
+	}
+	return ""
+}
+
+// logAttrs adds attributes to the line logged for r.
+func logAttrs(r *http.Request, attrs ...slog.Attr) {
+	if l, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		l.attrs = append(l.attrs, attrs...)
+	}
+}
+
+// statusRecorder captures the status written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LoggingMiddleware assigns each request an ID and logs one line per
+// request with its outcome and latency, plus whatever handlers added.
+// Server errors are logged at error level, everything else at info.
+func LoggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = generateID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		entry := &requestLog{id: id}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, entry)))
+
+		// A status of 0 means nothing was written, e.g. because the client
+		// went away.
+		status := rec.status
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := append([]slog.Attr{
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("host", r.Host),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("latency", time.Since(start)),
+		}, entry.attrs...)
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
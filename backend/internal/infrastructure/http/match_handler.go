@@ -0,0 +1,153 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+// MockDebugHeader asks for MatchHeader on a served request, e.g.
+// "X-Mock-Debug: 1".
+const MockDebugHeader = "X-Mock-Debug"
+
+// MatchHeader reports how a served request was matched when
+// MockDebugHeader is set: the mock served, or the closest candidate and
+// why it did not match.
+const MatchHeader = "X-Mock-Match"
+
+// matchProblem reports a request no mock matches, with the mocks that came
+// closest.
+type matchProblem struct {
+	problem
+	Candidates []domain.MatchCandidate `json:"candidates"`
+}
+
+// explainRequest is the JSON body accepted by ExplainMatch. Path may
+// include a query string.
+type explainRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies map[string]string `json:"cookies,omitempty"`
+}
+
+type explainResponse struct {
+	Method     string                  `json:"method"`
+	Path       string                  `json:"path"`
+	Matched    *domain.MockAPI         `json:"matched"`
+	Candidates []domain.MatchCandidate `json:"candidates"`
+	Mocks      int                     `json:"mocks"`
+}
+
+// ExplainMatch matches a hypothetical request against the user's mocks and
+// explains the result as a served request would be, without serving it.
+func (h *MockHandler) ExplainMatch(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	var body explainRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if body.Path == "" {
+		writeError(w, r, domain.Invalid(domain.ErrValidation, domain.FieldError{Field: "path", Message: "is required"}))
+		return
+	}
+	path, rawQuery, _ := strings.Cut(body.Path, "?")
+	query, err := neturl.ParseQuery(rawQuery)
+	if err != nil {
+		writeError(w, r, domain.Invalid(domain.ErrValidation, domain.FieldError{Field: "path", Message: "has an invalid query: " + err.Error()}))
+		return
+	}
+	req := usecase.Request{
+		Method:  strings.ToUpper(body.Method),
+		Path:    path,
+		Query:   query,
+		Header:  http.Header{},
+		Cookies: make(map[string][]string, len(body.Cookies)),
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	for name, value := range body.Headers {
+		http.Header(req.Header).Add(name, value)
+	}
+	for name, value := range body.Cookies {
+		req.Cookies[name] = []string{value}
+	}
+
+	explanation, err := h.service.ExplainMatch(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explainResponse{
+		Method:     explanation.Request.Method,
+		Path:       explanation.Request.Path,
+		Matched:    explanation.Mock,
+		Candidates: explanation.Candidates,
+		Mocks:      explanation.Mocks,
+	})
+}
+
+// writeNoMatch answers a served request that matched no mock with the
+// closest candidates, and sets MatchHeader if asked to.
+func (h *MockHandler) writeNoMatch(w http.ResponseWriter, r *http.Request, userID string, req usecase.Request) {
+	detail := "No mock matches " + req.Method + " " + req.Path
+	explanation, err := h.service.ExplainMatch(r.Context(), userID, req)
+	if err != nil {
+		// The explanation is a courtesy; the request still matched nothing.
+		writeProblem(w, r, http.StatusNotFound, domain.ErrMockNotFound.Code, detail)
+		return
+	}
+	if explanation.Mocks == 0 {
+		detail += fmt.Sprintf(": user %q has no mocks", userID)
+	}
+	if debugRequested(r) {
+		w.Header().Set(MatchHeader, missSummary(explanation.Candidates))
+	}
+	candidates := explanation.Candidates
+	if candidates == nil {
+		candidates = []domain.MatchCandidate{}
+	}
+	sendProblem(w, http.StatusNotFound, matchProblem{
+		problem:    newProblem(r, http.StatusNotFound, domain.ErrMockNotFound.Code, detail),
+		Candidates: candidates,
+	})
+}
+
+// debugRequested reports whether r asks for MatchHeader.
+func debugRequested(r *http.Request) bool {
+	debug, _ := strconv.ParseBool(r.Header.Get(MockDebugHeader))
+	return debug
+}
+
+// hitSummary is MatchHeader for a request served by mock.
+func hitSummary(mock *domain.MockAPI) string {
+	return "hit " + mock.ID + " " + mock.Method + " " + mock.Path + mock.Match.String()
+}
+
+// missSummary is MatchHeader for a request no mock matches.
+func missSummary(candidates []domain.MatchCandidate) string {
+	if len(candidates) == 0 {
+		return "miss"
+	}
+	c := candidates[0]
+	reasons := make([]string, len(c.Mismatches))
+	for i, m := range c.Mismatches {
+		reasons[i] = m.Message
+	}
+	return "miss; closest " + c.MockID + " " + c.Method + " " + c.Path + c.Match + ": " + strings.Join(reasons, "; ")
+}
@@ -0,0 +1,81 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+// limitedReads is a repository whose reads of the user's mocks fail once
+// reads is down to 0. A negative count never runs out.
+type limitedReads struct {
+	domain.MockRepository
+	reads int
+}
+
+func (r *limitedReads) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	if r.reads == 0 {
+		return nil, errors.New("connection reset")
+	}
+	r.reads--
+	return r.MockRepository.GetByUser(ctx, userID)
+}
+
+func TestServeMockNoMatch(t *testing.T) {
+	repo := &limitedReads{MockRepository: repository.NewInMemoryMockRepository(), reads: -1}
+	service := usecase.NewMockService(repo)
+	mock := createMock(t, service, usecase.MockInput{
+		Path: "/search", Method: "GET", Status: 200, ResponseBody: `[]`,
+		Match: &domain.MatchRules{Query: []domain.MatchRule{{Name: "q", Value: "go"}}},
+	})
+	router := newTestRouter(service)
+
+	// body decodes a no-match problem.
+	body := func(rec *httptest.ResponseRecorder) (problem struct {
+		Code       string                   `json:"code"`
+		Detail     string                   `json:"detail"`
+		Candidates *[]domain.MatchCandidate `json:"candidates"`
+	}) {
+		t.Helper()
+		resp := rec.Result()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Code != domain.ErrMockNotFound.Code || problem.Detail != "No mock matches GET /search" {
+			t.Errorf("problem = %+v", problem)
+		}
+		return problem
+	}
+
+	// A near miss lists the rule that failed. The request is matched, then
+	// explained.
+	repo.reads = 2
+	got := body(serve(router, "GET", "http://u1.api.test/search?q=rust", nil))
+	if got.Candidates == nil || len(*got.Candidates) != 1 {
+		t.Fatalf("candidates = %v, want the mock", got.Candidates)
+	}
+	want := domain.MatchMismatch{Part: "query", Name: "q", Message: `query parameter "q" is "rust", want "go"`}
+	if c := (*got.Candidates)[0]; c.MockID != mock.ID || len(c.Mismatches) != 1 || c.Mismatches[0] != want {
+		t.Errorf("candidate = %+v, want %s failing %+v", c, mock.ID, want)
+	}
+
+	// Without an explanation, it is a plain 404.
+	repo.reads = 1
+	got = body(serve(router, "GET", "http://u1.api.test/search?q=rust", nil))
+	if got.Candidates != nil {
+		t.Errorf("candidates = %v, want none", *got.Candidates)
+	}
+}
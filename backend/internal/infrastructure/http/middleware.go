@@ -4,45 +4,83 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const UserIDCookie = "user_id"
 const userIDKey = "userID"
 
 func getUserID(r *http.Request) string {
+	if userID, ok := lookupUserID(r); ok {
+		return userID
+	}
+	// Generate new user ID (cookie will be set by middleware)
+	return generateID()
+}
+
+// lookupUserID returns the user of r, if it names one.
+func lookupUserID(r *http.Request) (string, bool) {
 	// Try to get from context first (set by middleware)
 	if userID := r.Context().Value(userIDKey); userID != nil {
 		if id, ok := userID.(string); ok {
-			return id
+			return id, true
 		}
 	}
 
 	// Try to get from cookie
 	cookie, err := r.Cookie(UserIDCookie)
 	if err == nil && cookie.Value != "" {
-		return cookie.Value
+		return cookie.Value, true
 	}
-
-	// Generate new user ID (cookie will be set by middleware)
-	return generateID()
+	return "", false
 }
 
-func getUserIDFromSubdomain(r *http.Request) string {
+// lookupUserIDFromSubdomain returns the user whose mocks r is served,
+// if its host or cookie names one.
+func lookupUserIDFromSubdomain(r *http.Request) (string, bool) {
 	host := r.Host
 	hostParts := strings.Split(host, ".")
 	if len(hostParts) > 1 {
 		userID := hostParts[0]
 		if userID != "" && userID != "localhost" {
-			return userID
+			return userID, true
 		}
 	}
-	return getUserID(r)
+	return lookupUserID(r)
 }
 
-func authMiddleware(next http.Handler) http.Handler {
+// AuthorHeader optionally names the person making a change, for the mock
+// revision history. Several people often share one user ID.
+const AuthorHeader = "X-Mock-Author"
+
+// getAuthor returns the author recorded for changes made by r, or "" to
+// record the user ID.
+func getAuthor(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(AuthorHeader))
+}
+
+func authMiddleware(keys *apiKeys, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An API key, when present, takes precedence over the cookie
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			if keys == nil {
+				writeProblem(w, r, http.StatusUnauthorized, codeInvalidAPIKey, "API keys are not enabled")
+				return
+			}
+			userID, valid := keys.verify(token)
+			if !valid {
+				writeProblem(w, r, http.StatusUnauthorized, codeInvalidAPIKey, "Invalid API key")
+				return
+			}
+			logAttrs(r, slog.String("user_id", userID))
+			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Try to get from cookie first
 		cookie, err := r.Cookie(UserIDCookie)
 		var userID string
@@ -62,6 +100,7 @@ func authMiddleware(next http.Handler) http.Handler {
 				Secure:   true,
 			})
 		}
+		logAttrs(r, slog.String("user_id", userID))
 		ctx := context.WithValue(r.Context(), userIDKey, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -72,3 +111,17 @@ func generateID() string {
 	rand.Read(b)
 	return hex.EncodeToString(b)
 }
+
+// TimeoutMiddleware cancels the context of a request that runs longer than
+// timeout, which abandons the repository calls it makes. Zero means no
+// limit.
+func TimeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"mock-api-backend/internal/domain"
+)
+
+// problemContentType is the media type of error responses, which are RFC
+// 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// Codes of the problems raised by the HTTP layer. Domain errors carry
+// their own codes.
+const (
+	codeBadRequest       = "bad_request"
+	codeInvalidBody      = "invalid_body"
+	codeUnauthorized     = "unauthorized"
+	codeInvalidAPIKey    = "invalid_api_key"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeRateLimited      = "rate_limited"
+	codeSchemaMismatch   = "request_schema_mismatch"
+	codeTimeout          = "timeout"
+	codeInternal         = "internal_error"
+)
+
+// problem is an RFC 7807 problem details object. Code, RequestID and
+// Errors are extension members; responses needing more embed a problem.
+type problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// newProblem describes a failure of r. Problems are told apart by their
+// code, so the type is always about:blank and the title the status text.
+func newProblem(r *http.Request, status int, code, detail string) problem {
+	return problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: RequestID(r.Context()),
+	}
+}
+
+// internalDetail is the detail of every internal error. The error itself
+// may quote SQL, file paths or driver messages, so it is only logged.
+const internalDetail = "Internal server error"
+
+// errorProblem describes err, which is a domain error, a timeout or
+// otherwise an internal error.
+func errorProblem(r *http.Request, err error) problem {
+	var de *domain.Error
+	switch {
+	case errors.As(err, &de):
+		status, ok := errorStatus[de.Code]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		p := newProblem(r, status, de.Code, err.Error())
+		p.Errors = de.Fields
+		return p
+	case errors.Is(err, context.DeadlineExceeded):
+		return newProblem(r, http.StatusGatewayTimeout, codeTimeout, "Request timed out")
+	}
+	slog.ErrorContext(r.Context(), "internal error",
+		"request_id", RequestID(r.Context()), "method", r.Method, "path", r.URL.Path, "error", err)
+	return newProblem(r, http.StatusInternalServerError, codeInternal, internalDetail)
+}
+
+// errorStatus maps the code of each domain error to its response status.
+var errorStatus = map[string]int{
+	domain.ErrMockAlreadyExists.Code:    http.StatusConflict,
+	domain.ErrMockNotFound.Code:         http.StatusNotFound,
+	domain.ErrInvalidRequestSchema.Code: http.StatusBadRequest,
+	domain.ErrBulkRejected.Code:         http.StatusConflict,
+	domain.ErrRevisionNotFound.Code:     http.StatusNotFound,
+	domain.ErrInvalidEnvironment.Code:   http.StatusBadRequest,
+	domain.ErrOverrideNotFound.Code:     http.StatusNotFound,
+	domain.ErrInvalidRateLimit.Code:     http.StatusBadRequest,
+	domain.ErrQuotaExceeded.Code:        http.StatusTooManyRequests,
+	domain.ErrPayloadTooLarge.Code:      http.StatusRequestEntityTooLarge,
+	domain.ErrValidation.Code:           http.StatusBadRequest,
+}
+
+// sendProblem writes p, a problem or a struct embedding one, with status.
+func sendProblem(w http.ResponseWriter, status int, p any) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeProblem sends a problem with the given status, code and detail.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	sendProblem(w, status, newProblem(r, status, code, detail))
+}
+
+// WriteProblem sends a problem with the given status, code and detail, for
+// handlers wrapped around the routers.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblem(w, r, status, code, detail)
+}
+
+// writeError sends the problem describing err and returns its status.
+func writeError(w http.ResponseWriter, r *http.Request, err error) int {
+	p := errorProblem(r, err)
+	sendProblem(w, p.Status, p)
+	return p.Status
+}
+
+// writeUnauthorized sends the problem for a request without a user.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusUnauthorized, codeUnauthorized, "Unauthorized")
+}
+
+// writeNotFound sends the problem for a request matching no route.
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, codeNotFound, "No route for "+r.Method+" "+r.URL.Path)
+}
+
+// writeMethodNotAllowed sends the problem for a method the route does not
+// support.
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+}
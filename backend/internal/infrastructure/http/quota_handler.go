@@ -0,0 +1,158 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+// limitManagement applies the management quotas: the request body size and
+// the request rate of both the client address and the user. Credentials are
+// free to make up, so a new cookie doesn't bring a new budget, and requests
+// without any are limited by client address alone.
+func (h *MockHandler) limitManagement(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		quotas := h.service.Quotas()
+		if quotas.MaxRequestBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, quotas.MaxRequestBytes)
+		}
+
+		var keys []string
+		if addr := h.clientAddr(r); addr != "" {
+			keys = append(keys, "ip:"+addr)
+		}
+		if hasCredentials(r) {
+			keys = append(keys, getUserID(r))
+		}
+		for _, key := range keys {
+			d, err := h.service.TakeRequestQuota(r.Context(), usecase.QuotaManagement, key)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if d != nil && !d.Allowed {
+				writeRateLimited(w, r, quotas.ManagementRate, d, "Too many management requests")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasCredentials reports whether r names its user, rather than being given
+// a new one by authMiddleware.
+func hasCredentials(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" {
+		return true
+	}
+	cookie, err := r.Cookie(UserIDCookie)
+	return err == nil && cookie.Value != ""
+}
+
+// clientAddr returns the address of the client that sent r, or "" if it
+// is unknown. A trusted header may list several addresses, of which the
+// last was added by the proxy and the rest by whoever sent the request.
+func (h *MockHandler) clientAddr(r *http.Request) string {
+	if h.clientIPHeader != "" {
+		if values := r.Header.Values(h.clientIPHeader); len(values) > 0 {
+			addrs := strings.Split(values[len(values)-1], ",")
+			return strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitProblem reports a request denied by a token bucket. RetryAfter
+// repeats the Retry-After header, in seconds.
+type rateLimitProblem struct {
+	problem
+	RetryAfter int `json:"retry_after"`
+}
+
+// writeRateLimited sends a 429 for a request denied by a token bucket.
+func writeRateLimited(w http.ResponseWriter, r *http.Request, l *domain.RateLimit, d *domain.RateLimitDecision, message string) {
+	setRateLimitHeaders(w, l, d)
+	retryAfter := ceilSeconds(d.RetryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	sendProblem(w, http.StatusTooManyRequests, rateLimitProblem{
+		problem:    newProblem(r, http.StatusTooManyRequests, codeRateLimited, message),
+		RetryAfter: retryAfter,
+	})
+}
+
+// quotaStatus returns the status for a quota error, or 0 for other errors.
+func quotaStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	}
+	return 0
+}
+
+// writeBodyError reports a request body that could not be read or decoded,
+// which is a 413 when it exceeded the management request size quota.
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, domain.ErrPayloadTooLarge.Code,
+			"Request body exceeds "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+		return
+	}
+	writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, err.Error())
+}
+
+// quotaUsage is one quota in the usage response. A nil limit means
+// unlimited.
+type quotaUsage struct {
+	Used  int  `json:"used"`
+	Limit *int `json:"limit"`
+}
+
+func newQuotaUsage(used, limit int) quotaUsage {
+	u := quotaUsage{Used: used}
+	if limit > 0 {
+		u.Limit = &limit
+	}
+	return u
+}
+
+// GetUsage reports the user's consumption of their quotas.
+func (h *MockHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	usage, err := h.service.Usage(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var maxRequestBytes *int64
+	if usage.Quotas.MaxRequestBytes > 0 {
+		maxRequestBytes = &usage.Quotas.MaxRequestBytes
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"mocks":              newQuotaUsage(usage.Mocks, usage.Quotas.MaxMocks),
+		"response_body_size": newQuotaUsage(usage.LargestBody, usage.Quotas.MaxBodyBytes),
+		"hits_per_mock":      newQuotaUsage(usage.MostHits, usage.Quotas.MaxHitsPerMock),
+		"max_request_bytes":  maxRequestBytes,
+		"management_rate":    usage.Quotas.ManagementRate,
+		"serving_rate":       usage.Quotas.ServingRate,
+	})
+}
@@ -0,0 +1,57 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	mockhttp "mock-api-backend/internal/infrastructure/http"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+func TestManagementRateLimit(t *testing.T) {
+	// from returns the header of a request sent from ip, with the user_id
+	// cookie set to user unless it is "".
+	from := func(ip, user string) http.Header {
+		h := http.Header{"Cf-Connecting-Ip": {ip}}
+		if user != "" {
+			h.Set("Cookie", mockhttp.UserIDCookie+"="+user)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name string
+		// The first request is allowed; the second is allowed unless limited.
+		first, second http.Header
+		limited       bool
+	}{
+		{"anonymous requests from one address", from("192.0.2.1", ""), from("192.0.2.1", ""), true},
+		{"anonymous requests from two addresses", from("192.0.2.1", ""), from("192.0.2.2", ""), false},
+		{"a new cookie from the same address", from("192.0.2.1", "u1"), from("192.0.2.1", "u2"), true},
+		{"the same cookie from another address", from("192.0.2.1", "u1"), from("192.0.2.2", "u1"), true},
+		{"two users from two addresses", from("192.0.2.1", "u1"), from("192.0.2.2", "u2"), false},
+		{"a forwarded address the proxy appended to", from("203.0.113.9, 192.0.2.1", ""), from("192.0.2.1", ""), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := usecase.NewMockService(repository.NewInMemoryMockRepository())
+			service.SetQuotas(usecase.Quotas{ManagementRate: &domain.RateLimit{Requests: 1, PeriodSeconds: 60}})
+			handler := mockhttp.NewMockHandler(service, "http", testDomain)
+			handler.TrustClientIPHeader("CF-Connecting-IP")
+			router := mockhttp.NewManagementRouter(handler, []string{"*"})
+
+			if rec := serve(router, http.MethodGet, "http://api.test/api/mocks", tt.first); rec.Code != http.StatusOK {
+				t.Fatalf("first request: status %d, want 200", rec.Code)
+			}
+			want := http.StatusOK
+			if tt.limited {
+				want = http.StatusTooManyRequests
+			}
+			if rec := serve(router, http.MethodGet, "http://api.test/api/mocks", tt.second); rec.Code != want {
+				t.Errorf("second request: status %d, want %d", rec.Code, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mock-api-backend/internal/domain"
+)
+
+type diffLineResponse struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type revisionDiffResponse struct {
+	From     *domain.MockRevision `json:"from"`
+	To       *domain.MockRevision `json:"to"`
+	Changes  []string             `json:"changes"`
+	BodyDiff []diffLineResponse   `json:"body_diff"`
+}
+
+// revisionPath splits /api/mocks/{id}/revisions[/rest] into the mock ID and
+// the rest.
+func revisionPath(path string) (id, rest string) {
+	trimmed := strings.TrimPrefix(path, "/api/mocks/")
+	id, rest, _ = strings.Cut(trimmed, "/revisions")
+	return id, strings.Trim(rest, "/")
+}
+
+// ListRevisions returns a mock's revision history, oldest first.
+func (h *MockHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	id, _ := revisionPath(r.URL.Path)
+	if id == "" {
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "ID is required")
+		return
+	}
+
+	revs, err := h.service.ListRevisions(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if revs == nil {
+		revs = []*domain.MockRevision{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revs)
+}
+
+// DiffRevisions compares two revisions given by the from and to query
+// parameters. to defaults to the latest revision.
+func (h *MockHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	id, _ := revisionPath(r.URL.Path)
+	if id == "" {
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "ID is required")
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, r, domain.Invalid(domain.ErrValidation, domain.FieldError{Field: "from", Message: "must be a revision number"}))
+		return
+	}
+	var to int
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			writeError(w, r, domain.Invalid(domain.ErrValidation, domain.FieldError{Field: "to", Message: "must be a revision number"}))
+			return
+		}
+	} else {
+		revs, err := h.service.ListRevisions(r.Context(), userID, id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if len(revs) > 0 {
+			to = revs[len(revs)-1].Number
+		}
+	}
+
+	diff, err := h.service.DiffRevisions(r.Context(), userID, id, from, to)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := revisionDiffResponse{
+		From:     diff.From,
+		To:       diff.To,
+		Changes:  diff.Changes,
+		BodyDiff: make([]diffLineResponse, len(diff.BodyDiff)),
+	}
+	if resp.Changes == nil {
+		resp.Changes = []string{}
+	}
+	for i, line := range diff.BodyDiff {
+		resp.BodyDiff[i] = diffLineResponse{Op: line.Op, Text: line.Text}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RestoreRevision makes /api/mocks/{id}/revisions/{n}/restore the mock's
+// current content and returns the updated mock.
+func (h *MockHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	id, rest := revisionPath(r.URL.Path)
+	n, ok := strings.CutSuffix(rest, "/restore")
+	number, err := strconv.Atoi(n)
+	if id == "" || !ok || err != nil {
+		writeProblem(w, r, http.StatusBadRequest, codeBadRequest, "Expected /api/mocks/{id}/revisions/{number}/restore")
+		return
+	}
+
+	mock, err := h.service.RestoreRevision(r.Context(), userID, id, number, getAuthor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mock)
+}
@@ -1,12 +1,30 @@
 package http
 
 import (
+	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
 )
 
+// corsMiddleware sets the CORS headers for allowed origins and answers
+// every preflight itself.
 func corsMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	headers := corsHeaders(allowedOrigins)
+	return func(next http.Handler) http.Handler {
+		return headers(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// corsHeaders sets the CORS headers for allowed origins and leaves OPTIONS
+// requests to next.
+func corsHeaders(allowedOrigins []string) func(http.Handler) http.Handler {
 	allowAll := slices.Contains(allowedOrigins, "*")
 
 	return func(next http.Handler) http.Handler {
@@ -16,17 +34,13 @@ func corsMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 				if allowAll || slices.Contains(allowedOrigins, origin) {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
 					w.Header().Set("Access-Control-Allow-Credentials", "true")
-					w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+					w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+AuthorHeader+", "+EnvironmentHeader+", "+MockDebugHeader+", "+RequestIDHeader)
 					w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,PATCH,OPTIONS")
+					w.Header().Set("Access-Control-Expose-Headers", "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, "+EnvironmentHeader+", "+MatchHeader+", "+RequestIDHeader)
 					w.Header().Set("Vary", "Origin")
 				}
 			}
 
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusNoContent)
-				return
-			}
-
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -35,29 +49,78 @@ func corsMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 func NewManagementRouter(handler *MockHandler, allowedOrigins []string) http.Handler {
 	mux := http.NewServeMux()
 
-	api := authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	api := authMiddleware(handler.apiKeys, handler.limitManagement(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 
 		switch {
+		case path == "/api/mocks/bulk" && r.Method == http.MethodPost:
+			traced("BulkMocks", handler.BulkMocks, w, r)
+		case path == "/api/mocks/apply" && r.Method == http.MethodPost:
+			traced("ApplyMocks", handler.ApplyMocks, w, r)
+		case path == "/api/mocks/explain" && r.Method == http.MethodPost:
+			traced("ExplainMatch", handler.ExplainMatch, w, r)
 		case path == "/api/mocks" && r.Method == http.MethodPost:
-			handler.CreateMock(w, r)
+			traced("CreateMock", handler.CreateMock, w, r)
 		case path == "/api/mocks" && r.Method == http.MethodGet:
-			handler.ListMocks(w, r)
+			traced("ListMocks", handler.ListMocks, w, r)
+		case path == "/api/hits" && r.Method == http.MethodGet:
+			traced("ListHits", handler.ListHits, w, r)
+		case path == "/api/usage" && r.Method == http.MethodGet:
+			traced("GetUsage", handler.GetUsage, w, r)
+		case path == "/api/environments" && r.Method == http.MethodGet:
+			traced("ListEnvironments", handler.ListEnvironments, w, r)
+		case path == "/api/environments/active" && (r.Method == http.MethodPut || r.Method == http.MethodDelete):
+			traced("SetActiveEnvironment", handler.SetActiveEnvironment, w, r)
+		case strings.HasPrefix(path, "/api/environments/") && r.Method == http.MethodPut:
+			traced("SetOverride", handler.SetOverride, w, r)
+		case strings.HasPrefix(path, "/api/environments/") && r.Method == http.MethodDelete:
+			traced("DeleteEnvironment", handler.DeleteEnvironment, w, r)
+		case path == "/api/cors" && r.Method == http.MethodGet:
+			traced("GetCORSPolicy", handler.GetCORSPolicy, w, r)
+		case path == "/api/cors" && (r.Method == http.MethodPut || r.Method == http.MethodDelete):
+			traced("SetCORSPolicy", handler.SetCORSPolicy, w, r)
+		case path == "/api/keys" && r.Method == http.MethodPost:
+			traced("CreateAPIKey", handler.CreateAPIKey, w, r)
+		case strings.HasPrefix(path, "/api/mocks/") && strings.HasSuffix(path, "/revisions") && r.Method == http.MethodGet:
+			traced("ListRevisions", handler.ListRevisions, w, r)
+		case strings.HasPrefix(path, "/api/mocks/") && strings.HasSuffix(path, "/revisions/diff") && r.Method == http.MethodGet:
+			traced("DiffRevisions", handler.DiffRevisions, w, r)
+		case strings.HasPrefix(path, "/api/mocks/") && strings.HasSuffix(path, "/restore") && r.Method == http.MethodPost:
+			traced("RestoreRevision", handler.RestoreRevision, w, r)
 		case strings.HasPrefix(path, "/api/mocks/") && r.Method == http.MethodPut:
-			handler.UpdateMock(w, r)
+			traced("UpdateMock", handler.UpdateMock, w, r)
 		case strings.HasPrefix(path, "/api/mocks/") && r.Method == http.MethodDelete:
-			handler.DeleteMock(w, r)
+			traced("DeleteMock", handler.DeleteMock, w, r)
 		default:
-			http.NotFound(w, r)
+			writeNotFound(w, r)
 		}
-	}))
+	})))
 
 	mux.Handle("/api/", api)
+	mux.HandleFunc("/", writeNotFound)
 	return corsMiddleware(allowedOrigins)(mux)
 }
 
+// NewServingRouter serves the users' mocks. CORS headers follow each
+// user's CORS policy, or allowedOrigins for users without one. OPTIONS
+// requests reach the handler, which answers them from the mocks.
 func NewServingRouter(handler *MockHandler, allowedOrigins []string) http.Handler {
-	return corsMiddleware(allowedOrigins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handler.ServeMock(w, r)
+	return handler.servingCORS(allowedOrigins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traced("ServeMock", handler.ServeMock, w, r)
 	}))
 }
+
+// NewHostRouter sends requests for managementDomain to the management
+// router and everything else, i.e. the users' subdomains, to the serving
+// router.
+func NewHostRouter(managementDomain string, management, serving http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == managementDomain {
+			logAttrs(r, slog.String("router", "management"))
+			management.ServeHTTP(w, r)
+			return
+		}
+		logAttrs(r, slog.String("router", "serving"))
+		serving.ServeHTTP(w, r)
+	})
+}
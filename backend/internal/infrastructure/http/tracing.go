@@ -0,0 +1,44 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of MockHandler methods. It uses the global
+// tracer provider, so spans go nowhere unless the binary installs one.
+var tracer = otel.Tracer("mock-api-backend/internal/infrastructure/http")
+
+// traced runs a MockHandler method in a span named after it. The span is a
+// child of the request's server span, and server errors mark it failed.
+// The trace ID is added to the request's log line.
+func traced(name string, fn http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "MockHandler."+name)
+	defer span.End()
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		logAttrs(r, slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	rec := &statusRecorder{ResponseWriter: w}
+	fn(rec, r.WithContext(ctx))
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// traceAttrs adds attributes to the span of the MockHandler method
+// handling r.
+func traceAttrs(r *http.Request, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(r.Context()).SetAttributes(attrs...)
+}
@@ -0,0 +1,174 @@
+// Package metrics exposes the server's Prometheus metrics. Labels are
+// limited to fixed sets, such as router and repository operation, so user
+// IDs and paths never become label values.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"mock-api-backend/internal/domain"
+)
+
+const namespace = "mock_api"
+
+// Metrics holds the collectors and the registry they are served from.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	matches         *prometheus.CounterVec
+	repoDuration    *prometheus.HistogramVec
+	repoErrors      *prometheus.CounterVec
+	cleanups        *prometheus.CounterVec
+	cleanedUp       prometheus.Counter
+}
+
+// New registers the collectors, plus Go runtime and process metrics. stats
+// is called on every scrape to report mock counts.
+func New(stats func(ctx context.Context) (domain.MockStats, error)) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by router, method and status code.",
+		}, []string{"router", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time to handle HTTP requests, by router.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"router"}),
+		matches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "serve_matches_total",
+			Help:      "Serving requests by whether a mock matched (hit) or not (miss).",
+		}, []string{"result"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Time spent in repository calls, by operation.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_errors_total",
+			Help:      "Repository calls that returned an error, by operation.",
+		}, []string{"operation"}),
+		cleanups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "expired_cleanup_runs_total",
+			Help:      "Runs of the expired mock cleanup, by result.",
+		}, []string{"result"}),
+		cleanedUp: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "expired_mocks_deleted_total",
+			Help:      "Expired mocks deleted by cleanup.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.requestDuration, m.matches,
+		m.repoDuration, m.repoErrors, m.cleanups, m.cleanedUp,
+		&mockCollector{stats: stats},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware counts and times the requests handled by next under the
+// given router label.
+func (m *Metrics) Middleware(router string, next http.Handler) http.Handler {
+	duration := m.requestDuration.WithLabelValues(router)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		duration.Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(router, method(r.Method), strconv.Itoa(rec.status)).Inc()
+	})
+}
+
+// ObserveMatch counts a serving request that did ("hit") or did not
+// ("miss") match a mock.
+func (m *Metrics) ObserveMatch(result string) {
+	m.matches.WithLabelValues(result).Inc()
+}
+
+// ObserveCleanup records a run of the expired mock cleanup.
+func (m *Metrics) ObserveCleanup(deleted int, err error) {
+	if err != nil {
+		m.cleanups.WithLabelValues("error").Inc()
+		return
+	}
+	m.cleanups.WithLabelValues("ok").Inc()
+	m.cleanedUp.Add(float64(deleted))
+}
+
+// method bounds the method label: anything but the standard methods is
+// counted as OTHER.
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// mockCollector reports mock counts, read from the repository at scrape
+// time so they are right for every backend and across restarts.
+type mockCollector struct {
+	stats func(ctx context.Context) (domain.MockStats, error)
+}
+
+var mocksDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "mocks"),
+	"Mocks stored, by state: active, or expired and awaiting cleanup.",
+	[]string{"state"}, nil,
+)
+
+func (c *mockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- mocksDesc
+}
+
+func (c *mockCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.stats(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(mocksDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(mocksDesc, prometheus.GaugeValue, float64(stats.Active), "active")
+	ch <- prometheus.MustNewConstMetric(mocksDesc, prometheus.GaugeValue, float64(stats.Expired), "expired")
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Repository wraps repo so every call is timed, and failed calls are
+// counted, under the name of the method called.
+func (m *Metrics) Repository(repo domain.MockRepository) domain.MockRepository {
+	return &instrumentedRepository{repo: repo, m: m}
+}
+
+type instrumentedRepository struct {
+	repo domain.MockRepository
+	m    *Metrics
+}
+
+// observe records a call to operation that started at start.
+func (r *instrumentedRepository) observe(operation string, start time.Time, err error) {
+	r.m.repoDuration.With(prometheus.Labels{"operation": operation}).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.m.repoErrors.With(prometheus.Labels{"operation": operation}).Inc()
+	}
+}
+
+func (r *instrumentedRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	start := time.Now()
+	err := r.repo.Save(ctx, mock)
+	r.observe("Save", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	start := time.Now()
+	v, err := r.repo.GetByUser(ctx, userID)
+	r.observe("GetByUser", start, err)
+	return v, err
+}
+
+func (r *instrumentedRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, mock)
+	r.observe("Update", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) IncrementHitCount(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.repo.IncrementHitCount(ctx, id)
+	r.observe("IncrementHitCount", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) DeleteExpired(ctx context.Context) error {
+	start := time.Now()
+	err := r.repo.DeleteExpired(ctx)
+	r.observe("DeleteExpired", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	start := time.Now()
+	v, err := r.repo.Stats(ctx)
+	r.observe("Stats", start, err)
+	return v, err
+}
+
+func (r *instrumentedRepository) Delete(ctx context.Context, userID, id string) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, userID, id)
+	r.observe("Delete", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	start := time.Now()
+	err := r.repo.AddRevision(ctx, rev)
+	r.observe("AddRevision", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	start := time.Now()
+	v, err := r.repo.GetRevisions(ctx, userID, mockID)
+	r.observe("GetRevisions", start, err)
+	return v, err
+}
+
+func (r *instrumentedRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	start := time.Now()
+	err := r.repo.SaveOverride(ctx, o)
+	r.observe("SaveOverride", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	start := time.Now()
+	v, err := r.repo.GetOverride(ctx, userID, environment, mockID)
+	r.observe("GetOverride", start, err)
+	return v, err
+}
+
+func (r *instrumentedRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	start := time.Now()
+	v, err := r.repo.GetOverrides(ctx, userID)
+	r.observe("GetOverrides", start, err)
+	return v, err
+}
+
+func (r *instrumentedRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	start := time.Now()
+	err := r.repo.DeleteOverride(ctx, userID, environment, mockID)
+	r.observe("DeleteOverride", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	start := time.Now()
+	err := r.repo.DeleteEnvironment(ctx, userID, environment)
+	r.observe("DeleteEnvironment", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	start := time.Now()
+	err := r.repo.SetActiveEnvironment(ctx, userID, environment)
+	r.observe("SetActiveEnvironment", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	start := time.Now()
+	v, err := r.repo.GetActiveEnvironment(ctx, userID)
+	r.observe("GetActiveEnvironment", start, err)
+	return v, err
+}
+
+func (r *instrumentedRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	start := time.Now()
+	err := r.repo.SetCORSPolicy(ctx, userID, policy)
+	r.observe("SetCORSPolicy", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	start := time.Now()
+	v, err := r.repo.GetCORSPolicy(ctx, userID)
+	r.observe("GetCORSPolicy", start, err)
+	return v, err
+}
+
+// WithinTx times the whole transaction, and instruments the calls made
+// inside it.
+func (r *instrumentedRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	start := time.Now()
+	err := r.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		return fn(&instrumentedRepository{repo: repo, m: r.m})
+	})
+	r.observe("WithinTx", start, err)
+	return err
+}
@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// migrationLockID is the advisory lock that serializes migrators, e.g.
+// several servers starting at once.
+const migrationLockID = 0x6d6f636b // "mock"
+
+// pgxDB is satisfied by *pgx.Conn and *pgxpool.Pool.
+type pgxDB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type postgresConn struct {
+	db pgxDB
+}
+
+// NewPostgres returns a Conn for a pgx connection or pool.
+func NewPostgres(db pgxDB) Conn {
+	return &postgresConn{db: db}
+}
+
+func (c *postgresConn) Init(ctx context.Context) error {
+	_, err := c.db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	return err
+}
+
+func (c *postgresConn) Applied(ctx context.Context) ([]int, error) {
+	rows, err := c.db.Query(ctx, `SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+func (c *postgresConn) Run(ctx context.Context, m Migration, script string, up bool) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	var applied bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied == up {
+		return nil
+	}
+
+	if script != "" {
+		// Without arguments pgx uses the simple protocol, which accepts
+		// several statements at once.
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+	}
+	if up {
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
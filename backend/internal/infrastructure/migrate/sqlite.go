@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SQLite statements, shared with the D1 runner in the worker.
+const (
+	SQLiteCreateTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`
+	SQLiteListApplied  = `SELECT version FROM schema_migrations ORDER BY version`
+	SQLiteInsertRecord = `INSERT OR IGNORE INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`
+	SQLiteDeleteRecord = `DELETE FROM schema_migrations WHERE version = ?`
+)
+
+type sqliteConn struct {
+	db *sql.DB
+}
+
+// NewSQLite returns a Conn for a SQLite database.
+func NewSQLite(db *sql.DB) Conn {
+	return &sqliteConn{db: db}
+}
+
+func (c *sqliteConn) Init(ctx context.Context) error {
+	_, err := c.db.ExecContext(ctx, SQLiteCreateTable)
+	return err
+}
+
+func (c *sqliteConn) Applied(ctx context.Context) ([]int, error) {
+	rows, err := c.db.QueryContext(ctx, SQLiteListApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (c *sqliteConn) Run(ctx context.Context, m Migration, script string, up bool) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)`, m.Version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied == up {
+		return nil
+	}
+
+	for _, stmt := range SplitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, SQLiteInsertRecord, m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+	} else {
+		_, err = tx.ExecContext(ctx, SQLiteDeleteRecord, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
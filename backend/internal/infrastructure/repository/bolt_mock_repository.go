@@ -0,0 +1,409 @@
+//go:build !(js && wasm)
+
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"mock-api-backend/internal/domain"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// mocksBucket maps mock ID to the JSON-encoded mock.
+	mocksBucket = []byte("mocks")
+	// routesBucket mapped user, method and path to a mock ID. Mocks are
+	// now matched on more than their route, so it is dropped on open.
+	routesBucket = []byte("routes")
+	// revisionsBucket maps mock ID and revision number to the
+	// JSON-encoded revision; keys sort by number within a mock.
+	revisionsBucket = []byte("revisions")
+	// overridesBucket maps user, environment and mock ID to the
+	// JSON-encoded override.
+	overridesBucket = []byte("overrides")
+	// activeBucket maps user ID to the active environment.
+	activeBucket = []byte("active_environments")
+	// corsBucket maps user ID to the JSON of the user's CORS policy.
+	corsBucket = []byte("cors_policies")
+)
+
+// BoltMockRepository stores mocks in a single BoltDB file. It needs no
+// external service, which makes it the durable choice for standalone use.
+type BoltMockRepository struct {
+	db *bolt.DB
+	// tx is set on the repository handed to WithinTx callbacks.
+	tx *bolt.Tx
+}
+
+// OpenBoltMockRepository opens or creates the database file at path.
+func OpenBoltMockRepository(path string) (*BoltMockRepository, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{mocksBucket, revisionsBucket, overridesBucket, activeBucket, corsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if tx.Bucket(routesBucket) != nil {
+			return tx.DeleteBucket(routesBucket)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", path, err)
+	}
+	return &BoltMockRepository{db: db}, nil
+}
+
+func (r *BoltMockRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *BoltMockRepository) view(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.View(fn)
+}
+
+func (r *BoltMockRepository) update(fn func(tx *bolt.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.Update(fn)
+}
+
+func getBoltMock(tx *bolt.Tx, id string) (*domain.MockAPI, error) {
+	data := tx.Bucket(mocksBucket).Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+	var mock domain.MockAPI
+	if err := json.Unmarshal(data, &mock); err != nil {
+		return nil, fmt.Errorf("failed to decode mock %s: %w", id, err)
+	}
+	return &mock, nil
+}
+
+func putBoltMock(tx *bolt.Tx, mock *domain.MockAPI) error {
+	data, err := json.Marshal(mock)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(mocksBucket).Put([]byte(mock.ID), data)
+}
+
+func revisionPrefix(mockID string) []byte {
+	return []byte(mockID + "\x00")
+}
+
+func revisionKey(mockID string, number int) []byte {
+	return fmt.Appendf(revisionPrefix(mockID), "%010d", number)
+}
+
+func deleteBoltRevisions(tx *bolt.Tx, mockID string) error {
+	c := tx.Bucket(revisionsBucket).Cursor()
+	prefix := revisionPrefix(mockID)
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func overrideKey(userID, environment, mockID string) []byte {
+	return []byte(userID + "\x00" + environment + "\x00" + mockID)
+}
+
+// deleteBoltOverrides removes the overrides matching the key prefix, and
+// only those for mockID when it is not empty.
+func deleteBoltOverrides(tx *bolt.Tx, prefix []byte, mockID string) error {
+	var keys [][]byte
+	c := tx.Bucket(overridesBucket).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if mockID == "" || bytes.HasSuffix(k, []byte("\x00"+mockID)) {
+			keys = append(keys, bytes.Clone(k))
+		}
+	}
+	for _, k := range keys {
+		if err := tx.Bucket(overridesBucket).Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteBoltMock(tx *bolt.Tx, mock *domain.MockAPI) error {
+	return tx.Bucket(mocksBucket).Delete([]byte(mock.ID))
+}
+
+func (r *BoltMockRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	return r.update(func(tx *bolt.Tx) error {
+		return putBoltMock(tx, mock)
+	})
+}
+
+func (r *BoltMockRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	var mocks []*domain.MockAPI
+	err := r.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(mocksBucket).ForEach(func(k, v []byte) error {
+			var mock domain.MockAPI
+			if err := json.Unmarshal(v, &mock); err != nil {
+				return fmt.Errorf("failed to decode mock %s: %w", k, err)
+			}
+			if mock.UserID == userID {
+				mocks = append(mocks, &mock)
+			}
+			return nil
+		})
+	})
+	// Newest first, like the SQL repositories
+	sort.Slice(mocks, func(i, j int) bool {
+		return mocks[i].CreatedAt.After(mocks[j].CreatedAt)
+	})
+	return mocks, err
+}
+
+func (r *BoltMockRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	return r.update(func(tx *bolt.Tx) error {
+		current, err := getBoltMock(tx, mock.ID)
+		if err != nil || current == nil || current.UserID != mock.UserID {
+			return err
+		}
+		if err := deleteBoltMock(tx, current); err != nil {
+			return err
+		}
+		updated := *mock
+		updated.CreatedAt = current.CreatedAt
+		updated.ExpiresAt = current.ExpiresAt
+		updated.HitCount = current.HitCount
+		return putBoltMock(tx, &updated)
+	})
+}
+
+func (r *BoltMockRepository) IncrementHitCount(ctx context.Context, id string) error {
+	return r.update(func(tx *bolt.Tx) error {
+		mock, err := getBoltMock(tx, id)
+		if err != nil || mock == nil {
+			return err
+		}
+		mock.HitCount++
+		return putBoltMock(tx, mock)
+	})
+}
+
+func (r *BoltMockRepository) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+	return r.update(func(tx *bolt.Tx) error {
+		var expired []*domain.MockAPI
+		err := tx.Bucket(mocksBucket).ForEach(func(k, v []byte) error {
+			var mock domain.MockAPI
+			if err := json.Unmarshal(v, &mock); err != nil {
+				return fmt.Errorf("failed to decode mock %s: %w", k, err)
+			}
+			if now.After(mock.ExpiresAt) {
+				expired = append(expired, &mock)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Buckets must not be modified while ForEach is iterating them.
+		for _, mock := range expired {
+			if err := deleteBoltMock(tx, mock); err != nil {
+				return err
+			}
+			if err := deleteBoltRevisions(tx, mock.ID); err != nil {
+				return err
+			}
+			if err := deleteBoltOverrides(tx, []byte(mock.UserID+"\x00"), mock.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *BoltMockRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	var stats domain.MockStats
+	now := time.Now()
+	err := r.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(mocksBucket).ForEach(func(k, v []byte) error {
+			var mock struct {
+				ExpiresAt time.Time `json:"expires_at"`
+			}
+			if err := json.Unmarshal(v, &mock); err != nil {
+				return fmt.Errorf("failed to decode mock %s: %w", k, err)
+			}
+			if now.After(mock.ExpiresAt) {
+				stats.Expired++
+			} else {
+				stats.Active++
+			}
+			return nil
+		})
+	})
+	return stats, err
+}
+
+func (r *BoltMockRepository) Delete(ctx context.Context, userID, id string) error {
+	return r.update(func(tx *bolt.Tx) error {
+		mock, err := getBoltMock(tx, id)
+		if err != nil || mock == nil || mock.UserID != userID {
+			return err
+		}
+		if err := deleteBoltMock(tx, mock); err != nil {
+			return err
+		}
+		if err := deleteBoltRevisions(tx, id); err != nil {
+			return err
+		}
+		return deleteBoltOverrides(tx, []byte(userID+"\x00"), id)
+	})
+}
+
+func (r *BoltMockRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	data, err := json.Marshal(rev)
+	if err != nil {
+		return err
+	}
+	return r.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(revisionsBucket).Put(revisionKey(rev.MockID, rev.Number), data)
+	})
+}
+
+func (r *BoltMockRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	var revs []*domain.MockRevision
+	err := r.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(revisionsBucket).Cursor()
+		prefix := revisionPrefix(mockID)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var rev domain.MockRevision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				return fmt.Errorf("failed to decode revision %s: %w", k, err)
+			}
+			if rev.UserID == userID {
+				revs = append(revs, &rev)
+			}
+		}
+		return nil
+	})
+	return revs, err
+}
+
+func (r *BoltMockRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return r.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(overridesBucket).Put(overrideKey(o.UserID, o.Environment, o.MockID), data)
+	})
+}
+
+func (r *BoltMockRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	var o *domain.MockOverride
+	err := r.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(overridesBucket).Get(overrideKey(userID, environment, mockID))
+		if data == nil {
+			return nil
+		}
+		o = &domain.MockOverride{}
+		return json.Unmarshal(data, o)
+	})
+	return o, err
+}
+
+func (r *BoltMockRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	var overrides []*domain.MockOverride
+	err := r.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(overridesBucket).Cursor()
+		prefix := []byte(userID + "\x00")
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var o domain.MockOverride
+			if err := json.Unmarshal(v, &o); err != nil {
+				return fmt.Errorf("failed to decode override %s: %w", k, err)
+			}
+			overrides = append(overrides, &o)
+		}
+		return nil
+	})
+	return overrides, err
+}
+
+func (r *BoltMockRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	return r.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(overridesBucket).Delete(overrideKey(userID, environment, mockID))
+	})
+}
+
+func (r *BoltMockRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	return r.update(func(tx *bolt.Tx) error {
+		return deleteBoltOverrides(tx, []byte(userID+"\x00"+environment+"\x00"), "")
+	})
+}
+
+func (r *BoltMockRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	return r.update(func(tx *bolt.Tx) error {
+		if environment == "" {
+			return tx.Bucket(activeBucket).Delete([]byte(userID))
+		}
+		return tx.Bucket(activeBucket).Put([]byte(userID), []byte(environment))
+	})
+}
+
+func (r *BoltMockRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	var environment string
+	err := r.view(func(tx *bolt.Tx) error {
+		environment = string(tx.Bucket(activeBucket).Get([]byte(userID)))
+		return nil
+	})
+	return environment, err
+}
+
+func (r *BoltMockRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	return r.update(func(tx *bolt.Tx) error {
+		if policy == nil {
+			return tx.Bucket(corsBucket).Delete([]byte(userID))
+		}
+		data, err := json.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(corsBucket).Put([]byte(userID), data)
+	})
+}
+
+func (r *BoltMockRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	var policy *domain.CORSPolicy
+	err := r.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(corsBucket).Get([]byte(userID))
+		if data == nil {
+			return nil
+		}
+		policy = &domain.CORSPolicy{}
+		return json.Unmarshal(data, policy)
+	})
+	return policy, err
+}
+
+func (r *BoltMockRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return fn(&BoltMockRepository{db: r.db, tx: tx})
+	})
+}
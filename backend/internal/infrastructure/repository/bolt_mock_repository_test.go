@@ -0,0 +1,22 @@
+//go:build !(js && wasm)
+
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/infrastructure/repository/repotest"
+)
+
+func TestBoltMockRepository(t *testing.T) {
+	repotest.Run(t, func() (domain.MockRepository, func(), error) {
+		repo, err := repository.OpenBoltMockRepository(filepath.Join(t.TempDir(), "mocks.db"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, func() { repo.Close() }, nil
+	})
+}
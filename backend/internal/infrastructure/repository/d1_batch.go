@@ -0,0 +1,73 @@
+//go:build js && wasm
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+
+	"github.com/syumai/workers/cloudflare"
+)
+
+// d1Statement is a write queued for a D1 batch.
+type d1Statement struct {
+	query string
+	args  []any
+}
+
+// execBatch sends statements to D1's batch API, which applies them in a
+// single implicit transaction. The database/sql driver has no transaction
+// support, so this talks to the binding directly. Once ctx is done the
+// batch is no longer waited for, though D1 may still apply it.
+func (r *D1MockRepository) execBatch(ctx context.Context, stmts []d1Statement) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	db := cloudflare.GetBinding(r.binding)
+	if db.IsUndefined() {
+		return fmt.Errorf("d1 binding %q not found", r.binding)
+	}
+
+	prepared := js.Global().Get("Array").New(len(stmts))
+	for i, st := range stmts {
+		prepared.SetIndex(i, db.Call("prepare", st.query).Call("bind", st.args...))
+	}
+
+	if _, err := awaitPromise(ctx, db.Call("batch", prepared)); err != nil {
+		return fmt.Errorf("d1 batch failed: %w", err)
+	}
+	return nil
+}
+
+// awaitPromise waits for promise to settle or ctx to be done. Both
+// callbacks are released on return; if ctx wins, the promise settling
+// later only logs a call to a released function.
+func awaitPromise(ctx context.Context, promise js.Value) (js.Value, error) {
+	// Buffered, so the callbacks don't block once nobody is waiting.
+	resultCh := make(chan js.Value, 1)
+	errCh := make(chan error, 1)
+	then := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		resultCh <- args[0]
+		return js.Undefined()
+	})
+	catch := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		errCh <- fmt.Errorf("%s", args[0].Call("toString").String())
+		return js.Undefined()
+	})
+	defer func() {
+		then.Release()
+		catch.Release()
+	}()
+
+	promise.Call("then", then, catch)
+	select {
+	case result := <-resultCh:
+		return result, nil
+	case err := <-errCh:
+		return js.Value{}, err
+	case <-ctx.Done():
+		return js.Value{}, ctx.Err()
+	}
+}
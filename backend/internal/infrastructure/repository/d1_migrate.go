@@ -0,0 +1,76 @@
+//go:build js && wasm
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"mock-api-backend/internal/infrastructure/migrate"
+)
+
+// d1MigrationConn runs migrations through D1's batch API, since D1 has no
+// interactive transactions. Unlike the other backends, the applied check
+// happens outside the batch, so two isolates racing on the same migration
+// can make one of them fail; the next attempt then finds it applied.
+type d1MigrationConn struct {
+	repo *D1MockRepository
+}
+
+// MigrationConn returns a migrate.Conn for the repository's database.
+func (r *D1MockRepository) MigrationConn() migrate.Conn {
+	return &d1MigrationConn{repo: r}
+}
+
+func (c *d1MigrationConn) Init(ctx context.Context) error {
+	_, err := c.repo.db.ExecContext(ctx, migrate.SQLiteCreateTable)
+	return err
+}
+
+func (c *d1MigrationConn) Applied(ctx context.Context) ([]int, error) {
+	rows, err := c.repo.db.QueryContext(ctx, migrate.SQLiteListApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (c *d1MigrationConn) Run(ctx context.Context, m migrate.Migration, script string, up bool) error {
+	applied, err := c.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	isApplied := false
+	for _, v := range applied {
+		if v == m.Version {
+			isApplied = true
+		}
+	}
+	if isApplied == up {
+		return nil
+	}
+
+	var stmts []d1Statement
+	for _, q := range migrate.SplitStatements(script) {
+		stmts = append(stmts, d1Statement{query: q})
+	}
+	if up {
+		stmts = append(stmts, d1Statement{
+			query: migrate.SQLiteInsertRecord,
+			args:  []any{m.Version, m.Name, time.Now().UTC().Format(time.RFC3339)},
+		})
+	} else {
+		stmts = append(stmts, d1Statement{query: migrate.SQLiteDeleteRecord, args: []any{m.Version}})
+	}
+	return c.repo.execBatch(ctx, stmts)
+}
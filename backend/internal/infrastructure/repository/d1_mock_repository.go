@@ -14,7 +14,11 @@ import (
 )
 
 type D1MockRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	binding string
+	// pending collects writes while inside WithinTx; they are sent to D1 as
+	// a single batch when the transaction function succeeds.
+	pending *[]d1Statement
 }
 
 func NewD1MockRepository(bindingName string) (*D1MockRepository, error) {
@@ -23,57 +27,39 @@ func NewD1MockRepository(bindingName string) (*D1MockRepository, error) {
 		return nil, fmt.Errorf("failed to open d1 connector: %w", err)
 	}
 	db := sql.OpenDB(c)
-	return &D1MockRepository{db: db}, nil
+	return &D1MockRepository{db: db, binding: bindingName}, nil
 }
 
-func (r *D1MockRepository) Save(mock *domain.MockAPI) error {
-	query := `
-		INSERT INTO mocks (id, user_id, method, path, response_status, response_body, created_at, expires_at, hit_count)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	// Convert time.Time to RFC3339 string format for D1 compatibility
-	createdAtStr := mock.CreatedAt.Format(time.RFC3339)
-	expiresAtStr := mock.ExpiresAt.Format(time.RFC3339)
-	
-	_, err := r.db.ExecContext(context.Background(), query,
-		mock.ID,
-		mock.UserID,
-		mock.Method,
-		mock.Path,
-		mock.Status,
-		mock.ResponseBody,
-		createdAtStr,
-		expiresAtStr,
-		mock.HitCount,
-	)
-	return err
+// RateLimitStore returns a rate limit store in the same database, so
+// buckets are shared by every Worker isolate.
+func (r *D1MockRepository) RateLimitStore() domain.RateLimitStore {
+	return NewSQLRateLimitStore(r.db)
 }
 
-func (r *D1MockRepository) Update(mock *domain.MockAPI) error {
-	query := `
-		UPDATE mocks
-		SET user_id = ?, method = ?, path = ?, response_status = ?, response_body = ?
-		WHERE id = ?
-	`
-	_, err := r.db.ExecContext(context.Background(), query,
-		mock.UserID,
-		mock.Method,
-		mock.Path,
-		mock.Status,
-		mock.ResponseBody,
-		mock.ID,
-	)
-	return err
+// HitStore returns a hit store in the same database, so every Worker
+// isolate tails the same hits.
+func (r *D1MockRepository) HitStore() domain.HitStore {
+	return NewSQLHitStore(r.db)
+}
+
+func (r *D1MockRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	args, err := insertMockArgs(mock)
+	if err != nil {
+		return err
+	}
+	return r.exec(ctx, sqliteInsertMock, args...)
+}
+
+func (r *D1MockRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	args, err := updateMockArgs(mock)
+	if err != nil {
+		return err
+	}
+	return r.exec(ctx, sqliteUpdateMock, args...)
 }
 
-func (r *D1MockRepository) GetByUser(userID string) ([]*domain.MockAPI, error) {
-	query := `
-		SELECT id, user_id, method, path, response_status, response_body, created_at, expires_at, hit_count
-		FROM mocks
-		WHERE user_id = ?
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.QueryContext(context.Background(), query, userID)
+func (r *D1MockRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	rows, err := r.db.QueryContext(ctx, sqliteListMocksByUser, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -81,93 +67,171 @@ func (r *D1MockRepository) GetByUser(userID string) ([]*domain.MockAPI, error) {
 
 	var mocks []*domain.MockAPI
 	for rows.Next() {
-		var m domain.MockAPI
-		var createdAtStr, expiresAtStr string
-		if err := rows.Scan(
-			&m.ID,
-			&m.UserID,
-			&m.Method,
-			&m.Path,
-			&m.Status,
-			&m.ResponseBody,
-			&createdAtStr,
-			&expiresAtStr,
-			&m.HitCount,
-		); err != nil {
+		m, err := scanSQLiteMock(rows)
+		if err != nil {
 			return nil, err
 		}
-		// Parse RFC3339 strings back to time.Time
-		createdAt, err := time.Parse(time.RFC3339, createdAtStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
+		mocks = append(mocks, m)
+	}
+	return mocks, nil
+}
+
+func (r *D1MockRepository) IncrementHitCount(ctx context.Context, id string) error {
+	return r.exec(ctx, sqliteIncrementHitCount, id)
+}
+
+func (r *D1MockRepository) DeleteExpired(ctx context.Context) error {
+	// Convert time.Time to RFC3339 string format for D1 compatibility
+	nowStr := time.Now().Format(time.RFC3339)
+	return r.WithinTx(ctx, func(repo domain.MockRepository) error {
+		tx := repo.(*D1MockRepository)
+		if err := tx.exec(ctx, sqliteDeleteExpiredRevisions, nowStr); err != nil {
+			return err
+		}
+		if err := tx.exec(ctx, sqliteDeleteExpiredOverrides, nowStr); err != nil {
+			return err
+		}
+		return tx.exec(ctx, sqliteDeleteExpired, nowStr)
+	})
+}
+
+func (r *D1MockRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	nowStr := time.Now().Format(time.RFC3339)
+	return scanSQLiteStats(r.db.QueryRowContext(ctx, sqliteMockStats, nowStr))
+}
+
+func (r *D1MockRepository) Delete(ctx context.Context, userID, id string) error {
+	return r.WithinTx(ctx, func(repo domain.MockRepository) error {
+		tx := repo.(*D1MockRepository)
+		if err := tx.exec(ctx, sqliteDeleteRevisions, id, userID); err != nil {
+			return err
 		}
-		expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+		if err := tx.exec(ctx, sqliteDeleteMockOverrides, id, userID); err != nil {
+			return err
+		}
+		return tx.exec(ctx, sqliteDeleteMock, id, userID)
+	})
+}
+
+func (r *D1MockRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	args, err := insertRevisionArgs(rev)
+	if err != nil {
+		return err
+	}
+	return r.exec(ctx, sqliteInsertRevision, args...)
+}
+
+func (r *D1MockRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	rows, err := r.db.QueryContext(ctx, sqliteListRevisions, mockID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revs []*domain.MockRevision
+	for rows.Next() {
+		rev, err := scanSQLiteRevision(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse expires_at: %w", err)
+			return nil, err
 		}
-		m.CreatedAt = createdAt
-		m.ExpiresAt = expiresAt
-		mocks = append(mocks, &m)
+		revs = append(revs, rev)
 	}
-	return mocks, nil
+	return revs, nil
+}
+
+func (r *D1MockRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	return r.exec(ctx, sqliteSaveOverride, saveOverrideArgs(o)...)
 }
 
-func (r *D1MockRepository) GetByPathAndMethod(userID, path, method string) (*domain.MockAPI, error) {
-	query := `
-		SELECT id, user_id, method, path, response_status, response_body, created_at, expires_at, hit_count
-		FROM mocks
-		WHERE user_id = ? AND path = ? AND method = ?
-	`
-	row := r.db.QueryRowContext(context.Background(), query, userID, path, method)
-
-	var m domain.MockAPI
-	var createdAtStr, expiresAtStr string
-	if err := row.Scan(
-		&m.ID,
-		&m.UserID,
-		&m.Method,
-		&m.Path,
-		&m.Status,
-		&m.ResponseBody,
-		&createdAtStr,
-		&expiresAtStr,
-		&m.HitCount,
-	); err != nil {
+func (r *D1MockRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	row := r.db.QueryRowContext(ctx, sqliteGetOverride, userID, environment, mockID)
+
+	o, err := scanSQLiteOverride(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	// Parse RFC3339 strings back to time.Time
-	createdAt, err := time.Parse(time.RFC3339, createdAtStr)
+	return o, nil
+}
+
+func (r *D1MockRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	rows, err := r.db.QueryContext(ctx, sqliteListOverrides, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse created_at: %w", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []*domain.MockOverride
+	for rows.Next() {
+		o, err := scanSQLiteOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
 	}
-	expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+	return overrides, nil
+}
+
+func (r *D1MockRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	return r.exec(ctx, sqliteDeleteOverride, userID, environment, mockID)
+}
+
+func (r *D1MockRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	return r.exec(ctx, sqliteDeleteEnvironment, userID, environment)
+}
+
+func (r *D1MockRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	if environment == "" {
+		return r.exec(ctx, sqliteClearActiveEnvironment, userID)
+	}
+	return r.exec(ctx, sqliteSetActiveEnvironment, userID, environment)
+}
+
+func (r *D1MockRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	var environment string
+	err := r.db.QueryRowContext(ctx, sqliteGetActiveEnvironment, userID).Scan(&environment)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return environment, err
+}
+
+func (r *D1MockRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	if policy == nil {
+		return r.exec(ctx, sqliteClearCORSPolicy, userID)
+	}
+	value, err := nullableJSONArg(policy, "policy")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse expires_at: %w", err)
+		return err
 	}
-	m.CreatedAt = createdAt
-	m.ExpiresAt = expiresAt
-	return &m, nil
+	return r.exec(ctx, sqliteSetCORSPolicy, userID, value)
 }
 
-func (r *D1MockRepository) IncrementHitCount(id string) error {
-	query := `UPDATE mocks SET hit_count = hit_count + 1 WHERE id = ?`
-	_, err := r.db.ExecContext(context.Background(), query, id)
-	return err
+func (r *D1MockRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	return scanCORSPolicy(r.db.QueryRowContext(ctx, sqliteGetCORSPolicy, userID))
 }
 
-func (r *D1MockRepository) DeleteExpired() error {
-	query := `DELETE FROM mocks WHERE expires_at < ?`
-	// Convert time.Time to RFC3339 string format for D1 compatibility
-	nowStr := time.Now().Format(time.RFC3339)
-	_, err := r.db.ExecContext(context.Background(), query, nowStr)
-	return err
+func (r *D1MockRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	if r.pending != nil {
+		return fn(r)
+	}
+
+	var pending []d1Statement
+	tx := &D1MockRepository{db: r.db, binding: r.binding, pending: &pending}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return r.execBatch(ctx, pending)
 }
 
-func (r *D1MockRepository) Delete(userID, id string) error {
-	query := `DELETE FROM mocks WHERE id = ? AND user_id = ?`
-	_, err := r.db.ExecContext(context.Background(), query, id, userID)
+// exec runs a write immediately, or queues it when inside WithinTx.
+func (r *D1MockRepository) exec(ctx context.Context, query string, args ...any) error {
+	if r.pending != nil {
+		*r.pending = append(*r.pending, d1Statement{query: query, args: args})
+		return nil
+	}
+	_, err := r.db.ExecContext(ctx, query, args...)
 	return err
 }
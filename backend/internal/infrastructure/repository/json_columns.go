@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// marshalNullableJSON encodes v for a nullable TEXT column. A nil v maps to
+// NULL, reported as valid == false.
+func marshalNullableJSON[T any](v *T) (string, bool, error) {
+	if v == nil {
+		return "", false, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// unmarshalNullableJSON is the inverse of marshalNullableJSON.
+func unmarshalNullableJSON[T any](s string, valid bool, column string) (*T, error) {
+	if !valid || s == "" {
+		return nil, nil
+	}
+	v := new(T)
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", column, err)
+	}
+	return v, nil
+}
@@ -1,7 +1,8 @@
 package repository
 
 import (
-	"fmt"
+	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,85 +10,353 @@ import (
 )
 
 type InMemoryMockRepository struct {
-	mu    sync.RWMutex
-	mocks map[string]*domain.MockAPI
+	mu        sync.RWMutex
+	mocks     map[string]*domain.MockAPI
+	revisions map[string][]*domain.MockRevision
+	overrides map[overrideID]*domain.MockOverride
+	// active maps a user ID to the active environment.
+	active map[string]string
+	cors   map[string]*domain.CORSPolicy
+	// undo is set on the repository handed to WithinTx callbacks, which
+	// shares the maps and the write lock held by WithinTx. Each write
+	// appends how to revert it.
+	undo *[]func()
+}
+
+type overrideID struct {
+	userID, environment, mockID string
 }
 
 func NewInMemoryMockRepository() *InMemoryMockRepository {
 	return &InMemoryMockRepository{
-		mocks: make(map[string]*domain.MockAPI),
+		mocks:     make(map[string]*domain.MockAPI),
+		revisions: make(map[string][]*domain.MockRevision),
+		overrides: make(map[overrideID]*domain.MockOverride),
+		active:    make(map[string]string),
+		cors:      make(map[string]*domain.CORSPolicy),
+	}
+}
+
+// cloneMock copies a mock so callers never share the stored value, matching
+// the database-backed repositories.
+func cloneMock(mock *domain.MockAPI) *domain.MockAPI {
+	clone := *mock
+	if mock.RequestSchema != nil {
+		rs := *mock.RequestSchema
+		clone.RequestSchema = &rs
+	}
+	if mock.RateLimit != nil {
+		rl := *mock.RateLimit
+		clone.RateLimit = &rl
+	}
+	clone.Match = mock.Match.Clone()
+	clone.CORS = mock.CORS.Clone()
+	return &clone
+}
+
+func cloneOverride(o *domain.MockOverride) *domain.MockOverride {
+	clone := *o
+	if o.ResponseBody != nil {
+		body := *o.ResponseBody
+		clone.ResponseBody = &body
 	}
+	return &clone
 }
 
-func (r *InMemoryMockRepository) Save(mock *domain.MockAPI) error {
+// lock takes the write lock, unless WithinTx already holds it, and returns
+// the function that releases it.
+func (r *InMemoryMockRepository) lock() func() {
+	if r.undo != nil {
+		return func() {}
+	}
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.mocks[mock.ID] = mock
-	return nil
+	return r.mu.Unlock
 }
 
-func (r *InMemoryMockRepository) GetByUser(userID string) ([]*domain.MockAPI, error) {
+// rlock is lock for reads.
+func (r *InMemoryMockRepository) rlock() func() {
+	if r.undo != nil {
+		return func() {}
+	}
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	return r.mu.RUnlock
+}
+
+// remember records how to revert a write made inside WithinTx.
+func (r *InMemoryMockRepository) remember(undo func()) {
+	if r.undo != nil {
+		*r.undo = append(*r.undo, undo)
+	}
+}
+
+// setEntry stores value under key in m, remembering the previous entry.
+// The caller holds the write lock.
+func setEntry[K comparable, V any](r *InMemoryMockRepository, m map[K]V, key K, value V) {
+	rememberEntry(r, m, key)
+	m[key] = value
+}
+
+// deleteEntry removes key from m, remembering the previous entry. The
+// caller holds the write lock.
+func deleteEntry[K comparable, V any](r *InMemoryMockRepository, m map[K]V, key K) {
+	if _, exists := m[key]; exists {
+		rememberEntry(r, m, key)
+		delete(m, key)
+	}
+}
+
+func rememberEntry[K comparable, V any](r *InMemoryMockRepository, m map[K]V, key K) {
+	if r.undo == nil {
+		return
+	}
+	prev, existed := m[key]
+	r.remember(func() {
+		if existed {
+			m[key] = prev
+		} else {
+			delete(m, key)
+		}
+	})
+}
+
+// deleteMock removes a mock with its revisions and overrides. The caller
+// holds the write lock.
+func (r *InMemoryMockRepository) deleteMock(id string) {
+	deleteEntry(r, r.mocks, id)
+	deleteEntry(r, r.revisions, id)
+	for key := range r.overrides {
+		if key.mockID == id {
+			deleteEntry(r, r.overrides, key)
+		}
+	}
+}
+
+func (r *InMemoryMockRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	defer r.lock()()
+	setEntry(r, r.mocks, mock.ID, cloneMock(mock))
+	return nil
+}
+
+func (r *InMemoryMockRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	defer r.rlock()()
 
 	var result []*domain.MockAPI
 	for _, mock := range r.mocks {
 		if mock.UserID == userID {
-			result = append(result, mock)
+			result = append(result, cloneMock(mock))
 		}
 	}
+	// Newest first, like the SQL repositories
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
 	return result, nil
 }
 
-func (r *InMemoryMockRepository) GetByPathAndMethod(userID, path, method string) (*domain.MockAPI, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+func (r *InMemoryMockRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	defer r.lock()()
 
-	for _, mock := range r.mocks {
-		if mock.UserID == userID && mock.Path == path && mock.Method == method {
-			return mock, nil
-		}
+	// Like the SQL repositories, only the owner's mock is updated and the
+	// creation time and hit count are kept.
+	current, exists := r.mocks[mock.ID]
+	if !exists || current.UserID != mock.UserID {
+		return nil
 	}
-	return nil, nil
+	updated := cloneMock(mock)
+	updated.CreatedAt = current.CreatedAt
+	updated.ExpiresAt = current.ExpiresAt
+	updated.HitCount = current.HitCount
+	setEntry(r, r.mocks, mock.ID, updated)
+	return nil
 }
 
-func (r *InMemoryMockRepository) IncrementHitCount(id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *InMemoryMockRepository) IncrementHitCount(ctx context.Context, id string) error {
+	defer r.lock()()
 
 	if mock, exists := r.mocks[id]; exists {
 		mock.HitCount++
+		r.remember(func() { mock.HitCount-- })
 	}
 	return nil
 }
 
-func (r *InMemoryMockRepository) DeleteExpired() error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *InMemoryMockRepository) DeleteExpired(ctx context.Context) error {
+	defer r.lock()()
 
 	now := time.Now()
 	for id, mock := range r.mocks {
 		if now.After(mock.ExpiresAt) {
-			delete(r.mocks, id)
+			r.deleteMock(id)
 		}
 	}
 	return nil
 }
 
-func (r *InMemoryMockRepository) Delete(userID, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *InMemoryMockRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	defer r.rlock()()
+
+	var stats domain.MockStats
+	now := time.Now()
+	for _, mock := range r.mocks {
+		if now.After(mock.ExpiresAt) {
+			stats.Expired++
+		} else {
+			stats.Active++
+		}
+	}
+	return stats, nil
+}
+
+func (r *InMemoryMockRepository) Delete(ctx context.Context, userID, id string) error {
+	defer r.lock()()
+
+	// Another user's mock is left alone without an error, so IDs can't be
+	// probed for existence.
+	if mock, exists := r.mocks[id]; exists && mock.UserID == userID {
+		r.deleteMock(id)
+	}
+	return nil
+}
+
+func (r *InMemoryMockRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	defer r.lock()()
+
+	clone := *rev
+	// Revisions are immutable, so the previous slice is a valid undo even
+	// if append reuses its array.
+	setEntry(r, r.revisions, rev.MockID, append(r.revisions[rev.MockID], &clone))
+	return nil
+}
+
+func (r *InMemoryMockRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	defer r.rlock()()
+
+	var result []*domain.MockRevision
+	for _, rev := range r.revisions[mockID] {
+		if rev.UserID == userID {
+			clone := *rev
+			result = append(result, &clone)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Number < result[j].Number
+	})
+	return result, nil
+}
+
+func (r *InMemoryMockRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	defer r.lock()()
+	setEntry(r, r.overrides, overrideID{o.UserID, o.Environment, o.MockID}, cloneOverride(o))
+	return nil
+}
+
+func (r *InMemoryMockRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	defer r.rlock()()
 
-	mock, exists := r.mocks[id]
-	if !exists {
-		return nil // Already deleted or doesn't exist
+	if o, exists := r.overrides[overrideID{userID, environment, mockID}]; exists {
+		return cloneOverride(o), nil
 	}
+	return nil, nil
+}
 
-	// Verify ownership
-	if mock.UserID != userID {
-		return fmt.Errorf("mock not found or access denied")
+func (r *InMemoryMockRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	defer r.rlock()()
+
+	var result []*domain.MockOverride
+	for key, o := range r.overrides {
+		if key.userID == userID {
+			result = append(result, cloneOverride(o))
+		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Environment != result[j].Environment {
+			return result[i].Environment < result[j].Environment
+		}
+		return result[i].MockID < result[j].MockID
+	})
+	return result, nil
+}
 
-	delete(r.mocks, id)
+func (r *InMemoryMockRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	defer r.lock()()
+	deleteEntry(r, r.overrides, overrideID{userID, environment, mockID})
+	return nil
+}
+
+func (r *InMemoryMockRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	defer r.lock()()
+
+	for key := range r.overrides {
+		if key.userID == userID && key.environment == environment {
+			deleteEntry(r, r.overrides, key)
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryMockRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	defer r.lock()()
+
+	if environment == "" {
+		deleteEntry(r, r.active, userID)
+	} else {
+		setEntry(r, r.active, userID, environment)
+	}
+	return nil
+}
+
+func (r *InMemoryMockRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	defer r.rlock()()
+	return r.active[userID], nil
+}
+
+func (r *InMemoryMockRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	defer r.lock()()
+
+	if policy == nil {
+		deleteEntry(r, r.cors, userID)
+	} else {
+		setEntry(r, r.cors, userID, policy.Clone())
+	}
+	return nil
+}
+
+func (r *InMemoryMockRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	defer r.rlock()()
+	return r.cors[userID].Clone(), nil
+}
+
+func (r *InMemoryMockRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	if r.undo != nil {
+		return fn(r)
+	}
+
+	// Hold the write lock for the whole transaction. fn writes to the live
+	// maps, and its writes are reverted, newest first, unless it succeeds.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var undo []func()
+	committed := false
+	defer func() {
+		if !committed {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+		}
+	}()
+
+	tx := &InMemoryMockRepository{
+		mocks:     r.mocks,
+		revisions: r.revisions,
+		overrides: r.overrides,
+		active:    r.active,
+		cors:      r.cors,
+		undo:      &undo,
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	committed = true
 	return nil
 }
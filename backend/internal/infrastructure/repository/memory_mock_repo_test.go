@@ -0,0 +1,15 @@
+package repository_test
+
+import (
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/infrastructure/repository/repotest"
+)
+
+func TestInMemoryMockRepository(t *testing.T) {
+	repotest.Run(t, func() (domain.MockRepository, func(), error) {
+		return repository.NewInMemoryMockRepository(), func() {}, nil
+	})
+}
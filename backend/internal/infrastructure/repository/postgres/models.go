@@ -18,4 +18,45 @@ type Mock struct {
 	HitCount       int32
 	CreatedAt      pgtype.Timestamp
 	ExpiresAt      pgtype.Timestamp
+	RequestSchema  pgtype.Text
+	RateLimit      pgtype.Text
+	MatchRules     pgtype.Text
+	Cors           pgtype.Text
+}
+
+type MockRevision struct {
+	MockID         pgtype.UUID
+	Number         int32
+	UserID         string
+	Author         string
+	Action         string
+	Method         string
+	Path           string
+	ResponseStatus int32
+	ResponseBody   string
+	RequestSchema  pgtype.Text
+	CreatedAt      pgtype.Timestamp
+	RateLimit      pgtype.Text
+	MatchRules     pgtype.Text
+	Cors           pgtype.Text
+}
+
+type MockOverride struct {
+	UserID         string
+	Environment    string
+	MockID         pgtype.UUID
+	ResponseStatus int32
+	ResponseBody   pgtype.Text
+	DelayMs        int32
+	UpdatedAt      pgtype.Timestamp
+}
+
+type ActiveEnvironment struct {
+	UserID      string
+	Environment string
+}
+
+type CorsPolicy struct {
+	UserID string
+	Policy string
 }
@@ -11,10 +11,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const clearActiveEnvironment = `-- name: ClearActiveEnvironment :exec
+DELETE FROM active_environments
+WHERE user_id = $1
+`
+
+func (q *Queries) ClearActiveEnvironment(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, clearActiveEnvironment, userID)
+	return err
+}
+
+const clearCORSPolicy = `-- name: ClearCORSPolicy :exec
+DELETE FROM cors_policies
+WHERE user_id = $1
+`
+
+func (q *Queries) ClearCORSPolicy(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, clearCORSPolicy, userID)
+	return err
+}
+
+const countMocks = `-- name: CountMocks :one
+SELECT COUNT(*) FILTER (WHERE expires_at >= NOW()) AS active,
+       COUNT(*) FILTER (WHERE expires_at < NOW()) AS expired
+FROM mocks
+`
+
+type CountMocksRow struct {
+	Active  int64
+	Expired int64
+}
+
+func (q *Queries) CountMocks(ctx context.Context) (CountMocksRow, error) {
+	row := q.db.QueryRow(ctx, countMocks)
+	var i CountMocksRow
+	err := row.Scan(&i.Active, &i.Expired)
+	return i, err
+}
+
 const createMock = `-- name: CreateMock :one
-INSERT INTO mocks (id, user_id, method, path, response_status, response_body, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at
+INSERT INTO mocks (id, user_id, method, path, response_status, response_body, expires_at, request_schema, created_at, hit_count, rate_limit, match_rules, cors)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at, request_schema, rate_limit, match_rules, cors
 `
 
 type CreateMockParams struct {
@@ -25,6 +63,12 @@ type CreateMockParams struct {
 	ResponseStatus int32
 	ResponseBody   string
 	ExpiresAt      pgtype.Timestamp
+	RequestSchema  pgtype.Text
+	CreatedAt      pgtype.Timestamp
+	HitCount       int32
+	RateLimit      pgtype.Text
+	MatchRules     pgtype.Text
+	Cors           pgtype.Text
 }
 
 func (q *Queries) CreateMock(ctx context.Context, arg CreateMockParams) (Mock, error) {
@@ -36,6 +80,12 @@ func (q *Queries) CreateMock(ctx context.Context, arg CreateMockParams) (Mock, e
 		arg.ResponseStatus,
 		arg.ResponseBody,
 		arg.ExpiresAt,
+		arg.RequestSchema,
+		arg.CreatedAt,
+		arg.HitCount,
+		arg.RateLimit,
+		arg.MatchRules,
+		arg.Cors,
 	)
 	var i Mock
 	err := row.Scan(
@@ -48,10 +98,71 @@ func (q *Queries) CreateMock(ctx context.Context, arg CreateMockParams) (Mock, e
 		&i.HitCount,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.RequestSchema,
+		&i.RateLimit,
+		&i.MatchRules,
+		&i.Cors,
 	)
 	return i, err
 }
 
+const createMockRevision = `-- name: CreateMockRevision :exec
+INSERT INTO mock_revisions (mock_id, number, user_id, author, action, method, path, response_status, response_body, request_schema, created_at, rate_limit, match_rules, cors)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+`
+
+type CreateMockRevisionParams struct {
+	MockID         pgtype.UUID
+	Number         int32
+	UserID         string
+	Author         string
+	Action         string
+	Method         string
+	Path           string
+	ResponseStatus int32
+	ResponseBody   string
+	RequestSchema  pgtype.Text
+	CreatedAt      pgtype.Timestamp
+	RateLimit      pgtype.Text
+	MatchRules     pgtype.Text
+	Cors           pgtype.Text
+}
+
+func (q *Queries) CreateMockRevision(ctx context.Context, arg CreateMockRevisionParams) error {
+	_, err := q.db.Exec(ctx, createMockRevision,
+		arg.MockID,
+		arg.Number,
+		arg.UserID,
+		arg.Author,
+		arg.Action,
+		arg.Method,
+		arg.Path,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+		arg.RequestSchema,
+		arg.CreatedAt,
+		arg.RateLimit,
+		arg.MatchRules,
+		arg.Cors,
+	)
+	return err
+}
+
+const deleteEnvironment = `-- name: DeleteEnvironment :exec
+DELETE FROM mock_overrides
+WHERE user_id = $1 AND environment = $2
+`
+
+type DeleteEnvironmentParams struct {
+	UserID      string
+	Environment string
+}
+
+func (q *Queries) DeleteEnvironment(ctx context.Context, arg DeleteEnvironmentParams) error {
+	_, err := q.db.Exec(ctx, deleteEnvironment, arg.UserID, arg.Environment)
+	return err
+}
+
 const deleteExpired = `-- name: DeleteExpired :exec
 DELETE FROM mocks
 WHERE expires_at < NOW()
@@ -62,6 +173,26 @@ func (q *Queries) DeleteExpired(ctx context.Context) error {
 	return err
 }
 
+const deleteExpiredOverrides = `-- name: DeleteExpiredOverrides :exec
+DELETE FROM mock_overrides
+WHERE mock_id IN (SELECT id FROM mocks WHERE expires_at < NOW())
+`
+
+func (q *Queries) DeleteExpiredOverrides(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredOverrides)
+	return err
+}
+
+const deleteExpiredRevisions = `-- name: DeleteExpiredRevisions :exec
+DELETE FROM mock_revisions
+WHERE mock_id IN (SELECT id FROM mocks WHERE expires_at < NOW())
+`
+
+func (q *Queries) DeleteExpiredRevisions(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredRevisions)
+	return err
+}
+
 const deleteMock = `-- name: DeleteMock :exec
 DELETE FROM mocks
 WHERE id = $1 AND user_id = $2
@@ -77,8 +208,78 @@ func (q *Queries) DeleteMock(ctx context.Context, arg DeleteMockParams) error {
 	return err
 }
 
+const deleteMockOverride = `-- name: DeleteMockOverride :exec
+DELETE FROM mock_overrides
+WHERE user_id = $1 AND environment = $2 AND mock_id = $3
+`
+
+type DeleteMockOverrideParams struct {
+	UserID      string
+	Environment string
+	MockID      pgtype.UUID
+}
+
+func (q *Queries) DeleteMockOverride(ctx context.Context, arg DeleteMockOverrideParams) error {
+	_, err := q.db.Exec(ctx, deleteMockOverride, arg.UserID, arg.Environment, arg.MockID)
+	return err
+}
+
+const deleteMockRevisions = `-- name: DeleteMockRevisions :exec
+DELETE FROM mock_revisions
+WHERE mock_id = $1 AND user_id = $2
+`
+
+type DeleteMockRevisionsParams struct {
+	MockID pgtype.UUID
+	UserID string
+}
+
+func (q *Queries) DeleteMockRevisions(ctx context.Context, arg DeleteMockRevisionsParams) error {
+	_, err := q.db.Exec(ctx, deleteMockRevisions, arg.MockID, arg.UserID)
+	return err
+}
+
+const deleteOverridesOfMock = `-- name: DeleteOverridesOfMock :exec
+DELETE FROM mock_overrides
+WHERE mock_id = $1 AND user_id = $2
+`
+
+type DeleteOverridesOfMockParams struct {
+	MockID pgtype.UUID
+	UserID string
+}
+
+func (q *Queries) DeleteOverridesOfMock(ctx context.Context, arg DeleteOverridesOfMockParams) error {
+	_, err := q.db.Exec(ctx, deleteOverridesOfMock, arg.MockID, arg.UserID)
+	return err
+}
+
+const getActiveEnvironment = `-- name: GetActiveEnvironment :one
+SELECT environment FROM active_environments
+WHERE user_id = $1
+`
+
+func (q *Queries) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	row := q.db.QueryRow(ctx, getActiveEnvironment, userID)
+	var environment string
+	err := row.Scan(&environment)
+	return environment, err
+}
+
+const getCORSPolicy = `-- name: GetCORSPolicy :one
+SELECT policy FROM cors_policies
+WHERE user_id = $1
+`
+
+func (q *Queries) GetCORSPolicy(ctx context.Context, userID string) (string, error) {
+	row := q.db.QueryRow(ctx, getCORSPolicy, userID)
+	var policy string
+	err := row.Scan(&policy)
+	return policy, err
+}
+
 const getMock = `-- name: GetMock :one
-SELECT id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at FROM mocks
+SELECT id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at, request_schema, rate_limit, match_rules, cors FROM mocks
 WHERE id = $1 LIMIT 1
 `
 
@@ -95,36 +296,36 @@ func (q *Queries) GetMock(ctx context.Context, id pgtype.UUID) (Mock, error) {
 		&i.HitCount,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.RequestSchema,
+		&i.RateLimit,
+		&i.MatchRules,
+		&i.Cors,
 	)
 	return i, err
 }
 
-const getMockByPathAndMethod = `-- name: GetMockByPathAndMethod :one
-SELECT id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at FROM mocks
-WHERE user_id = $1 AND path = $2 AND method = $3
-ORDER BY created_at DESC
-LIMIT 1
+const getMockOverride = `-- name: GetMockOverride :one
+SELECT user_id, environment, mock_id, response_status, response_body, delay_ms, updated_at FROM mock_overrides
+WHERE user_id = $1 AND environment = $2 AND mock_id = $3
 `
 
-type GetMockByPathAndMethodParams struct {
-	UserID string
-	Path   string
-	Method string
+type GetMockOverrideParams struct {
+	UserID      string
+	Environment string
+	MockID      pgtype.UUID
 }
 
-func (q *Queries) GetMockByPathAndMethod(ctx context.Context, arg GetMockByPathAndMethodParams) (Mock, error) {
-	row := q.db.QueryRow(ctx, getMockByPathAndMethod, arg.UserID, arg.Path, arg.Method)
-	var i Mock
+func (q *Queries) GetMockOverride(ctx context.Context, arg GetMockOverrideParams) (MockOverride, error) {
+	row := q.db.QueryRow(ctx, getMockOverride, arg.UserID, arg.Environment, arg.MockID)
+	var i MockOverride
 	err := row.Scan(
-		&i.ID,
 		&i.UserID,
-		&i.Method,
-		&i.Path,
+		&i.Environment,
+		&i.MockID,
 		&i.ResponseStatus,
 		&i.ResponseBody,
-		&i.HitCount,
-		&i.CreatedAt,
-		&i.ExpiresAt,
+		&i.DelayMs,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -140,8 +341,88 @@ func (q *Queries) IncrementHitCount(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const listMockOverridesByUser = `-- name: ListMockOverridesByUser :many
+SELECT user_id, environment, mock_id, response_status, response_body, delay_ms, updated_at FROM mock_overrides
+WHERE user_id = $1
+ORDER BY environment, mock_id
+`
+
+func (q *Queries) ListMockOverridesByUser(ctx context.Context, userID string) ([]MockOverride, error) {
+	rows, err := q.db.Query(ctx, listMockOverridesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MockOverride
+	for rows.Next() {
+		var i MockOverride
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Environment,
+			&i.MockID,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+			&i.DelayMs,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMockRevisions = `-- name: ListMockRevisions :many
+SELECT mock_id, number, user_id, author, action, method, path, response_status, response_body, request_schema, created_at, rate_limit, match_rules, cors FROM mock_revisions
+WHERE mock_id = $1 AND user_id = $2
+ORDER BY number
+`
+
+type ListMockRevisionsParams struct {
+	MockID pgtype.UUID
+	UserID string
+}
+
+func (q *Queries) ListMockRevisions(ctx context.Context, arg ListMockRevisionsParams) ([]MockRevision, error) {
+	rows, err := q.db.Query(ctx, listMockRevisions, arg.MockID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MockRevision
+	for rows.Next() {
+		var i MockRevision
+		if err := rows.Scan(
+			&i.MockID,
+			&i.Number,
+			&i.UserID,
+			&i.Author,
+			&i.Action,
+			&i.Method,
+			&i.Path,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+			&i.RequestSchema,
+			&i.CreatedAt,
+			&i.RateLimit,
+			&i.MatchRules,
+			&i.Cors,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMocksByUser = `-- name: ListMocksByUser :many
-SELECT id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at FROM mocks
+SELECT id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at, request_schema, rate_limit, match_rules, cors FROM mocks
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -165,6 +446,10 @@ func (q *Queries) ListMocksByUser(ctx context.Context, userID string) ([]Mock, e
 			&i.HitCount,
 			&i.CreatedAt,
 			&i.ExpiresAt,
+			&i.RequestSchema,
+			&i.RateLimit,
+			&i.MatchRules,
+			&i.Cors,
 		); err != nil {
 			return nil, err
 		}
@@ -176,11 +461,76 @@ func (q *Queries) ListMocksByUser(ctx context.Context, userID string) ([]Mock, e
 	return items, nil
 }
 
+const saveMockOverride = `-- name: SaveMockOverride :exec
+INSERT INTO mock_overrides (user_id, environment, mock_id, response_status, response_body, delay_ms, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id, environment, mock_id) DO UPDATE SET
+    response_status = EXCLUDED.response_status,
+    response_body = EXCLUDED.response_body,
+    delay_ms = EXCLUDED.delay_ms,
+    updated_at = EXCLUDED.updated_at
+`
+
+type SaveMockOverrideParams struct {
+	UserID         string
+	Environment    string
+	MockID         pgtype.UUID
+	ResponseStatus int32
+	ResponseBody   pgtype.Text
+	DelayMs        int32
+	UpdatedAt      pgtype.Timestamp
+}
+
+func (q *Queries) SaveMockOverride(ctx context.Context, arg SaveMockOverrideParams) error {
+	_, err := q.db.Exec(ctx, saveMockOverride,
+		arg.UserID,
+		arg.Environment,
+		arg.MockID,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+		arg.DelayMs,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const setActiveEnvironment = `-- name: SetActiveEnvironment :exec
+INSERT INTO active_environments (user_id, environment)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET environment = EXCLUDED.environment
+`
+
+type SetActiveEnvironmentParams struct {
+	UserID      string
+	Environment string
+}
+
+func (q *Queries) SetActiveEnvironment(ctx context.Context, arg SetActiveEnvironmentParams) error {
+	_, err := q.db.Exec(ctx, setActiveEnvironment, arg.UserID, arg.Environment)
+	return err
+}
+
+const setCORSPolicy = `-- name: SetCORSPolicy :exec
+INSERT INTO cors_policies (user_id, policy)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET policy = EXCLUDED.policy
+`
+
+type SetCORSPolicyParams struct {
+	UserID string
+	Policy string
+}
+
+func (q *Queries) SetCORSPolicy(ctx context.Context, arg SetCORSPolicyParams) error {
+	_, err := q.db.Exec(ctx, setCORSPolicy, arg.UserID, arg.Policy)
+	return err
+}
+
 const updateMock = `-- name: UpdateMock :one
 UPDATE mocks
-SET method = $3, path = $4, response_status = $5, response_body = $6
+SET method = $3, path = $4, response_status = $5, response_body = $6, request_schema = $7, rate_limit = $8, match_rules = $9, cors = $10
 WHERE id = $1 AND user_id = $2
-RETURNING id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at
+RETURNING id, user_id, method, path, response_status, response_body, hit_count, created_at, expires_at, request_schema, rate_limit, match_rules, cors
 `
 
 type UpdateMockParams struct {
@@ -190,6 +540,10 @@ type UpdateMockParams struct {
 	Path           string
 	ResponseStatus int32
 	ResponseBody   string
+	RequestSchema  pgtype.Text
+	RateLimit      pgtype.Text
+	MatchRules     pgtype.Text
+	Cors           pgtype.Text
 }
 
 func (q *Queries) UpdateMock(ctx context.Context, arg UpdateMockParams) (Mock, error) {
@@ -200,6 +554,10 @@ func (q *Queries) UpdateMock(ctx context.Context, arg UpdateMockParams) (Mock, e
 		arg.Path,
 		arg.ResponseStatus,
 		arg.ResponseBody,
+		arg.RequestSchema,
+		arg.RateLimit,
+		arg.MatchRules,
+		arg.Cors,
 	)
 	var i Mock
 	err := row.Scan(
@@ -212,6 +570,10 @@ func (q *Queries) UpdateMock(ctx context.Context, arg UpdateMockParams) (Mock, e
 		&i.HitCount,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.RequestSchema,
+		&i.RateLimit,
+		&i.MatchRules,
+		&i.Cors,
 	)
 	return i, err
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"errors"
 
@@ -15,24 +16,47 @@ import (
 )
 
 type PostgresMockRepository struct {
+	pool    *pgxpool.Pool
 	queries *pgrepo.Queries
 }
 
 func NewPostgresMockRepository(pool *pgxpool.Pool) *PostgresMockRepository {
 	return &PostgresMockRepository{
+		pool:    pool,
 		queries: pgrepo.New(pool),
 	}
 }
 
-func (r *PostgresMockRepository) Save(mock *domain.MockAPI) error {
+func (r *PostgresMockRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
 	var uuid pgtype.UUID
 	if err := uuid.Scan(mock.ID); err != nil {
 		return fmt.Errorf("invalid UUID: %w", err)
 	}
 
 	expiresAt := pgtype.Timestamp{Time: mock.ExpiresAt, Valid: !mock.ExpiresAt.IsZero()}
+	createdAt := mock.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	requestSchema, err := nullableJSONText(mock.RequestSchema, "request_schema")
+	if err != nil {
+		return err
+	}
+	rateLimit, err := nullableJSONText(mock.RateLimit, "rate_limit")
+	if err != nil {
+		return err
+	}
+	matchRules, err := nullableJSONText(mock.Match, "match_rules")
+	if err != nil {
+		return err
+	}
+	cors, err := nullableJSONText(mock.CORS, "cors")
+	if err != nil {
+		return err
+	}
 
-	_, err := r.queries.CreateMock(context.Background(), pgrepo.CreateMockParams{
+	_, err = r.queries.CreateMock(ctx, pgrepo.CreateMockParams{
 		ID:             uuid,
 		UserID:         mock.UserID,
 		Method:         mock.Method,
@@ -40,45 +64,259 @@ func (r *PostgresMockRepository) Save(mock *domain.MockAPI) error {
 		ResponseStatus: int32(mock.Status),
 		ResponseBody:   mock.ResponseBody,
 		ExpiresAt:      expiresAt,
+		RequestSchema:  requestSchema,
+		CreatedAt:      pgtype.Timestamp{Time: createdAt, Valid: true},
+		HitCount:       int32(mock.HitCount),
+		RateLimit:      rateLimit,
+		MatchRules:     matchRules,
+		Cors:           cors,
 	})
 	return err
 }
 
-func (r *PostgresMockRepository) Update(mock *domain.MockAPI) error {
+func (r *PostgresMockRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
 	var uuid pgtype.UUID
 	if err := uuid.Scan(mock.ID); err != nil {
 		return fmt.Errorf("invalid UUID: %w", err)
 	}
 
-	_, err := r.queries.UpdateMock(context.Background(), pgrepo.UpdateMockParams{
+	requestSchema, err := nullableJSONText(mock.RequestSchema, "request_schema")
+	if err != nil {
+		return err
+	}
+	rateLimit, err := nullableJSONText(mock.RateLimit, "rate_limit")
+	if err != nil {
+		return err
+	}
+	matchRules, err := nullableJSONText(mock.Match, "match_rules")
+	if err != nil {
+		return err
+	}
+	cors, err := nullableJSONText(mock.CORS, "cors")
+	if err != nil {
+		return err
+	}
+
+	_, err = r.queries.UpdateMock(ctx, pgrepo.UpdateMockParams{
 		ID:             uuid,
 		UserID:         mock.UserID,
 		Method:         mock.Method,
 		Path:           mock.Path,
 		ResponseStatus: int32(mock.Status),
 		ResponseBody:   mock.ResponseBody,
+		RequestSchema:  requestSchema,
+		RateLimit:      rateLimit,
+		MatchRules:     matchRules,
+		Cors:           cors,
 	})
+	// Updating a missing or foreign mock is a no-op, as in the other
+	// repositories.
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
 	return err
 }
 
-func (r *PostgresMockRepository) GetByUser(userID string) ([]*domain.MockAPI, error) {
-	mocks, err := r.queries.ListMocksByUser(context.Background(), userID)
+func (r *PostgresMockRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	mocks, err := r.queries.ListMocksByUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	var result []*domain.MockAPI
 	for _, m := range mocks {
-		result = append(result, toDomainMock(m))
+		mock, err := toDomainMock(m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, mock)
 	}
 	return result, nil
 }
 
-func (r *PostgresMockRepository) GetByPathAndMethod(userID, path, method string) (*domain.MockAPI, error) {
-	mock, err := r.queries.GetMockByPathAndMethod(context.Background(), pgrepo.GetMockByPathAndMethodParams{
+func (r *PostgresMockRepository) IncrementHitCount(ctx context.Context, id string) error {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.queries.IncrementHitCount(ctx, uuid)
+}
+
+func (r *PostgresMockRepository) DeleteExpired(ctx context.Context) error {
+	return r.WithinTx(ctx, func(repo domain.MockRepository) error {
+		q := repo.(*PostgresMockRepository).queries
+		if err := q.DeleteExpiredRevisions(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteExpiredOverrides(ctx); err != nil {
+			return err
+		}
+		return q.DeleteExpired(ctx)
+	})
+}
+
+func (r *PostgresMockRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	row, err := r.queries.CountMocks(ctx)
+	if err != nil {
+		return domain.MockStats{}, err
+	}
+	return domain.MockStats{Active: int(row.Active), Expired: int(row.Expired)}, nil
+}
+
+func (r *PostgresMockRepository) Delete(ctx context.Context, userID, id string) error {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.WithinTx(ctx, func(repo domain.MockRepository) error {
+		q := repo.(*PostgresMockRepository).queries
+		err := q.DeleteMockRevisions(ctx, pgrepo.DeleteMockRevisionsParams{
+			MockID: uuid,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.DeleteOverridesOfMock(ctx, pgrepo.DeleteOverridesOfMockParams{
+			MockID: uuid,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteMock(ctx, pgrepo.DeleteMockParams{
+			ID:     uuid,
+			UserID: userID,
+		})
+	})
+}
+
+func (r *PostgresMockRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(rev.MockID); err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	requestSchema, err := nullableJSONText(rev.RequestSchema, "request_schema")
+	if err != nil {
+		return err
+	}
+	rateLimit, err := nullableJSONText(rev.RateLimit, "rate_limit")
+	if err != nil {
+		return err
+	}
+	matchRules, err := nullableJSONText(rev.Match, "match_rules")
+	if err != nil {
+		return err
+	}
+	cors, err := nullableJSONText(rev.CORS, "cors")
+	if err != nil {
+		return err
+	}
+
+	return r.queries.CreateMockRevision(ctx, pgrepo.CreateMockRevisionParams{
+		MockID:         uuid,
+		Number:         int32(rev.Number),
+		UserID:         rev.UserID,
+		Author:         rev.Author,
+		Action:         rev.Action,
+		Method:         rev.Method,
+		Path:           rev.Path,
+		ResponseStatus: int32(rev.Status),
+		ResponseBody:   rev.ResponseBody,
+		RequestSchema:  requestSchema,
+		CreatedAt:      pgtype.Timestamp{Time: rev.CreatedAt, Valid: true},
+		RateLimit:      rateLimit,
+		MatchRules:     matchRules,
+		Cors:           cors,
+	})
+}
+
+func (r *PostgresMockRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(mockID); err != nil {
+		// Not a mock ID this repository could have stored.
+		return nil, nil
+	}
+
+	revs, err := r.queries.ListMockRevisions(ctx, pgrepo.ListMockRevisionsParams{
+		MockID: uuid,
 		UserID: userID,
-		Path:   path,
-		Method: method,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*domain.MockRevision
+	for _, rev := range revs {
+		requestSchema, err := unmarshalNullableJSON[domain.RequestSchema](rev.RequestSchema.String, rev.RequestSchema.Valid, "request_schema")
+		if err != nil {
+			return nil, err
+		}
+		rateLimit, err := unmarshalNullableJSON[domain.RateLimit](rev.RateLimit.String, rev.RateLimit.Valid, "rate_limit")
+		if err != nil {
+			return nil, err
+		}
+		matchRules, err := unmarshalNullableJSON[domain.MatchRules](rev.MatchRules.String, rev.MatchRules.Valid, "match_rules")
+		if err != nil {
+			return nil, err
+		}
+		cors, err := unmarshalNullableJSON[domain.CORSOverride](rev.Cors.String, rev.Cors.Valid, "cors")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &domain.MockRevision{
+			MockID:        uuidToString(rev.MockID),
+			Number:        int(rev.Number),
+			UserID:        rev.UserID,
+			Author:        rev.Author,
+			Action:        rev.Action,
+			Method:        rev.Method,
+			Path:          rev.Path,
+			Status:        int(rev.ResponseStatus),
+			ResponseBody:  rev.ResponseBody,
+			RequestSchema: requestSchema,
+			RateLimit:     rateLimit,
+			Match:         matchRules,
+			CORS:          cors,
+			CreatedAt:     rev.CreatedAt.Time,
+		})
+	}
+	return result, nil
+}
+
+func (r *PostgresMockRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(o.MockID); err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	var body pgtype.Text
+	if o.ResponseBody != nil {
+		body = pgtype.Text{String: *o.ResponseBody, Valid: true}
+	}
+
+	return r.queries.SaveMockOverride(ctx, pgrepo.SaveMockOverrideParams{
+		UserID:         o.UserID,
+		Environment:    o.Environment,
+		MockID:         uuid,
+		ResponseStatus: int32(o.Status),
+		ResponseBody:   body,
+		DelayMs:        int32(o.DelayMs),
+		UpdatedAt:      pgtype.Timestamp{Time: o.UpdatedAt, Valid: true},
+	})
+}
+
+func (r *PostgresMockRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(mockID); err != nil {
+		return nil, nil
+	}
+
+	o, err := r.queries.GetMockOverride(ctx, pgrepo.GetMockOverrideParams{
+		UserID:      userID,
+		Environment: environment,
+		MockID:      uuid,
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -86,44 +324,158 @@ func (r *PostgresMockRepository) GetByPathAndMethod(userID, path, method string)
 		}
 		return nil, err
 	}
-	return toDomainMock(mock), nil
+	return toDomainOverride(o), nil
 }
 
-func (r *PostgresMockRepository) IncrementHitCount(id string) error {
+func (r *PostgresMockRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	overrides, err := r.queries.ListMockOverridesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*domain.MockOverride
+	for _, o := range overrides {
+		result = append(result, toDomainOverride(o))
+	}
+	return result, nil
+}
+
+func (r *PostgresMockRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
 	var uuid pgtype.UUID
-	if err := uuid.Scan(id); err != nil {
-		return fmt.Errorf("invalid UUID: %w", err)
+	if err := uuid.Scan(mockID); err != nil {
+		return nil
 	}
-	return r.queries.IncrementHitCount(context.Background(), uuid)
+	return r.queries.DeleteMockOverride(ctx, pgrepo.DeleteMockOverrideParams{
+		UserID:      userID,
+		Environment: environment,
+		MockID:      uuid,
+	})
+}
+
+func (r *PostgresMockRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	return r.queries.DeleteEnvironment(ctx, pgrepo.DeleteEnvironmentParams{
+		UserID:      userID,
+		Environment: environment,
+	})
 }
 
-func (r *PostgresMockRepository) DeleteExpired() error {
-	return r.queries.DeleteExpired(context.Background())
+func (r *PostgresMockRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	if environment == "" {
+		return r.queries.ClearActiveEnvironment(ctx, userID)
+	}
+	return r.queries.SetActiveEnvironment(ctx, pgrepo.SetActiveEnvironmentParams{
+		UserID:      userID,
+		Environment: environment,
+	})
 }
 
-func (r *PostgresMockRepository) Delete(userID, id string) error {
-	var uuid pgtype.UUID
-	if err := uuid.Scan(id); err != nil {
-		return fmt.Errorf("invalid UUID: %w", err)
+func (r *PostgresMockRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	environment, err := r.queries.GetActiveEnvironment(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
 	}
-	return r.queries.DeleteMock(context.Background(), pgrepo.DeleteMockParams{
-		ID:     uuid,
+	return environment, err
+}
+
+func (r *PostgresMockRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	if policy == nil {
+		return r.queries.ClearCORSPolicy(ctx, userID)
+	}
+	value, err := nullableJSONText(policy, "policy")
+	if err != nil {
+		return err
+	}
+	return r.queries.SetCORSPolicy(ctx, pgrepo.SetCORSPolicyParams{
 		UserID: userID,
+		Policy: value.String,
 	})
 }
 
-func toDomainMock(m pgrepo.Mock) *domain.MockAPI {
+func (r *PostgresMockRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	policy, err := r.queries.GetCORSPolicy(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalNullableJSON[domain.CORSPolicy](policy, true, "policy")
+}
+
+func (r *PostgresMockRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	// A repository bound to a transaction has no pool; nested calls simply
+	// join the outer transaction.
+	if r.pool == nil {
+		return fn(r)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&PostgresMockRepository{queries: r.queries.WithTx(tx)}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func toDomainMock(m pgrepo.Mock) (*domain.MockAPI, error) {
+	requestSchema, err := unmarshalNullableJSON[domain.RequestSchema](m.RequestSchema.String, m.RequestSchema.Valid, "request_schema")
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := unmarshalNullableJSON[domain.RateLimit](m.RateLimit.String, m.RateLimit.Valid, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	matchRules, err := unmarshalNullableJSON[domain.MatchRules](m.MatchRules.String, m.MatchRules.Valid, "match_rules")
+	if err != nil {
+		return nil, err
+	}
+	cors, err := unmarshalNullableJSON[domain.CORSOverride](m.Cors.String, m.Cors.Valid, "cors")
+	if err != nil {
+		return nil, err
+	}
 	return &domain.MockAPI{
-		ID:           uuidToString(m.ID),
-		UserID:       m.UserID,
-		Method:       m.Method,
-		Path:         m.Path,
-		Status:       int(m.ResponseStatus),
-		ResponseBody: m.ResponseBody,
-		HitCount:     int(m.HitCount),
-		CreatedAt:    m.CreatedAt.Time,
-		ExpiresAt:    m.ExpiresAt.Time,
+		ID:            uuidToString(m.ID),
+		UserID:        m.UserID,
+		Method:        m.Method,
+		Path:          m.Path,
+		Status:        int(m.ResponseStatus),
+		ResponseBody:  m.ResponseBody,
+		RequestSchema: requestSchema,
+		RateLimit:     rateLimit,
+		Match:         matchRules,
+		CORS:          cors,
+		HitCount:      int(m.HitCount),
+		CreatedAt:     m.CreatedAt.Time,
+		ExpiresAt:     m.ExpiresAt.Time,
+	}, nil
+}
+
+func toDomainOverride(o pgrepo.MockOverride) *domain.MockOverride {
+	override := &domain.MockOverride{
+		UserID:      o.UserID,
+		Environment: o.Environment,
+		MockID:      uuidToString(o.MockID),
+		Status:      int(o.ResponseStatus),
+		DelayMs:     int(o.DelayMs),
+		UpdatedAt:   o.UpdatedAt.Time,
+	}
+	if o.ResponseBody.Valid {
+		override.ResponseBody = &o.ResponseBody.String
+	}
+	return override
+}
+
+func nullableJSONText[T any](v *T, column string) (pgtype.Text, error) {
+	s, valid, err := marshalNullableJSON(v)
+	if err != nil {
+		return pgtype.Text{}, fmt.Errorf("failed to encode %s: %w", column, err)
 	}
+	return pgtype.Text{String: s, Valid: valid}, nil
 }
 
 func uuidToString(uuid pgtype.UUID) string {
@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/migrate"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/infrastructure/repository/repotest"
+	sqlfiles "mock-api-backend/sql"
+)
+
+// TestPostgresMockRepository runs against the database at
+// TEST_POSTGRES_DSN, e.g. postgres://postgres@localhost:5432/mock_api_test,
+// migrating it first. Cases use fresh user IDs, but never point it at data
+// you want to keep.
+func TestPostgresMockRepository(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	migrations, err := migrate.Load(sqlfiles.PostgresMigrations())
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrate.New(migrate.NewPostgres(pool), migrations).Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repotest.Run(t, func() (domain.MockRepository, func(), error) {
+		return repository.NewPostgresMockRepository(pool), func() {}, nil
+	})
+}
@@ -0,0 +1,832 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"mock-api-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Cases lists the conformance checks in the order they run.
+var Cases = []Case{
+	{"SaveAndGet", testSaveAndGet},
+	{"GetMissing", testGetMissing},
+	{"GetByUserIsolationAndOrder", testGetByUser},
+	{"SameRouteDifferentMatch", testSameRouteDifferentMatch},
+	{"Update", testUpdate},
+	{"UpdateMissingOrForeign", testUpdateMissingOrForeign},
+	{"IncrementHitCountConcurrently", testIncrementHitCount},
+	{"Delete", testDelete},
+	{"DeleteExpired", testDeleteExpired},
+	{"Stats", testStats},
+	{"WithinTxCommits", testWithinTxCommits},
+	{"WithinTxRollsBack", testWithinTxRollsBack},
+	{"ReturnsCopies", testReturnsCopies},
+	{"RevisionsInOrderPerUser", testRevisions},
+	{"DeleteRemovesRevisions", testDeleteRemovesRevisions},
+	{"WithinTxRollsBackRevisions", testWithinTxRollsBackRevisions},
+	{"Overrides", testOverrides},
+	{"DeleteRemovesOverrides", testDeleteRemovesOverrides},
+	{"ActiveEnvironment", testActiveEnvironment},
+	{"CORSPolicy", testCORSPolicy},
+}
+
+// ctx is passed to every repository call; the cases never cancel it.
+var ctx = context.Background()
+
+// timeTolerance absorbs the precision lost by backends that store times
+// as RFC3339 strings.
+const timeTolerance = time.Second
+
+func newMock(userID, method, path string, createdAt time.Time) *domain.MockAPI {
+	return &domain.MockAPI{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		Method:       method,
+		Path:         path,
+		Status:       200,
+		ResponseBody: `{"ok":true}`,
+		CreatedAt:    createdAt,
+		ExpiresAt:    createdAt.Add(time.Hour),
+	}
+}
+
+func newUserID() string {
+	return "repotest-" + uuid.New().String()
+}
+
+// now is truncated to whole seconds so every backend stores it exactly.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func save(t testing.TB, repo domain.MockRepository, mocks ...*domain.MockAPI) {
+	t.Helper()
+	for _, m := range mocks {
+		if err := repo.Save(ctx, m); err != nil {
+			t.Fatalf("Save(%s %s): %v", m.Method, m.Path, err)
+		}
+	}
+}
+
+// get returns the user's mock with the given method and path, or nil.
+func get(t testing.TB, repo domain.MockRepository, userID, path, method string) *domain.MockAPI {
+	t.Helper()
+	for _, m := range list(t, repo, userID) {
+		if m.Path == path && m.Method == method {
+			return m
+		}
+	}
+	return nil
+}
+
+func list(t testing.TB, repo domain.MockRepository, userID string) []*domain.MockAPI {
+	t.Helper()
+	mocks, err := repo.GetByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUser: %v", err)
+	}
+	return mocks
+}
+
+func sameTime(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d > -timeTolerance && d < timeTolerance
+}
+
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// assertMock compares the fields a repository must round-trip.
+func assertMock(t testing.TB, got, want *domain.MockAPI) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("mock %s %s not found", want.Method, want.Path)
+	}
+	if got.ID != want.ID || got.UserID != want.UserID || got.Method != want.Method || got.Path != want.Path {
+		t.Errorf("identity = %s %s %s %s, want %s %s %s %s",
+			got.ID, got.UserID, got.Method, got.Path, want.ID, want.UserID, want.Method, want.Path)
+	}
+	if got.Status != want.Status || got.ResponseBody != want.ResponseBody {
+		t.Errorf("response = %d %q, want %d %q", got.Status, got.ResponseBody, want.Status, want.ResponseBody)
+	}
+	if !sameJSON(got.RequestSchema, want.RequestSchema) {
+		t.Errorf("request schema differs from what was saved")
+	}
+	if !sameJSON(got.RateLimit, want.RateLimit) {
+		t.Errorf("rate limit = %+v, want %+v", got.RateLimit, want.RateLimit)
+	}
+	if !sameJSON(got.Match, want.Match) {
+		t.Errorf("match = %+v, want %+v", got.Match, want.Match)
+	}
+	if !sameJSON(got.CORS, want.CORS) {
+		t.Errorf("cors = %+v, want %+v", got.CORS, want.CORS)
+	}
+	if got.HitCount != want.HitCount {
+		t.Errorf("hit count = %d, want %d", got.HitCount, want.HitCount)
+	}
+	if !sameTime(got.CreatedAt, want.CreatedAt) {
+		t.Errorf("created at = %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+	if !sameTime(got.ExpiresAt, want.ExpiresAt) {
+		t.Errorf("expires at = %v, want %v", got.ExpiresAt, want.ExpiresAt)
+	}
+}
+
+func testSaveAndGet(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	m := newMock(userID, "POST", "/orders", now())
+	m.Status = 201
+	m.HitCount = 3
+	m.RequestSchema = &domain.RequestSchema{
+		Body:        json.RawMessage(`{"type":"object"}`),
+		ErrorStatus: 422,
+	}
+	m.RateLimit = &domain.RateLimit{Requests: 10, PeriodSeconds: 60, Scope: domain.RateLimitScopeUser}
+	m.Match = &domain.MatchRules{
+		Query: []domain.MatchRule{
+			{Name: "status", Value: "open"},
+			{Name: "debug", Op: domain.MatchAbsent},
+		},
+		Headers: []domain.MatchRule{{Name: "Accept", Op: domain.MatchContains, Value: "json"}},
+		Cookies: []domain.MatchRule{{Name: "session", Op: domain.MatchPresent}},
+	}
+	noOrigin, noCredentials := "", false
+	m.CORS = &domain.CORSOverride{AllowOrigin: &noOrigin, AllowCredentials: &noCredentials}
+	plain := newMock(userID, "GET", "/orders", now())
+	save(t, repo, m, plain)
+
+	assertMock(t, get(t, repo, userID, "/orders", "POST"), m)
+	assertMock(t, get(t, repo, userID, "/orders", "GET"), plain)
+}
+
+func testGetMissing(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	save(t, repo, newMock(userID, "GET", "/users", now()))
+
+	if m := get(t, repo, userID, "/users", "POST"); m != nil {
+		t.Errorf("different method matched %s %s", m.Method, m.Path)
+	}
+	if m := get(t, repo, userID, "/users/1", "GET"); m != nil {
+		t.Errorf("different path matched %s %s", m.Method, m.Path)
+	}
+	if m := get(t, repo, newUserID(), "/users", "GET"); m != nil {
+		t.Errorf("another user's mock was returned")
+	}
+}
+
+func testGetByUser(t testing.TB, repo domain.MockRepository) {
+	userID, otherID := newUserID(), newUserID()
+	base := now()
+	oldest := newMock(userID, "GET", "/a", base.Add(-2*time.Hour))
+	middle := newMock(userID, "GET", "/b", base.Add(-time.Hour))
+	newest := newMock(userID, "GET", "/c", base)
+	// Saved out of order so insertion order can't pass for creation order.
+	save(t, repo, middle, newest, oldest, newMock(otherID, "GET", "/a", base))
+
+	mocks := list(t, repo, userID)
+	if len(mocks) != 3 {
+		t.Fatalf("GetByUser returned %d mocks, want 3", len(mocks))
+	}
+	for i, want := range []*domain.MockAPI{newest, middle, oldest} {
+		if mocks[i].ID != want.ID {
+			t.Errorf("GetByUser[%d] = %s, want %s (newest first)", i, mocks[i].Path, want.Path)
+		}
+	}
+
+	if mocks := list(t, repo, newUserID()); len(mocks) != 0 {
+		t.Errorf("unknown user has %d mocks, want 0", len(mocks))
+	}
+}
+
+func testSameRouteDifferentMatch(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	fallback := newMock(userID, "GET", "/search", now().Add(-time.Minute))
+	matched := newMock(userID, "GET", "/search", now())
+	matched.Match = &domain.MatchRules{Query: []domain.MatchRule{{Name: "q", Value: "a"}}}
+	save(t, repo, fallback, matched)
+
+	// Mocks differing only in their match rules are stored side by side,
+	// and deleting one leaves the other.
+	if mocks := list(t, repo, userID); len(mocks) != 2 {
+		t.Fatalf("GetByUser returned %d mocks, want 2", len(mocks))
+	}
+	if err := repo.Delete(ctx, userID, matched.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	assertMock(t, get(t, repo, userID, "/search", "GET"), fallback)
+}
+
+func testUpdate(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	m := newMock(userID, "GET", "/old", now().Add(-time.Minute))
+	save(t, repo, m)
+	if err := repo.IncrementHitCount(ctx, m.ID); err != nil {
+		t.Fatalf("IncrementHitCount: %v", err)
+	}
+
+	updated := *m
+	updated.Method = "PUT"
+	updated.Path = "/new"
+	updated.Status = 202
+	updated.ResponseBody = "updated"
+	updated.RequestSchema = &domain.RequestSchema{Query: json.RawMessage(`{"type":"object"}`)}
+	updated.RateLimit = &domain.RateLimit{Requests: 5, PeriodSeconds: 1}
+	updated.Match = &domain.MatchRules{Query: []domain.MatchRule{{Name: "page", Op: domain.MatchRegex, Value: "[0-9]+"}}}
+	// Creation time, expiry and hit count are not changed by Update.
+	updated.CreatedAt = now().Add(time.Hour)
+	updated.ExpiresAt = now().Add(2 * time.Hour)
+	updated.HitCount = 0
+	if err := repo.Update(ctx, &updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if old := get(t, repo, userID, "/old", "GET"); old != nil {
+		t.Errorf("old method and path still match after Update")
+	}
+	want := updated
+	want.CreatedAt = m.CreatedAt
+	want.ExpiresAt = m.ExpiresAt
+	want.HitCount = 1
+	assertMock(t, get(t, repo, userID, "/new", "PUT"), &want)
+}
+
+func testUpdateMissingOrForeign(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	m := newMock(userID, "GET", "/mine", now())
+	save(t, repo, m)
+
+	missing := newMock(userID, "GET", "/ghost", now())
+	if err := repo.Update(ctx, missing); err != nil {
+		t.Errorf("Update of a missing mock = %v, want nil", err)
+	}
+	if got := get(t, repo, userID, "/ghost", "GET"); got != nil {
+		t.Errorf("Update of a missing mock created it")
+	}
+
+	foreign := *m
+	foreign.UserID = newUserID()
+	foreign.ResponseBody = "hijacked"
+	if err := repo.Update(ctx, &foreign); err != nil {
+		t.Errorf("Update of another user's mock = %v, want nil", err)
+	}
+	assertMock(t, get(t, repo, userID, "/mine", "GET"), m)
+}
+
+func testIncrementHitCount(t testing.TB, repo domain.MockRepository) {
+	const hits = 50
+	userID := newUserID()
+	m := newMock(userID, "GET", "/popular", now())
+	save(t, repo, m)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, hits)
+	for range hits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- repo.IncrementHitCount(ctx, m.ID)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("IncrementHitCount: %v", err)
+		}
+	}
+
+	if got := get(t, repo, userID, "/popular", "GET"); got == nil || got.HitCount != hits {
+		t.Errorf("hit count after %d concurrent increments = %v", hits, hitCount(got))
+	}
+	if err := repo.IncrementHitCount(ctx, uuid.New().String()); err != nil {
+		t.Errorf("IncrementHitCount of a missing mock = %v, want nil", err)
+	}
+}
+
+func hitCount(m *domain.MockAPI) any {
+	if m == nil {
+		return "mock missing"
+	}
+	return m.HitCount
+}
+
+func testDelete(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	kept := newMock(userID, "GET", "/kept", now())
+	gone := newMock(userID, "GET", "/gone", now())
+	save(t, repo, kept, gone)
+
+	if err := repo.Delete(ctx, userID, gone.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := get(t, repo, userID, "/gone", "GET"); got != nil {
+		t.Errorf("deleted mock is still returned")
+	}
+
+	if err := repo.Delete(ctx, newUserID(), kept.ID); err != nil {
+		t.Errorf("Delete of another user's mock = %v, want nil", err)
+	}
+	if got := get(t, repo, userID, "/kept", "GET"); got == nil {
+		t.Errorf("Delete removed another user's mock")
+	}
+
+	if err := repo.Delete(ctx, userID, uuid.New().String()); err != nil {
+		t.Errorf("Delete of a missing mock = %v, want nil", err)
+	}
+	if err := repo.Delete(ctx, userID, gone.ID); err != nil {
+		t.Errorf("second Delete = %v, want nil", err)
+	}
+}
+
+func testDeleteExpired(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	expired := newMock(userID, "GET", "/expired", now().Add(-2*time.Hour))
+	live := newMock(userID, "GET", "/live", now())
+	save(t, repo, expired, live)
+
+	if err := repo.DeleteExpired(ctx); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if got := get(t, repo, userID, "/expired", "GET"); got != nil {
+		t.Errorf("expired mock was not deleted")
+	}
+	if got := get(t, repo, userID, "/live", "GET"); got == nil {
+		t.Errorf("unexpired mock was deleted")
+	}
+}
+
+func stats(t testing.TB, repo domain.MockRepository) domain.MockStats {
+	t.Helper()
+	s, err := repo.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	return s
+}
+
+// testStats compares counts before and after, since the repository may
+// hold other users' mocks.
+func testStats(t testing.TB, repo domain.MockRepository) {
+	before := stats(t, repo)
+
+	userID := newUserID()
+	save(t, repo,
+		newMock(userID, "GET", "/a", now()),
+		newMock(userID, "GET", "/b", now()),
+		newMock(userID, "GET", "/expired", now().Add(-2*time.Hour)))
+
+	after := stats(t, repo)
+	if after.Active-before.Active != 2 || after.Expired-before.Expired != 1 {
+		t.Errorf("Stats went from %+v to %+v, want 2 more active and 1 more expired", before, after)
+	}
+
+	if err := repo.DeleteExpired(ctx); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if got := stats(t, repo); got.Expired != 0 || got.Active != after.Active {
+		t.Errorf("Stats after DeleteExpired = %+v, want %d active and none expired", got, after.Active)
+	}
+}
+
+func testWithinTxCommits(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	toUpdate := newMock(userID, "GET", "/update", now())
+	toDelete := newMock(userID, "GET", "/delete", now())
+	created := newMock(userID, "GET", "/create", now())
+	save(t, repo, toUpdate, toDelete)
+
+	updated := *toUpdate
+	updated.ResponseBody = "updated"
+	err := repo.WithinTx(ctx, func(tx domain.MockRepository) error {
+		if err := tx.Save(ctx, created); err != nil {
+			return err
+		}
+		if err := tx.Update(ctx, &updated); err != nil {
+			return err
+		}
+		return tx.Delete(ctx, userID, toDelete.ID)
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	assertMock(t, get(t, repo, userID, "/create", "GET"), created)
+	assertMock(t, get(t, repo, userID, "/update", "GET"), &updated)
+	if got := get(t, repo, userID, "/delete", "GET"); got != nil {
+		t.Errorf("delete inside a committed transaction was lost")
+	}
+}
+
+func testWithinTxRollsBack(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	existing := newMock(userID, "GET", "/existing", now())
+	save(t, repo, existing)
+
+	errAbort := errors.New("abort")
+	updated := *existing
+	updated.ResponseBody = "updated"
+	err := repo.WithinTx(ctx, func(tx domain.MockRepository) error {
+		if err := tx.Save(ctx, newMock(userID, "GET", "/created", now())); err != nil {
+			return err
+		}
+		if err := tx.Update(ctx, &updated); err != nil {
+			return err
+		}
+		if err := tx.Delete(ctx, userID, existing.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("WithinTx = %v, want the callback's error", err)
+	}
+
+	if got := get(t, repo, userID, "/created", "GET"); got != nil {
+		t.Errorf("save inside a failed transaction was kept")
+	}
+	assertMock(t, get(t, repo, userID, "/existing", "GET"), existing)
+}
+
+func testReturnsCopies(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	m := newMock(userID, "GET", "/copy", now())
+	want := *m
+	save(t, repo, m)
+
+	// Changing values passed to or returned by the repository must not
+	// change what is stored.
+	m.ResponseBody = "changed after save"
+	got := get(t, repo, userID, "/copy", "GET")
+	assertMock(t, got, &want)
+	got.ResponseBody = "changed after get"
+	for _, listed := range list(t, repo, userID) {
+		listed.ResponseBody = "changed after list"
+	}
+
+	assertMock(t, get(t, repo, userID, "/copy", "GET"), &want)
+}
+
+func newRevision(mock *domain.MockAPI, number int) *domain.MockRevision {
+	return &domain.MockRevision{
+		MockID:       mock.ID,
+		Number:       number,
+		UserID:       mock.UserID,
+		Author:       "repotest",
+		Action:       domain.RevisionUpdate,
+		Path:         mock.Path,
+		Method:       mock.Method,
+		Status:       mock.Status,
+		ResponseBody: fmt.Sprintf("body %d", number),
+		CreatedAt:    now(),
+	}
+}
+
+func addRevisions(t testing.TB, repo domain.MockRepository, revs ...*domain.MockRevision) {
+	t.Helper()
+	for _, rev := range revs {
+		if err := repo.AddRevision(ctx, rev); err != nil {
+			t.Fatalf("AddRevision(%d): %v", rev.Number, err)
+		}
+	}
+}
+
+func revisions(t testing.TB, repo domain.MockRepository, userID, mockID string) []*domain.MockRevision {
+	t.Helper()
+	revs, err := repo.GetRevisions(ctx, userID, mockID)
+	if err != nil {
+		t.Fatalf("GetRevisions: %v", err)
+	}
+	return revs
+}
+
+func testRevisions(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	m := newMock(userID, "GET", "/revisions", now())
+	other := newMock(userID, "GET", "/other", now())
+	save(t, repo, m, other)
+
+	// Added out of order, and with numbers that sort wrongly as strings.
+	want := []*domain.MockRevision{newRevision(m, 2), newRevision(m, 10), newRevision(m, 1)}
+	want[0].RequestSchema = &domain.RequestSchema{Body: json.RawMessage(`{"type":"object"}`), ErrorStatus: 422}
+	want[0].RateLimit = &domain.RateLimit{Requests: 3, PeriodSeconds: 10}
+	want[0].Match = &domain.MatchRules{Query: []domain.MatchRule{{Name: "q", Op: domain.MatchPresent}}}
+	maxAge := 60
+	want[0].CORS = &domain.CORSOverride{MaxAge: &maxAge}
+	addRevisions(t, repo, want...)
+	addRevisions(t, repo, newRevision(other, 1))
+
+	got := revisions(t, repo, userID, m.ID)
+	if len(got) != 3 {
+		t.Fatalf("GetRevisions returned %d revisions, want 3", len(got))
+	}
+	for i, number := range []int{1, 2, 10} {
+		if got[i].Number != number {
+			t.Errorf("revision %d has number %d, want %d", i, got[i].Number, number)
+		}
+	}
+
+	rev, exp := got[1], want[0]
+	if rev.MockID != exp.MockID || rev.UserID != exp.UserID || rev.Author != exp.Author ||
+		rev.Action != exp.Action || rev.Method != exp.Method || rev.Path != exp.Path ||
+		rev.Status != exp.Status || rev.ResponseBody != exp.ResponseBody {
+		t.Errorf("revision = %+v, want %+v", rev, exp)
+	}
+	if !sameJSON(rev.RequestSchema, exp.RequestSchema) {
+		t.Errorf("revision request_schema = %+v, want %+v", rev.RequestSchema, exp.RequestSchema)
+	}
+	if !sameJSON(rev.RateLimit, exp.RateLimit) {
+		t.Errorf("revision rate_limit = %+v, want %+v", rev.RateLimit, exp.RateLimit)
+	}
+	if !sameJSON(rev.Match, exp.Match) {
+		t.Errorf("revision match = %+v, want %+v", rev.Match, exp.Match)
+	}
+	if !sameJSON(rev.CORS, exp.CORS) {
+		t.Errorf("revision cors = %+v, want %+v", rev.CORS, exp.CORS)
+	}
+	if !sameTime(rev.CreatedAt, exp.CreatedAt) {
+		t.Errorf("revision created_at = %v, want %v", rev.CreatedAt, exp.CreatedAt)
+	}
+
+	if got := revisions(t, repo, newUserID(), m.ID); len(got) != 0 {
+		t.Errorf("another user sees %d revisions, want 0", len(got))
+	}
+	if got := revisions(t, repo, userID, uuid.New().String()); len(got) != 0 {
+		t.Errorf("a missing mock has %d revisions, want 0", len(got))
+	}
+}
+
+func testDeleteRemovesRevisions(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	deleted := newMock(userID, "GET", "/deleted", now())
+	expired := newMock(userID, "GET", "/expired", now().Add(-2*time.Hour))
+	kept := newMock(userID, "GET", "/kept", now())
+	save(t, repo, deleted, expired, kept)
+	addRevisions(t, repo, newRevision(deleted, 1), newRevision(expired, 1), newRevision(kept, 1))
+
+	if err := repo.Delete(ctx, newUserID(), kept.ID); err != nil {
+		t.Fatalf("Delete of another user's mock: %v", err)
+	}
+	if err := repo.Delete(ctx, userID, deleted.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.DeleteExpired(ctx); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+
+	if got := revisions(t, repo, userID, deleted.ID); len(got) != 0 {
+		t.Errorf("deleted mock still has %d revisions", len(got))
+	}
+	if got := revisions(t, repo, userID, expired.ID); len(got) != 0 {
+		t.Errorf("expired mock still has %d revisions", len(got))
+	}
+	if got := revisions(t, repo, userID, kept.ID); len(got) != 1 {
+		t.Errorf("kept mock has %d revisions, want 1", len(got))
+	}
+}
+
+func testWithinTxRollsBackRevisions(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	m := newMock(userID, "GET", "/revisions", now())
+	save(t, repo, m)
+	addRevisions(t, repo, newRevision(m, 1))
+
+	errAbort := errors.New("abort")
+	err := repo.WithinTx(ctx, func(tx domain.MockRepository) error {
+		if err := tx.AddRevision(ctx, newRevision(m, 2)); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("WithinTx = %v, want the callback's error", err)
+	}
+
+	if got := revisions(t, repo, userID, m.ID); len(got) != 1 {
+		t.Errorf("mock has %d revisions after a failed transaction, want 1", len(got))
+	}
+}
+
+func newOverride(mock *domain.MockAPI, environment string) *domain.MockOverride {
+	body := `{"error":"` + environment + `"}`
+	return &domain.MockOverride{
+		UserID:       mock.UserID,
+		Environment:  environment,
+		MockID:       mock.ID,
+		Status:       503,
+		ResponseBody: &body,
+		DelayMs:      250,
+		UpdatedAt:    now(),
+	}
+}
+
+func saveOverrides(t testing.TB, repo domain.MockRepository, overrides ...*domain.MockOverride) {
+	t.Helper()
+	for _, o := range overrides {
+		if err := repo.SaveOverride(ctx, o); err != nil {
+			t.Fatalf("SaveOverride(%s): %v", o.Environment, err)
+		}
+	}
+}
+
+func getOverride(t testing.TB, repo domain.MockRepository, userID, environment, mockID string) *domain.MockOverride {
+	t.Helper()
+	o, err := repo.GetOverride(ctx, userID, environment, mockID)
+	if err != nil {
+		t.Fatalf("GetOverride: %v", err)
+	}
+	return o
+}
+
+func overrides(t testing.TB, repo domain.MockRepository, userID string) []*domain.MockOverride {
+	t.Helper()
+	list, err := repo.GetOverrides(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetOverrides: %v", err)
+	}
+	return list
+}
+
+func assertOverride(t testing.TB, got, want *domain.MockOverride) {
+	t.Helper()
+	if got == nil {
+		t.Errorf("override %s/%s is missing", want.Environment, want.MockID)
+		return
+	}
+	if got.UserID != want.UserID || got.Environment != want.Environment || got.MockID != want.MockID ||
+		got.Status != want.Status || got.DelayMs != want.DelayMs {
+		t.Errorf("override = %+v, want %+v", got, want)
+	}
+	if (got.ResponseBody == nil) != (want.ResponseBody == nil) ||
+		(got.ResponseBody != nil && *got.ResponseBody != *want.ResponseBody) {
+		t.Errorf("override response_body = %v, want %v", got.ResponseBody, want.ResponseBody)
+	}
+	if !sameTime(got.UpdatedAt, want.UpdatedAt) {
+		t.Errorf("override updated_at = %v, want %v", got.UpdatedAt, want.UpdatedAt)
+	}
+}
+
+func testOverrides(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	a := newMock(userID, "GET", "/a", now())
+	b := newMock(userID, "GET", "/b", now())
+	save(t, repo, a, b)
+
+	slow := newOverride(a, "slow")
+	slow.Status = 0
+	slow.ResponseBody = nil
+	errorsA, errorsB := newOverride(a, "errors"), newOverride(b, "errors")
+	saveOverrides(t, repo, slow, errorsB, errorsA)
+
+	assertOverride(t, getOverride(t, repo, userID, "slow", a.ID), slow)
+	if got := getOverride(t, repo, userID, "slow", b.ID); got != nil {
+		t.Errorf("GetOverride of a missing override = %+v, want nil", got)
+	}
+	if got := getOverride(t, repo, newUserID(), "errors", a.ID); got != nil {
+		t.Errorf("another user sees an override")
+	}
+
+	// Saving again replaces the override.
+	replaced := newOverride(a, "errors")
+	replaced.Status = 500
+	saveOverrides(t, repo, replaced)
+	assertOverride(t, getOverride(t, repo, userID, "errors", a.ID), replaced)
+
+	list := overrides(t, repo, userID)
+	if len(list) != 3 {
+		t.Fatalf("GetOverrides returned %d overrides, want 3", len(list))
+	}
+	first, second := errorsA, errorsB
+	if b.ID < a.ID {
+		first, second = errorsB, errorsA
+	}
+	for i, want := range []*domain.MockOverride{first, second, slow} {
+		if list[i].Environment != want.Environment || list[i].MockID != want.MockID {
+			t.Errorf("override %d is %s/%s, want %s/%s", i, list[i].Environment, list[i].MockID, want.Environment, want.MockID)
+		}
+	}
+	if got := overrides(t, repo, newUserID()); len(got) != 0 {
+		t.Errorf("another user sees %d overrides, want 0", len(got))
+	}
+}
+
+func testDeleteRemovesOverrides(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	deleted := newMock(userID, "GET", "/deleted", now())
+	expired := newMock(userID, "GET", "/expired", now().Add(-2*time.Hour))
+	kept := newMock(userID, "GET", "/kept", now())
+	save(t, repo, deleted, expired, kept)
+	saveOverrides(t, repo,
+		newOverride(deleted, "errors"),
+		newOverride(expired, "errors"),
+		newOverride(kept, "errors"),
+		newOverride(kept, "slow"),
+		newOverride(kept, "empty"),
+	)
+
+	if err := repo.Delete(ctx, userID, deleted.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.DeleteExpired(ctx); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if err := repo.DeleteEnvironment(ctx, userID, "slow"); err != nil {
+		t.Fatalf("DeleteEnvironment: %v", err)
+	}
+	if err := repo.DeleteOverride(ctx, userID, "empty", kept.ID); err != nil {
+		t.Fatalf("DeleteOverride: %v", err)
+	}
+	if err := repo.DeleteOverride(ctx, newUserID(), "errors", kept.ID); err != nil {
+		t.Fatalf("DeleteOverride of another user's override: %v", err)
+	}
+
+	list := overrides(t, repo, userID)
+	if len(list) != 1 || list[0].MockID != kept.ID || list[0].Environment != "errors" {
+		t.Errorf("GetOverrides = %d overrides, want only errors/%s", len(list), kept.ID)
+	}
+}
+
+func testActiveEnvironment(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	active := func() string {
+		t.Helper()
+		env, err := repo.GetActiveEnvironment(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetActiveEnvironment: %v", err)
+		}
+		return env
+	}
+
+	if got := active(); got != "" {
+		t.Errorf("new user's active environment = %q, want none", got)
+	}
+	for _, env := range []string{"errors", "slow", ""} {
+		if err := repo.SetActiveEnvironment(ctx, userID, env); err != nil {
+			t.Fatalf("SetActiveEnvironment(%q): %v", env, err)
+		}
+		if got := active(); got != env {
+			t.Errorf("active environment = %q, want %q", got, env)
+		}
+	}
+
+	if err := repo.SetActiveEnvironment(ctx, userID, "errors"); err != nil {
+		t.Fatalf("SetActiveEnvironment: %v", err)
+	}
+	if env, err := repo.GetActiveEnvironment(ctx, newUserID()); err != nil || env != "" {
+		t.Errorf("another user's active environment = %q, %v, want none", env, err)
+	}
+}
+
+func testCORSPolicy(t testing.TB, repo domain.MockRepository) {
+	userID := newUserID()
+	policy := func() *domain.CORSPolicy {
+		t.Helper()
+		p, err := repo.GetCORSPolicy(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetCORSPolicy: %v", err)
+		}
+		return p
+	}
+
+	if got := policy(); got != nil {
+		t.Errorf("new user's CORS policy = %+v, want none", got)
+	}
+	for _, want := range []*domain.CORSPolicy{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{
+			AllowedOrigins: []string{"https://app.example", "https://admin.example"},
+			AllowedMethods: []string{"GET", "POST"},
+			AllowedHeaders: []string{"Content-Type"},
+			ExposedHeaders: []string{"X-Total-Count"},
+			MaxAge:         600,
+		},
+		{AllowedOrigins: []string{}},
+	} {
+		if err := repo.SetCORSPolicy(ctx, userID, want); err != nil {
+			t.Fatalf("SetCORSPolicy: %v", err)
+		}
+		if got := policy(); !sameJSON(got, want) {
+			t.Errorf("CORS policy = %+v, want %+v", got, want)
+		}
+	}
+
+	if other, err := repo.GetCORSPolicy(ctx, newUserID()); err != nil || other != nil {
+		t.Errorf("another user's CORS policy = %+v, %v, want none", other, err)
+	}
+	if err := repo.SetCORSPolicy(ctx, userID, nil); err != nil {
+		t.Fatalf("SetCORSPolicy(nil): %v", err)
+	}
+	if got := policy(); got != nil {
+		t.Errorf("CORS policy after removal = %+v, want none", got)
+	}
+}
@@ -0,0 +1,43 @@
+// Package repotest is the conformance suite every domain.MockRepository
+// must pass. Call Run from the backend's tests:
+//
+//	repotest.Run(t, func() (domain.MockRepository, func(), error) {
+//		repo, err := repository.OpenBoltMockRepository(filepath.Join(t.TempDir(), "mocks.db"))
+//		if err != nil {
+//			return nil, nil, err
+//		}
+//		return repo, func() { repo.Close() }, nil
+//	})
+//
+// Cases write mocks for fresh user IDs, so a shared scratch database works,
+// but never point the suite at data you want to keep.
+package repotest
+
+import (
+	"testing"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Opener returns a repository to test and a function that releases it.
+type Opener func() (domain.MockRepository, func(), error)
+
+// Case is one conformance check.
+type Case struct {
+	Name string
+	Run  func(t testing.TB, repo domain.MockRepository)
+}
+
+// Run runs every case as a subtest, each against a newly opened repository.
+func Run(t *testing.T, open Opener) {
+	for _, c := range Cases {
+		t.Run(c.Name, func(t *testing.T) {
+			repo, release, err := open()
+			if err != nil {
+				t.Fatalf("open repository: %v", err)
+			}
+			defer release()
+			c.Run(t, repo)
+		})
+	}
+}
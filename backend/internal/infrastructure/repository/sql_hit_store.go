@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// hitRetention is how long SQLHitStore keeps a hit, however few the user
+// has.
+const hitRetention = 24 * time.Hour
+
+// hitPruneEvery is how many hits are recorded between prunes of the
+// recording user's oldest hits, so most hits cost a single statement.
+const hitPruneEvery = 16
+
+// Statements for the hits table of the SQLite migrations.
+const (
+	sqliteInsertHit = `
+		INSERT INTO hits (user_id, time, method, path, status, mock_id, environment)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING seq
+	`
+	// Parameters: ?1 user ID, ?2 hits to keep, ?3 oldest time kept.
+	sqlitePruneHits = `
+		DELETE FROM hits
+		WHERE (user_id = ?1 AND seq <= (
+			SELECT seq FROM hits WHERE user_id = ?1 ORDER BY seq DESC LIMIT 1 OFFSET ?2
+		)) OR time < ?3
+	`
+	sqliteSelectHits = `
+		SELECT seq, time, method, path, status, mock_id, environment
+		FROM hits WHERE user_id = ? AND seq > ?
+		ORDER BY seq DESC LIMIT ?
+	`
+)
+
+// SQLHitStore keeps recent hits in SQLite or D1, so every Worker isolate
+// tails the same log. It keeps at least domain.HitsPerUser hits per user
+// for up to hitRetention.
+type SQLHitStore struct {
+	db *sql.DB
+}
+
+func NewSQLHitStore(db *sql.DB) *SQLHitStore {
+	return &SQLHitStore{db: db}
+}
+
+func (s *SQLHitStore) Record(ctx context.Context, userID string, hit domain.Hit) error {
+	if hit.Time.IsZero() {
+		hit.Time = time.Now()
+	}
+	var seq int64
+	err := s.db.QueryRowContext(ctx, sqliteInsertHit, userID, hit.Time.UnixMilli(), hit.Method, hit.Path,
+		hit.Status, hit.MockID, hit.Environment).Scan(&seq)
+	if err != nil {
+		return err
+	}
+	if seq%hitPruneEvery != 0 {
+		return nil
+	}
+	_, err = s.db.ExecContext(ctx, sqlitePruneHits, userID, domain.HitsPerUser,
+		time.Now().Add(-hitRetention).UnixMilli())
+	return err
+}
+
+func (s *SQLHitStore) Since(ctx context.Context, userID string, after int64, limit int) ([]domain.Hit, error) {
+	if limit <= 0 {
+		// SQLite reads a negative limit as none.
+		limit = -1
+	}
+	rows, err := s.db.QueryContext(ctx, sqliteSelectHits, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []domain.Hit
+	for rows.Next() {
+		var h domain.Hit
+		var ms int64
+		if err := rows.Scan(&h.Seq, &ms, &h.Method, &h.Path, &h.Status, &h.MockID, &h.Environment); err != nil {
+			return nil, err
+		}
+		h.Time = time.UnixMilli(ms).UTC()
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Reverse(hits)
+	return hits, nil
+}
@@ -0,0 +1,67 @@
+//go:build !(js && wasm)
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+)
+
+func TestSQLHitStore(t *testing.T) {
+	ctx := context.Background()
+	conn := migratedSQLite(t)
+	store := repository.NewSQLHitStore(conn)
+
+	total := domain.HitsPerUser + 50
+	for i := range total {
+		hit := domain.Hit{Method: "GET", Path: "/a", Status: 200, MockID: "m1"}
+		if err := store.Record(ctx, "u1", hit); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		if i == 0 {
+			old := domain.Hit{Time: time.Now().Add(-48 * time.Hour), Method: "GET", Path: "/old", Status: 404}
+			if err := store.Record(ctx, "u2", old); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+		}
+	}
+
+	all, err := store.Since(ctx, "u1", 0, 0)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(all) < domain.HitsPerUser || len(all) >= total {
+		t.Errorf("kept %d of %d hits, want at least %d and some pruned", len(all), total, domain.HitsPerUser)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Seq <= all[i-1].Seq {
+			t.Fatalf("hits out of order: %d after %d", all[i].Seq, all[i-1].Seq)
+		}
+	}
+	if h := all[0]; h.Method != "GET" || h.Path != "/a" || h.Status != 200 || h.MockID != "m1" || h.Time.IsZero() {
+		t.Errorf("hit = %+v", h)
+	}
+
+	last, err := store.Since(ctx, "u1", 0, 3)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(last) != 3 || last[2].Seq != all[len(all)-1].Seq {
+		t.Errorf("Since(limit 3) = %+v, want the newest 3", last)
+	}
+	newer, err := store.Since(ctx, "u1", all[len(all)-2].Seq, 0)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(newer) != 1 || newer[0].Seq != all[len(all)-1].Seq {
+		t.Errorf("Since(cursor) = %+v, want the newest hit", newer)
+	}
+
+	if old, err := store.Since(ctx, "u2", 0, 0); err != nil || len(old) != 0 {
+		t.Errorf("Since(u2) = %+v, %v; want the expired hit pruned", old, err)
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Statements for the rate_limit_buckets table of the SQLite migrations.
+// Parameters: ?1 key, ?2 capacity, ?3 refill rate in tokens per
+// millisecond, ?4 now in Unix milliseconds. The refill and the take happen
+// in one statement, so concurrent isolates never spend the same token; a
+// denied take updates no row and returns nothing. full_at is when the
+// bucket will be full again, rounded up.
+const (
+	sqliteTakeToken = `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+		VALUES (?1, ?2 - 1, ?4, ?4 + CAST(1 / ?3 AS INTEGER) + 1)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = MIN(?2, tokens + MAX(0, ?4 - updated_at) * ?3) - 1,
+			updated_at = MAX(updated_at, ?4),
+			full_at = MAX(updated_at, ?4)
+				+ CAST((1 + ?2 - MIN(?2, tokens + MAX(0, ?4 - updated_at) * ?3)) / ?3 AS INTEGER) + 1
+		WHERE MIN(?2, tokens + MAX(0, ?4 - updated_at) * ?3) >= 1
+		RETURNING tokens
+	`
+	sqliteGetBucket = `SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = ?`
+	// A full bucket is the same as none.
+	sqlitePruneBuckets = `DELETE FROM rate_limit_buckets WHERE full_at <= ?`
+)
+
+// rateLimitPruneEvery is how many takes an SQLRateLimitStore makes between
+// deleting full buckets, which would otherwise pile up for every mock and
+// user ever limited.
+const rateLimitPruneEvery = 64
+
+// SQLRateLimitStore keeps token buckets in SQLite or D1, so limits hold
+// across processes and Worker isolates.
+type SQLRateLimitStore struct {
+	db    *sql.DB
+	takes atomic.Int64
+}
+
+func NewSQLRateLimitStore(db *sql.DB) *SQLRateLimitStore {
+	return &SQLRateLimitStore{db: db}
+}
+
+func (s *SQLRateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error) {
+	if s.takes.Add(1)%rateLimitPruneEvery == 0 {
+		// A failed prune only keeps rows longer; a later take retries it.
+		_, _ = s.db.ExecContext(ctx, sqlitePruneBuckets, now.UnixMilli())
+	}
+
+	capacity := float64(limit.Requests)
+	perMs := limit.Rate() / 1000
+	nowMs := now.UnixMilli()
+
+	var tokens float64
+	err := s.db.QueryRowContext(ctx, sqliteTakeToken, key, capacity, perMs, nowMs).Scan(&tokens)
+	if err == nil {
+		return limit.Decide(tokens, true), nil
+	}
+	if err != sql.ErrNoRows {
+		return domain.RateLimitDecision{}, err
+	}
+
+	var updatedAt int64
+	if err := s.db.QueryRowContext(ctx, sqliteGetBucket, key).Scan(&tokens, &updatedAt); err != nil {
+		return domain.RateLimitDecision{}, err
+	}
+	tokens = math.Min(capacity, tokens+float64(max(0, nowMs-updatedAt))*perMs)
+	return limit.Decide(tokens, false), nil
+}
@@ -0,0 +1,55 @@
+//go:build !(js && wasm)
+
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+)
+
+func TestSQLRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	conn := migratedSQLite(t)
+	store := repository.NewSQLRateLimitStore(conn)
+	limit := domain.RateLimit{Requests: 2, PeriodSeconds: 10}
+	now := time.Now()
+
+	for i, want := range []bool{true, true, false} {
+		d, err := store.Take(ctx, "mock:a", limit, now)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if d.Allowed != want {
+			t.Errorf("take %d: allowed = %v, want %v", i+1, d.Allowed, want)
+		}
+	}
+	if d, err := store.Take(ctx, "mock:a", limit, now.Add(5*time.Second)); err != nil || !d.Allowed {
+		t.Errorf("take after refill = %+v, %v; want allowed", d, err)
+	}
+
+	// Buckets full by the time of a later take are pruned.
+	later := now.Add(time.Minute)
+	for i := range 64 {
+		if _, err := store.Take(ctx, fmt.Sprintf("mock:%d", i), limit, later); err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+	}
+	var n int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM rate_limit_buckets WHERE key = 'mock:a'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("full bucket was not pruned")
+	}
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM rate_limit_buckets`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n < 60 {
+		t.Errorf("%d buckets left, want the ones still refilling kept", n)
+	}
+}
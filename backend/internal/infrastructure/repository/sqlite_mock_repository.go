@@ -0,0 +1,232 @@
+//go:build !(js && wasm)
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// sqlExecutor is satisfied by both *sql.DB and *sql.Tx.
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SQLiteMockRepository stores mocks in SQLite using the D1 schema and
+// statements, so it behaves like D1 outside the Workers runtime.
+type SQLiteMockRepository struct {
+	db *sql.DB
+	// q is db, or the open transaction inside WithinTx.
+	q sqlExecutor
+}
+
+// NewSQLiteMockRepository expects the schema from the SQLite migrations.
+func NewSQLiteMockRepository(db *sql.DB) *SQLiteMockRepository {
+	return &SQLiteMockRepository{db: db, q: db}
+}
+
+func (r *SQLiteMockRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	args, err := insertMockArgs(mock)
+	if err != nil {
+		return err
+	}
+	_, err = r.q.ExecContext(ctx, sqliteInsertMock, args...)
+	return err
+}
+
+func (r *SQLiteMockRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	args, err := updateMockArgs(mock)
+	if err != nil {
+		return err
+	}
+	_, err = r.q.ExecContext(ctx, sqliteUpdateMock, args...)
+	return err
+}
+
+func (r *SQLiteMockRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	rows, err := r.q.QueryContext(ctx, sqliteListMocksByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mocks []*domain.MockAPI
+	for rows.Next() {
+		m, err := scanSQLiteMock(rows)
+		if err != nil {
+			return nil, err
+		}
+		mocks = append(mocks, m)
+	}
+	return mocks, rows.Err()
+}
+
+func (r *SQLiteMockRepository) IncrementHitCount(ctx context.Context, id string) error {
+	_, err := r.q.ExecContext(ctx, sqliteIncrementHitCount, id)
+	return err
+}
+
+func (r *SQLiteMockRepository) DeleteExpired(ctx context.Context) error {
+	nowStr := time.Now().Format(time.RFC3339)
+	return r.WithinTx(ctx, func(repo domain.MockRepository) error {
+		q := repo.(*SQLiteMockRepository).q
+		if _, err := q.ExecContext(ctx, sqliteDeleteExpiredRevisions, nowStr); err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, sqliteDeleteExpiredOverrides, nowStr); err != nil {
+			return err
+		}
+		_, err := q.ExecContext(ctx, sqliteDeleteExpired, nowStr)
+		return err
+	})
+}
+
+func (r *SQLiteMockRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	nowStr := time.Now().Format(time.RFC3339)
+	return scanSQLiteStats(r.q.QueryRowContext(ctx, sqliteMockStats, nowStr))
+}
+
+func (r *SQLiteMockRepository) Delete(ctx context.Context, userID, id string) error {
+	return r.WithinTx(ctx, func(repo domain.MockRepository) error {
+		q := repo.(*SQLiteMockRepository).q
+		if _, err := q.ExecContext(ctx, sqliteDeleteRevisions, id, userID); err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, sqliteDeleteMockOverrides, id, userID); err != nil {
+			return err
+		}
+		_, err := q.ExecContext(ctx, sqliteDeleteMock, id, userID)
+		return err
+	})
+}
+
+func (r *SQLiteMockRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	args, err := insertRevisionArgs(rev)
+	if err != nil {
+		return err
+	}
+	_, err = r.q.ExecContext(ctx, sqliteInsertRevision, args...)
+	return err
+}
+
+func (r *SQLiteMockRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	rows, err := r.q.QueryContext(ctx, sqliteListRevisions, mockID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revs []*domain.MockRevision
+	for rows.Next() {
+		rev, err := scanSQLiteRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, rev)
+	}
+	return revs, rows.Err()
+}
+
+func (r *SQLiteMockRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	_, err := r.q.ExecContext(ctx, sqliteSaveOverride, saveOverrideArgs(o)...)
+	return err
+}
+
+func (r *SQLiteMockRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	row := r.q.QueryRowContext(ctx, sqliteGetOverride, userID, environment, mockID)
+
+	o, err := scanSQLiteOverride(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *SQLiteMockRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	rows, err := r.q.QueryContext(ctx, sqliteListOverrides, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []*domain.MockOverride
+	for rows.Next() {
+		o, err := scanSQLiteOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+func (r *SQLiteMockRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	_, err := r.q.ExecContext(ctx, sqliteDeleteOverride, userID, environment, mockID)
+	return err
+}
+
+func (r *SQLiteMockRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	_, err := r.q.ExecContext(ctx, sqliteDeleteEnvironment, userID, environment)
+	return err
+}
+
+func (r *SQLiteMockRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	var err error
+	if environment == "" {
+		_, err = r.q.ExecContext(ctx, sqliteClearActiveEnvironment, userID)
+	} else {
+		_, err = r.q.ExecContext(ctx, sqliteSetActiveEnvironment, userID, environment)
+	}
+	return err
+}
+
+func (r *SQLiteMockRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	var environment string
+	err := r.q.QueryRowContext(ctx, sqliteGetActiveEnvironment, userID).Scan(&environment)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return environment, err
+}
+
+func (r *SQLiteMockRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	if policy == nil {
+		_, err := r.q.ExecContext(ctx, sqliteClearCORSPolicy, userID)
+		return err
+	}
+	value, err := nullableJSONArg(policy, "policy")
+	if err != nil {
+		return err
+	}
+	_, err = r.q.ExecContext(ctx, sqliteSetCORSPolicy, userID, value)
+	return err
+}
+
+func (r *SQLiteMockRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	return scanCORSPolicy(r.q.QueryRowContext(ctx, sqliteGetCORSPolicy, userID))
+}
+
+func (r *SQLiteMockRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	if r.q != r.db {
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteMockRepository{db: r.db, q: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,46 @@
+//go:build !(js && wasm)
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"mock-api-backend/internal/config"
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/db"
+	"mock-api-backend/internal/infrastructure/migrate"
+	"mock-api-backend/internal/infrastructure/repository/repotest"
+	"mock-api-backend/internal/infrastructure/storage"
+	sqlfiles "mock-api-backend/sql"
+)
+
+func TestSQLiteMockRepository(t *testing.T) {
+	repotest.Run(t, func() (domain.MockRepository, func(), error) {
+		return storage.Open(&config.Config{
+			Storage:        config.StorageSQLite,
+			DataPath:       filepath.Join(t.TempDir(), "mocks.db"),
+			MigrateOnStart: true,
+		})
+	})
+}
+
+// migratedSQLite opens a new SQLite database with every migration applied.
+func migratedSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := db.NewSQLiteConnection(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	migrations, err := migrate.Load(sqlfiles.SQLiteMigrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate.New(migrate.NewSQLite(conn), migrations).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
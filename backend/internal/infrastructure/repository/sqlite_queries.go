@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Statements shared by the D1 and SQLite repositories, which speak the same
+// dialect and share the migrations in sql/migrations/sqlite. Times are
+// stored as RFC3339 strings.
+const (
+	sqliteMockColumns = `id, user_id, method, path, response_status, response_body, created_at, expires_at, hit_count, request_schema, rate_limit, match_rules, cors`
+
+	sqliteInsertMock = `
+		INSERT INTO mocks (` + sqliteMockColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	sqliteUpdateMock = `
+		UPDATE mocks
+		SET method = ?, path = ?, response_status = ?, response_body = ?, request_schema = ?, rate_limit = ?, match_rules = ?, cors = ?
+		WHERE id = ? AND user_id = ?
+	`
+	sqliteListMocksByUser = `
+		SELECT ` + sqliteMockColumns + `
+		FROM mocks
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`
+	sqliteIncrementHitCount = `UPDATE mocks SET hit_count = hit_count + 1 WHERE id = ?`
+	sqliteDeleteExpired     = `DELETE FROM mocks WHERE expires_at < ?`
+	sqliteDeleteMock        = `DELETE FROM mocks WHERE id = ? AND user_id = ?`
+	sqliteMockStats         = `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN expires_at < ? THEN 1 ELSE 0 END), 0)
+		FROM mocks
+	`
+
+	sqliteRevisionColumns = `mock_id, number, user_id, author, action, method, path, response_status, response_body, request_schema, created_at, rate_limit, match_rules, cors`
+
+	sqliteInsertRevision = `
+		INSERT INTO mock_revisions (` + sqliteRevisionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	sqliteListRevisions = `
+		SELECT ` + sqliteRevisionColumns + `
+		FROM mock_revisions
+		WHERE mock_id = ? AND user_id = ?
+		ORDER BY number
+	`
+	sqliteDeleteRevisions        = `DELETE FROM mock_revisions WHERE mock_id = ? AND user_id = ?`
+	sqliteDeleteExpiredRevisions = `DELETE FROM mock_revisions WHERE mock_id IN (SELECT id FROM mocks WHERE expires_at < ?)`
+
+	sqliteOverrideColumns = `user_id, environment, mock_id, response_status, response_body, delay_ms, updated_at`
+
+	sqliteSaveOverride = `
+		INSERT INTO mock_overrides (` + sqliteOverrideColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, environment, mock_id) DO UPDATE SET
+			response_status = excluded.response_status,
+			response_body = excluded.response_body,
+			delay_ms = excluded.delay_ms,
+			updated_at = excluded.updated_at
+	`
+	sqliteGetOverride = `
+		SELECT ` + sqliteOverrideColumns + `
+		FROM mock_overrides
+		WHERE user_id = ? AND environment = ? AND mock_id = ?
+	`
+	sqliteListOverrides = `
+		SELECT ` + sqliteOverrideColumns + `
+		FROM mock_overrides
+		WHERE user_id = ?
+		ORDER BY environment, mock_id
+	`
+	sqliteDeleteOverride         = `DELETE FROM mock_overrides WHERE user_id = ? AND environment = ? AND mock_id = ?`
+	sqliteDeleteEnvironment      = `DELETE FROM mock_overrides WHERE user_id = ? AND environment = ?`
+	sqliteDeleteMockOverrides    = `DELETE FROM mock_overrides WHERE mock_id = ? AND user_id = ?`
+	sqliteDeleteExpiredOverrides = `DELETE FROM mock_overrides WHERE mock_id IN (SELECT id FROM mocks WHERE expires_at < ?)`
+
+	sqliteSetActiveEnvironment = `
+		INSERT INTO active_environments (user_id, environment) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET environment = excluded.environment
+	`
+	sqliteClearActiveEnvironment = `DELETE FROM active_environments WHERE user_id = ?`
+	sqliteGetActiveEnvironment   = `SELECT environment FROM active_environments WHERE user_id = ?`
+
+	sqliteSetCORSPolicy = `
+		INSERT INTO cors_policies (user_id, policy) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET policy = excluded.policy
+	`
+	sqliteClearCORSPolicy = `DELETE FROM cors_policies WHERE user_id = ?`
+	sqliteGetCORSPolicy   = `SELECT policy FROM cors_policies WHERE user_id = ?`
+)
+
+// insertMockArgs returns the arguments for sqliteInsertMock.
+func insertMockArgs(mock *domain.MockAPI) ([]any, error) {
+	requestSchema, err := nullableJSONArg(mock.RequestSchema, "request_schema")
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := nullableJSONArg(mock.RateLimit, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	matchRules, err := nullableJSONArg(mock.Match, "match_rules")
+	if err != nil {
+		return nil, err
+	}
+	cors, err := nullableJSONArg(mock.CORS, "cors")
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		mock.ID,
+		mock.UserID,
+		mock.Method,
+		mock.Path,
+		mock.Status,
+		mock.ResponseBody,
+		mock.CreatedAt.Format(time.RFC3339),
+		mock.ExpiresAt.Format(time.RFC3339),
+		mock.HitCount,
+		requestSchema,
+		rateLimit,
+		matchRules,
+		cors,
+	}, nil
+}
+
+// updateMockArgs returns the arguments for sqliteUpdateMock.
+func updateMockArgs(mock *domain.MockAPI) ([]any, error) {
+	requestSchema, err := nullableJSONArg(mock.RequestSchema, "request_schema")
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := nullableJSONArg(mock.RateLimit, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	matchRules, err := nullableJSONArg(mock.Match, "match_rules")
+	if err != nil {
+		return nil, err
+	}
+	cors, err := nullableJSONArg(mock.CORS, "cors")
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		mock.Method,
+		mock.Path,
+		mock.Status,
+		mock.ResponseBody,
+		requestSchema,
+		rateLimit,
+		matchRules,
+		cors,
+		mock.ID,
+		mock.UserID,
+	}, nil
+}
+
+// sqliteRow is satisfied by both *sql.Row and *sql.Rows.
+type sqliteRow interface {
+	Scan(dest ...any) error
+}
+
+func scanSQLiteMock(row sqliteRow) (*domain.MockAPI, error) {
+	var m domain.MockAPI
+	var createdAtStr, expiresAtStr string
+	var requestSchema, rateLimit, matchRules, cors sql.NullString
+	if err := row.Scan(
+		&m.ID,
+		&m.UserID,
+		&m.Method,
+		&m.Path,
+		&m.Status,
+		&m.ResponseBody,
+		&createdAtStr,
+		&expiresAtStr,
+		&m.HitCount,
+		&requestSchema,
+		&rateLimit,
+		&matchRules,
+		&cors,
+	); err != nil {
+		return nil, err
+	}
+	// Parse RFC3339 strings back to time.Time
+	createdAt, err := time.Parse(time.RFC3339, createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse created_at: %w", err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse expires_at: %w", err)
+	}
+	m.CreatedAt = createdAt
+	m.ExpiresAt = expiresAt
+
+	m.RequestSchema, err = unmarshalNullableJSON[domain.RequestSchema](requestSchema.String, requestSchema.Valid, "request_schema")
+	if err != nil {
+		return nil, err
+	}
+	m.RateLimit, err = unmarshalNullableJSON[domain.RateLimit](rateLimit.String, rateLimit.Valid, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	m.Match, err = unmarshalNullableJSON[domain.MatchRules](matchRules.String, matchRules.Valid, "match_rules")
+	if err != nil {
+		return nil, err
+	}
+	m.CORS, err = unmarshalNullableJSON[domain.CORSOverride](cors.String, cors.Valid, "cors")
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// nullableJSONArg returns the value of a nullable JSON column: a JSON
+// string, or nil for NULL. Plain values keep statements usable in D1
+// batches.
+func nullableJSONArg[T any](v *T, column string) (any, error) {
+	s, valid, err := marshalNullableJSON(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", column, err)
+	}
+	if !valid {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// insertRevisionArgs returns the arguments for sqliteInsertRevision.
+func insertRevisionArgs(rev *domain.MockRevision) ([]any, error) {
+	requestSchema, err := nullableJSONArg(rev.RequestSchema, "request_schema")
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := nullableJSONArg(rev.RateLimit, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	matchRules, err := nullableJSONArg(rev.Match, "match_rules")
+	if err != nil {
+		return nil, err
+	}
+	cors, err := nullableJSONArg(rev.CORS, "cors")
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		rev.MockID,
+		rev.Number,
+		rev.UserID,
+		rev.Author,
+		rev.Action,
+		rev.Method,
+		rev.Path,
+		rev.Status,
+		rev.ResponseBody,
+		requestSchema,
+		rev.CreatedAt.Format(time.RFC3339),
+		rateLimit,
+		matchRules,
+		cors,
+	}, nil
+}
+
+func scanSQLiteRevision(row sqliteRow) (*domain.MockRevision, error) {
+	var rev domain.MockRevision
+	var createdAtStr string
+	var requestSchema, rateLimit, matchRules, cors sql.NullString
+	if err := row.Scan(
+		&rev.MockID,
+		&rev.Number,
+		&rev.UserID,
+		&rev.Author,
+		&rev.Action,
+		&rev.Method,
+		&rev.Path,
+		&rev.Status,
+		&rev.ResponseBody,
+		&requestSchema,
+		&createdAtStr,
+		&rateLimit,
+		&matchRules,
+		&cors,
+	); err != nil {
+		return nil, err
+	}
+	createdAt, err := time.Parse(time.RFC3339, createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse created_at: %w", err)
+	}
+	rev.CreatedAt = createdAt
+
+	rev.RequestSchema, err = unmarshalNullableJSON[domain.RequestSchema](requestSchema.String, requestSchema.Valid, "request_schema")
+	if err != nil {
+		return nil, err
+	}
+	rev.RateLimit, err = unmarshalNullableJSON[domain.RateLimit](rateLimit.String, rateLimit.Valid, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	rev.Match, err = unmarshalNullableJSON[domain.MatchRules](matchRules.String, matchRules.Valid, "match_rules")
+	if err != nil {
+		return nil, err
+	}
+	rev.CORS, err = unmarshalNullableJSON[domain.CORSOverride](cors.String, cors.Valid, "cors")
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// saveOverrideArgs returns the arguments for sqliteSaveOverride.
+func saveOverrideArgs(o *domain.MockOverride) []any {
+	var body any
+	if o.ResponseBody != nil {
+		body = *o.ResponseBody
+	}
+	return []any{
+		o.UserID,
+		o.Environment,
+		o.MockID,
+		o.Status,
+		body,
+		o.DelayMs,
+		o.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func scanSQLiteOverride(row sqliteRow) (*domain.MockOverride, error) {
+	var o domain.MockOverride
+	var body sql.NullString
+	var updatedAtStr string
+	if err := row.Scan(
+		&o.UserID,
+		&o.Environment,
+		&o.MockID,
+		&o.Status,
+		&body,
+		&o.DelayMs,
+		&updatedAtStr,
+	); err != nil {
+		return nil, err
+	}
+	if body.Valid {
+		o.ResponseBody = &body.String
+	}
+	updatedAt, err := time.Parse(time.RFC3339, updatedAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse updated_at: %w", err)
+	}
+	o.UpdatedAt = updatedAt
+	return &o, nil
+}
+
+// scanCORSPolicy reads the result of sqliteGetCORSPolicy, which is nil
+// when there is no row.
+func scanCORSPolicy(row sqliteRow) (*domain.CORSPolicy, error) {
+	var policy string
+	if err := row.Scan(&policy); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return unmarshalNullableJSON[domain.CORSPolicy](policy, true, "policy")
+}
+
+// scanSQLiteStats reads the result of sqliteMockStats.
+func scanSQLiteStats(row sqliteRow) (domain.MockStats, error) {
+	var total, expired int
+	if err := row.Scan(&total, &expired); err != nil {
+		return domain.MockStats{}, err
+	}
+	return domain.MockStats{Active: total - expired, Expired: expired}, nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// WithTimeouts wraps repo so each call is cancelled after query, and each
+// transaction, including the calls inside it, after tx. Zero durations
+// mean no limit. Calls on an already cancelled context fail at once, even
+// for backends that ignore contexts.
+func WithTimeouts(repo domain.MockRepository, query, tx time.Duration) domain.MockRepository {
+	return &timeoutRepository{repo: repo, query: query, tx: tx}
+}
+
+type timeoutRepository struct {
+	repo      domain.MockRepository
+	query, tx time.Duration
+}
+
+// bound derives a context that expires after d, or fails if ctx is done.
+func (r *timeoutRepository) bound(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if d <= 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, nil
+}
+
+func (r *timeoutRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.Save(ctx, mock)
+}
+
+func (r *timeoutRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return r.repo.GetByUser(ctx, userID)
+}
+
+func (r *timeoutRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.Update(ctx, mock)
+}
+
+func (r *timeoutRepository) IncrementHitCount(ctx context.Context, id string) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.IncrementHitCount(ctx, id)
+}
+
+func (r *timeoutRepository) DeleteExpired(ctx context.Context) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.DeleteExpired(ctx)
+}
+
+func (r *timeoutRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return domain.MockStats{}, err
+	}
+	defer cancel()
+	return r.repo.Stats(ctx)
+}
+
+func (r *timeoutRepository) Delete(ctx context.Context, userID, id string) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.Delete(ctx, userID, id)
+}
+
+func (r *timeoutRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.AddRevision(ctx, rev)
+}
+
+func (r *timeoutRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return r.repo.GetRevisions(ctx, userID, mockID)
+}
+
+func (r *timeoutRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.SaveOverride(ctx, o)
+}
+
+func (r *timeoutRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return r.repo.GetOverride(ctx, userID, environment, mockID)
+}
+
+func (r *timeoutRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return r.repo.GetOverrides(ctx, userID)
+}
+
+func (r *timeoutRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.DeleteOverride(ctx, userID, environment, mockID)
+}
+
+func (r *timeoutRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.DeleteEnvironment(ctx, userID, environment)
+}
+
+func (r *timeoutRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.SetActiveEnvironment(ctx, userID, environment)
+}
+
+func (r *timeoutRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+	return r.repo.GetActiveEnvironment(ctx, userID)
+}
+
+func (r *timeoutRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.SetCORSPolicy(ctx, userID, policy)
+}
+
+func (r *timeoutRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	ctx, cancel, err := r.bound(ctx, r.query)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return r.repo.GetCORSPolicy(ctx, userID)
+}
+
+func (r *timeoutRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	ctx, cancel, err := r.bound(ctx, r.tx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return r.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		return fn(&timeoutRepository{repo: repo, query: r.query, tx: r.tx})
+	})
+}
@@ -0,0 +1,117 @@
+//go:build !(js && wasm)
+
+// Package storage opens the mock repository selected by configuration.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"mock-api-backend/internal/config"
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/db"
+	"mock-api-backend/internal/infrastructure/migrate"
+	"mock-api-backend/internal/infrastructure/repository"
+	sqlfiles "mock-api-backend/sql"
+)
+
+// Open returns the repository for cfg.Storage and a function that releases
+// it. Memory storage is lost on exit; the other backends are durable. With
+// cfg.MigrateOnStart, pending migrations are applied first.
+func Open(cfg *config.Config) (domain.MockRepository, func(), error) {
+	switch cfg.Storage {
+	case config.StorageMemory:
+		return repository.NewInMemoryMockRepository(), func() {}, nil
+
+	case config.StoragePostgres:
+		conn, err := db.NewPostgresConnection(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg.MigrateOnStart {
+			if err := migrateUp(migrate.NewPostgres(conn), sqlfiles.PostgresMigrations()); err != nil {
+				conn.Close()
+				return nil, nil, err
+			}
+		}
+		return repository.NewPostgresMockRepository(conn), conn.Close, nil
+
+	case config.StorageSQLite:
+		conn, err := db.NewSQLiteConnection(cfg.DataPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg.MigrateOnStart {
+			if err := migrateUp(migrate.NewSQLite(conn), sqlfiles.SQLiteMigrations()); err != nil {
+				conn.Close()
+				return nil, nil, err
+			}
+		}
+		return repository.NewSQLiteMockRepository(conn), func() { conn.Close() }, nil
+
+	case config.StorageBolt:
+		repo, err := repository.OpenBoltMockRepository(cfg.DataPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, func() { repo.Close() }, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+// OpenMigrator returns a migrator for cfg.Storage and a function that
+// releases it. Only the SQL backends have migrations.
+func OpenMigrator(cfg *config.Config) (*migrate.Migrator, func(), error) {
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		conn, err := db.NewPostgresConnection(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		m, err := newMigrator(migrate.NewPostgres(conn), sqlfiles.PostgresMigrations())
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return m, conn.Close, nil
+
+	case config.StorageSQLite:
+		conn, err := db.NewSQLiteConnection(cfg.DataPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		m, err := newMigrator(migrate.NewSQLite(conn), sqlfiles.SQLiteMigrations())
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return m, func() { conn.Close() }, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%s storage has no migrations", cfg.Storage)
+	}
+}
+
+func newMigrator(conn migrate.Conn, files fs.FS) (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return migrate.New(conn, migrations), nil
+}
+
+func migrateUp(conn migrate.Conn, files fs.FS) error {
+	m, err := newMigrator(conn, files)
+	if err != nil {
+		return err
+	}
+	applied, err := m.Up(context.Background())
+	for _, mig := range applied {
+		slog.Info("applied migration", "version", mig.Version, "name", mig.Name)
+	}
+	return err
+}
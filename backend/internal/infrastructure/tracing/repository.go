@@ -0,0 +1,158 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Repository wraps repo so every call is traced as a span named after the
+// method called, such as MockRepository.GetByUser.
+func Repository(repo domain.MockRepository) domain.MockRepository {
+	return &tracedRepository{repo: repo, tracer: otel.Tracer("mock-api-backend/internal/infrastructure/repository")}
+}
+
+type tracedRepository struct {
+	repo   domain.MockRepository
+	tracer trace.Tracer
+}
+
+func (r *tracedRepository) Save(ctx context.Context, mock *domain.MockAPI) error {
+	ctx, span := start(ctx, r.tracer, "Save")
+	err := r.repo.Save(ctx, mock)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	ctx, span := start(ctx, r.tracer, "GetByUser")
+	v, err := r.repo.GetByUser(ctx, userID)
+	end(span, err)
+	return v, err
+}
+
+func (r *tracedRepository) Update(ctx context.Context, mock *domain.MockAPI) error {
+	ctx, span := start(ctx, r.tracer, "Update")
+	err := r.repo.Update(ctx, mock)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) IncrementHitCount(ctx context.Context, id string) error {
+	ctx, span := start(ctx, r.tracer, "IncrementHitCount")
+	err := r.repo.IncrementHitCount(ctx, id)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) DeleteExpired(ctx context.Context) error {
+	ctx, span := start(ctx, r.tracer, "DeleteExpired")
+	err := r.repo.DeleteExpired(ctx)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) Stats(ctx context.Context) (domain.MockStats, error) {
+	ctx, span := start(ctx, r.tracer, "Stats")
+	v, err := r.repo.Stats(ctx)
+	end(span, err)
+	return v, err
+}
+
+func (r *tracedRepository) Delete(ctx context.Context, userID, id string) error {
+	ctx, span := start(ctx, r.tracer, "Delete")
+	err := r.repo.Delete(ctx, userID, id)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) AddRevision(ctx context.Context, rev *domain.MockRevision) error {
+	ctx, span := start(ctx, r.tracer, "AddRevision")
+	err := r.repo.AddRevision(ctx, rev)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) GetRevisions(ctx context.Context, userID, mockID string) ([]*domain.MockRevision, error) {
+	ctx, span := start(ctx, r.tracer, "GetRevisions")
+	v, err := r.repo.GetRevisions(ctx, userID, mockID)
+	end(span, err)
+	return v, err
+}
+
+func (r *tracedRepository) SaveOverride(ctx context.Context, o *domain.MockOverride) error {
+	ctx, span := start(ctx, r.tracer, "SaveOverride")
+	err := r.repo.SaveOverride(ctx, o)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) GetOverride(ctx context.Context, userID, environment, mockID string) (*domain.MockOverride, error) {
+	ctx, span := start(ctx, r.tracer, "GetOverride")
+	v, err := r.repo.GetOverride(ctx, userID, environment, mockID)
+	end(span, err)
+	return v, err
+}
+
+func (r *tracedRepository) GetOverrides(ctx context.Context, userID string) ([]*domain.MockOverride, error) {
+	ctx, span := start(ctx, r.tracer, "GetOverrides")
+	v, err := r.repo.GetOverrides(ctx, userID)
+	end(span, err)
+	return v, err
+}
+
+func (r *tracedRepository) DeleteOverride(ctx context.Context, userID, environment, mockID string) error {
+	ctx, span := start(ctx, r.tracer, "DeleteOverride")
+	err := r.repo.DeleteOverride(ctx, userID, environment, mockID)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) DeleteEnvironment(ctx context.Context, userID, environment string) error {
+	ctx, span := start(ctx, r.tracer, "DeleteEnvironment")
+	err := r.repo.DeleteEnvironment(ctx, userID, environment)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) SetActiveEnvironment(ctx context.Context, userID, environment string) error {
+	ctx, span := start(ctx, r.tracer, "SetActiveEnvironment")
+	err := r.repo.SetActiveEnvironment(ctx, userID, environment)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) GetActiveEnvironment(ctx context.Context, userID string) (string, error) {
+	ctx, span := start(ctx, r.tracer, "GetActiveEnvironment")
+	v, err := r.repo.GetActiveEnvironment(ctx, userID)
+	end(span, err)
+	return v, err
+}
+
+func (r *tracedRepository) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) error {
+	ctx, span := start(ctx, r.tracer, "SetCORSPolicy")
+	err := r.repo.SetCORSPolicy(ctx, userID, policy)
+	end(span, err)
+	return err
+}
+
+func (r *tracedRepository) GetCORSPolicy(ctx context.Context, userID string) (*domain.CORSPolicy, error) {
+	ctx, span := start(ctx, r.tracer, "GetCORSPolicy")
+	v, err := r.repo.GetCORSPolicy(ctx, userID)
+	end(span, err)
+	return v, err
+}
+
+// WithinTx traces the whole transaction, with the calls made inside it
+// as child spans.
+func (r *tracedRepository) WithinTx(ctx context.Context, fn func(repo domain.MockRepository) error) error {
+	ctx, span := start(ctx, r.tracer, "WithinTx")
+	err := r.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		return fn(&tracedRepository{repo: repo, tracer: r.tracer})
+	})
+	end(span, err)
+	return err
+}
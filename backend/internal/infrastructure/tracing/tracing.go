@@ -0,0 +1,97 @@
+// Package tracing exports OpenTelemetry spans over OTLP/HTTP. Incoming W3C
+// trace context is honoured whether or not spans are exported, so a request
+// keeps its trace ID as it passes through.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config selects where spans are exported. An empty Endpoint disables
+// exporting.
+type Config struct {
+	// Endpoint is the full OTLP/HTTP traces URL, such as
+	// http://localhost:4318/v1/traces.
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+}
+
+// Setup installs the W3C trace context and baggage propagators and, when
+// cfg.Endpoint is set, a global tracer provider that batches spans to it.
+// It returns nil when exporting is disabled; otherwise the caller must shut
+// the provider down to flush buffered spans. opts are applied after cfg,
+// for example to export with a different HTTP client.
+func Setup(ctx context.Context, cfg Config, opts ...otlptracehttp.Option) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if cfg.Endpoint == "" {
+		return nil, nil
+	}
+
+	base := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Endpoint)}
+	if len(cfg.Headers) > 0 {
+		base = append(base, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, append(base, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(cfg.ServiceName, exporter)
+}
+
+// NewProvider installs a global tracer provider that batches spans to
+// exporter. Tests can pass tracetest.NewInMemoryExporter to inspect the
+// spans.
+func NewProvider(serviceName string, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp, nil
+}
+
+// Middleware starts a server span for every request, as a child of the
+// caller's span when the request carries a traceparent header.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
+// end records err on span, if any, and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// start begins a span named after the repository method called.
+func start(ctx context.Context, tracer trace.Tracer, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "MockRepository."+operation, trace.WithSpanKind(trace.SpanKindClient))
+}
@@ -0,0 +1,109 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"mock-api-backend/internal/domain"
+	mockhttp "mock-api-backend/internal/infrastructure/http"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/infrastructure/tracing"
+	"mock-api-backend/internal/usecase"
+)
+
+const managementDomain = "api.test"
+
+// flakyRepository fails GetByUser when fail is set.
+type flakyRepository struct {
+	domain.MockRepository
+	fail bool
+}
+
+func (r *flakyRepository) GetByUser(ctx context.Context, userID string) ([]*domain.MockAPI, error) {
+	if r.fail {
+		return nil, errors.New("connection refused")
+	}
+	return r.MockRepository.GetByUser(ctx, userID)
+}
+
+func TestRequestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp, err := tracing.NewProvider("mock-api-test", exporter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	repo := &flakyRepository{MockRepository: repository.NewInMemoryMockRepository()}
+	handler := mockhttp.NewMockHandler(usecase.NewMockService(tracing.Repository(repo)), "http", managementDomain)
+	router := tracing.Middleware(mockhttp.NewHostRouter(managementDomain,
+		mockhttp.NewManagementRouter(handler, []string{"*"}),
+		mockhttp.NewServingRouter(handler, []string{"*"})))
+
+	// spans sends GET /api/mocks and returns its spans by name.
+	spans := func(wantStatus int) map[string]tracetest.SpanStub {
+		t.Helper()
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/mocks", nil)
+		req.Host = managementDomain
+		req.AddCookie(&http.Cookie{Name: mockhttp.UserIDCookie, Value: "u1"})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != wantStatus {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, wantStatus, rec.Body)
+		}
+		if err := tp.ForceFlush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		byName := make(map[string]tracetest.SpanStub)
+		for _, s := range exporter.GetSpans() {
+			byName[s.Name] = s
+		}
+		return byName
+	}
+
+	// The spans nest: server, handler, service, repository.
+	chain := []string{"GET", "MockHandler.ListMocks", "MockService.GetMocks", "MockRepository.GetByUser"}
+	got := spans(http.StatusOK)
+	for i, name := range chain {
+		span, ok := got[name]
+		if !ok {
+			t.Fatalf("no %s span among %v", name, got)
+		}
+		if span.Status.Code == codes.Error {
+			t.Errorf("%s span failed: %s", name, span.Status.Description)
+		}
+		if i == 0 {
+			if span.Parent.IsValid() {
+				t.Errorf("%s span has a parent", name)
+			}
+			continue
+		}
+		parent := got[chain[i-1]].SpanContext
+		if span.Parent.SpanID() != parent.SpanID() || span.SpanContext.TraceID() != parent.TraceID() {
+			t.Errorf("%s span is not a child of %s", name, chain[i-1])
+		}
+	}
+
+	// A failing repository call fails every span up to the handler, and is
+	// recorded where it happened.
+	repo.fail = true
+	got = spans(http.StatusInternalServerError)
+	for _, name := range chain[1:] {
+		if got[name].Status.Code != codes.Error {
+			t.Errorf("%s span status = %v, want Error", name, got[name].Status.Code)
+		}
+	}
+	for _, name := range chain[2:] {
+		events := got[name].Events
+		if len(events) == 0 || events[0].Name != "exception" {
+			t.Errorf("%s span recorded no error: %v", name, events)
+		}
+	}
+}
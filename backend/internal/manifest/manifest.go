@@ -0,0 +1,96 @@
+// Package manifest parses declarative mock collections. A manifest is YAML
+// or JSON (YAML being a superset) listing the mocks a user should have:
+//
+//	mocks:
+//	  - method: GET
+//	    path: /users
+//	    status: 200
+//	    response_body:
+//	      users: []
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+type Manifest struct {
+	Mocks []Mock `json:"mocks"`
+}
+
+// Mock is one manifest entry. ResponseBody may be a string, which is served
+// verbatim, or any other value, which is served as its JSON encoding.
+type Mock struct {
+	Method        string                `json:"method"`
+	Path          string                `json:"path"`
+	Status        int                   `json:"status"`
+	ResponseBody  json.RawMessage       `json:"response_body"`
+	RequestSchema *domain.RequestSchema `json:"request_schema,omitempty"`
+	RateLimit     *domain.RateLimit     `json:"rate_limit,omitempty"`
+	Match         *domain.MatchRules    `json:"match,omitempty"`
+	CORS          *domain.CORSOverride  `json:"cors,omitempty"`
+}
+
+// Parse decodes a YAML or JSON manifest.
+func Parse(data []byte) (*Manifest, error) {
+	// Decode YAML generically and round-trip through JSON so that nested
+	// values (schemas, structured bodies) land in json.RawMessage fields.
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if doc == nil {
+		return &Manifest{}, nil
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Inputs converts the manifest entries to service inputs. Methods are
+// upper-cased; the service validates the rest.
+func (m *Manifest) Inputs() ([]usecase.MockInput, error) {
+	inputs := make([]usecase.MockInput, len(m.Mocks))
+	for i, mock := range m.Mocks {
+		body, err := mock.body()
+		if err != nil {
+			return nil, fmt.Errorf("mocks[%d]: %w", i, err)
+		}
+		in := usecase.MockInput{
+			Path:          mock.Path,
+			Method:        strings.ToUpper(mock.Method),
+			Status:        mock.Status,
+			ResponseBody:  body,
+			RequestSchema: mock.RequestSchema,
+			RateLimit:     mock.RateLimit,
+			Match:         mock.Match,
+			CORS:          mock.CORS,
+		}
+		inputs[i] = in
+	}
+	return inputs, nil
+}
+
+func (m Mock) body() (string, error) {
+	if len(m.ResponseBody) == 0 || string(m.ResponseBody) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(m.ResponseBody, &s); err == nil {
+		return s, nil
+	}
+	return string(m.ResponseBody), nil
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Plan step actions produced by PlanApply.
+const (
+	PlanCreate    = "create"
+	PlanUpdate    = "update"
+	PlanDelete    = "delete"
+	PlanUnchanged = "unchanged"
+)
+
+// PlanStep is one change needed to make a user's mocks match a manifest.
+// Match is the canonical form of the mock's match rules, and Changes lists
+// the fields an update touches.
+type PlanStep struct {
+	Action  string
+	ID      string
+	Method  string
+	Path    string
+	Match   string
+	Changes []string
+	Input   MockInput
+}
+
+// ApplyPlan is the diff between a user's mocks and a desired set.
+type ApplyPlan struct {
+	Steps   []PlanStep
+	Applied bool
+}
+
+// Count returns how many steps have the given action.
+func (p *ApplyPlan) Count(action string) int {
+	n := 0
+	for _, st := range p.Steps {
+		if st.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// PlanApply diffs the desired mocks against the user's current mocks. Mocks
+// are identified by method, path and match rules. Mocks missing from
+// desired are only deleted when prune is set.
+func (s *MockService) PlanApply(ctx context.Context, userID string, desired []MockInput, prune bool) (_ *ApplyPlan, err error) {
+	ctx, span := startSpan(ctx, "PlanApply", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	desired = slices.Clone(desired)
+	seen := make(map[routeKey]bool, len(desired))
+	for i, in := range desired {
+		in, err := s.checkInput(in)
+		if err != nil {
+			return nil, prefixFields(err, fmt.Sprintf("mocks[%d].", i))
+		}
+		desired[i] = in
+		key := planKey(in.Method, in.Path, in.Match)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s %s%s is declared more than once", domain.ErrBulkRejected, in.Method, in.Path, in.Match)
+		}
+		seen[key] = true
+	}
+
+	existing, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[routeKey]*domain.MockAPI, len(existing))
+	for _, m := range existing {
+		byKey[planKey(m.Method, m.Path, m.Match)] = m
+	}
+
+	plan := &ApplyPlan{}
+	for _, in := range desired {
+		match := in.Match.String()
+		current, ok := byKey[planKey(in.Method, in.Path, in.Match)]
+		if !ok {
+			plan.Steps = append(plan.Steps, PlanStep{Action: PlanCreate, Method: in.Method, Path: in.Path, Match: match, Input: in})
+			continue
+		}
+		step := PlanStep{Action: PlanUnchanged, ID: current.ID, Method: in.Method, Path: in.Path, Match: match, Input: in}
+		if changes := changedFields(current, in); len(changes) > 0 {
+			step.Action = PlanUpdate
+			step.Changes = changes
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	if prune {
+		for _, m := range existing {
+			if !seen[planKey(m.Method, m.Path, m.Match)] {
+				plan.Steps = append(plan.Steps, PlanStep{Action: PlanDelete, ID: m.ID, Method: m.Method, Path: m.Path, Match: m.Match.String()})
+			}
+		}
+	}
+
+	// Deletes run in plan order after the creates, so the user must have
+	// room for the mocks being added while the pruned ones still exist.
+	if creates := plan.Count(PlanCreate); creates > 0 {
+		if err := s.checkMockCount(len(existing) + creates - 1); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// Apply makes the user's mocks match desired. With dryRun it only returns
+// the plan. All changes are applied in a single transaction.
+func (s *MockService) Apply(ctx context.Context, userID string, desired []MockInput, prune, dryRun bool) (_ *ApplyPlan, err error) {
+	ctx, span := startSpan(ctx, "Apply", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	plan, err := s.PlanApply(ctx, userID, desired, prune)
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	var ops []BulkOperation
+	var stepIndex []int
+	for i, st := range plan.Steps {
+		switch st.Action {
+		case PlanCreate:
+			ops = append(ops, BulkOperation{Action: BulkCreate, Mock: st.Input})
+		case PlanUpdate:
+			ops = append(ops, BulkOperation{Action: BulkUpdate, ID: st.ID, Mock: st.Input})
+		case PlanDelete:
+			ops = append(ops, BulkOperation{Action: BulkDelete, ID: st.ID})
+		default:
+			continue
+		}
+		stepIndex = append(stepIndex, i)
+	}
+
+	if len(ops) > 0 {
+		results, err := s.applyBulk(ctx, userID, ops)
+		if err != nil {
+			for _, res := range results {
+				if res.Err != nil {
+					st := plan.Steps[stepIndex[res.Index]]
+					return nil, fmt.Errorf("%w: %s %s %s%s: %v", domain.ErrBulkRejected, st.Action, st.Method, st.Path, st.Match, res.Err)
+				}
+			}
+			return nil, err
+		}
+		for _, res := range results {
+			plan.Steps[stepIndex[res.Index]].ID = res.ID
+		}
+	}
+
+	plan.Applied = true
+	return plan, nil
+}
+
+// routeKey identifies a mock in a plan, like sameRoute.
+type routeKey struct {
+	method, path, match string
+}
+
+func planKey(method, path string, rules *domain.MatchRules) routeKey {
+	return routeKey{method, path, rules.Key()}
+}
+
+func changedFields(current *domain.MockAPI, in MockInput) []string {
+	var changes []string
+	if current.Status != in.Status {
+		changes = append(changes, "status")
+	}
+	if current.ResponseBody != in.ResponseBody {
+		changes = append(changes, "response_body")
+	}
+	if !sameJSON(current.RequestSchema, in.RequestSchema) {
+		changes = append(changes, "request_schema")
+	}
+	if !sameJSON(current.RateLimit, in.RateLimit) {
+		changes = append(changes, "rate_limit")
+	}
+	if !sameJSON(current.CORS, in.CORS) {
+		changes = append(changes, "cors")
+	}
+	return changes
+}
+
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Actions accepted in a bulk request.
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// MaxBulkOperations bounds the size of a single bulk request.
+const MaxBulkOperations = 100
+
+// Outcomes reported per bulk operation.
+const (
+	BulkApplied    = "applied"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back"
+)
+
+// BulkOperation is one create, update or delete in a bulk request. ID is
+// required for updates and deletes; Mock is ignored for deletes.
+type BulkOperation struct {
+	Action string
+	ID     string
+	Mock   MockInput
+}
+
+// BulkResult reports what happened to one operation. When any operation
+// fails, the others are reported as rolled back.
+type BulkResult struct {
+	Index  int
+	Action string
+	ID     string
+	Status string
+	Err    error
+	Mock   *domain.MockAPI
+}
+
+// ApplyBulk applies all operations in one repository transaction. Either
+// every operation is applied or none is: on any failure the returned error
+// wraps domain.ErrBulkRejected and the results say which operations failed.
+func (s *MockService) ApplyBulk(ctx context.Context, userID string, ops []BulkOperation) (_ []BulkResult, err error) {
+	ctx, span := startSpan(ctx, "ApplyBulk", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: no operations", domain.ErrBulkRejected)
+	}
+	if len(ops) > MaxBulkOperations {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed", domain.ErrBulkRejected, MaxBulkOperations)
+	}
+	return s.applyBulk(ctx, userID, ops)
+}
+
+func (s *MockService) applyBulk(ctx context.Context, userID string, ops []BulkOperation) ([]BulkResult, error) {
+	ops = slices.Clone(ops)
+	results := make([]BulkResult, len(ops))
+	failed := false
+	for i, op := range ops {
+		results[i] = BulkResult{Index: i, Action: op.Action, ID: op.ID}
+		var err error
+		if ops[i], err = s.checkBulkOperation(op); err != nil {
+			results[i].Status = BulkFailed
+			results[i].Err = err
+			failed = true
+		}
+	}
+	if failed {
+		return rollBack(results), fmt.Errorf("%w: invalid operations", domain.ErrBulkRejected)
+	}
+
+	err := s.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		state, err := s.newBulkState(ctx, repo, userID)
+		if err != nil {
+			return err
+		}
+
+		for i, op := range ops {
+			mock, err := state.apply(ctx, repo, userID, op)
+			if err != nil {
+				if !isBulkConflict(err) {
+					return err
+				}
+				results[i].Status = BulkFailed
+				results[i].Err = err
+				failed = true
+				continue
+			}
+			results[i].Status = BulkApplied
+			results[i].ID = mock.ID
+			if op.Action != BulkDelete {
+				results[i].Mock = mock
+			}
+		}
+
+		if failed {
+			return domain.ErrBulkRejected
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrBulkRejected) {
+			return rollBack(results), fmt.Errorf("%w: conflicting operations", domain.ErrBulkRejected)
+		}
+		return nil, err
+	}
+	return results, nil
+}
+
+// checkBulkOperation validates op and returns it with its mock normalized
+// by checkInput.
+func (s *MockService) checkBulkOperation(op BulkOperation) (BulkOperation, error) {
+	switch op.Action {
+	case BulkCreate, BulkUpdate:
+		in, err := s.checkInput(op.Mock)
+		err = prefixFields(err, "mock.")
+		if op.Action == BulkUpdate && op.ID == "" {
+			fields := []domain.FieldError{{Field: "id", Message: "is required"}}
+			var de *domain.Error
+			if errors.Is(err, domain.ErrValidation) && errors.As(err, &de) {
+				return op, domain.Invalid(domain.ErrValidation, append(fields, de.Fields...)...)
+			}
+			idErr := domain.Invalid(domain.ErrValidation, fields...)
+			if err == nil {
+				return op, idErr
+			}
+			// Other errors, such as an oversized body, are reported too.
+			return op, fmt.Errorf("%w; %w", idErr, err)
+		}
+		op.Mock = in
+		return op, err
+	case BulkDelete:
+		if op.ID == "" {
+			return op, domain.Invalid(domain.ErrValidation, domain.FieldError{Field: "id", Message: "is required"})
+		}
+		return op, nil
+	default:
+		return op, domain.Invalid(domain.ErrValidation, domain.FieldError{
+			Field:   "action",
+			Message: fmt.Sprintf("must be %q, %q or %q, got %q", BulkCreate, BulkUpdate, BulkDelete, op.Action),
+		})
+	}
+}
+
+func isBulkConflict(err error) bool {
+	return errors.Is(err, domain.ErrMockAlreadyExists) || errors.Is(err, domain.ErrMockNotFound) ||
+		errors.Is(err, domain.ErrQuotaExceeded)
+}
+
+func rollBack(results []BulkResult) []BulkResult {
+	for i := range results {
+		if results[i].Status != BulkFailed {
+			results[i].Status = BulkRolledBack
+			results[i].Mock = nil
+			if results[i].Action == BulkCreate {
+				results[i].ID = ""
+			}
+		}
+	}
+	return results
+}
+
+// bulkState tracks the user's mocks as operations are applied, so conflicts
+// are detected without reading back uncommitted writes.
+type bulkState struct {
+	byID map[string]*domain.MockAPI
+	// nextRevision holds the next revision number of mocks already written
+	// in this transaction.
+	nextRevision map[string]int
+	// checkCount fails when the user may not have another mock.
+	checkCount func(count int) error
+}
+
+func (s *MockService) newBulkState(ctx context.Context, repo domain.MockRepository, userID string) (*bulkState, error) {
+	mocks, err := repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	state := &bulkState{
+		byID:         make(map[string]*domain.MockAPI, len(mocks)),
+		nextRevision: make(map[string]int),
+		checkCount:   s.checkMockCount,
+	}
+	for _, m := range mocks {
+		state.byID[m.ID] = m
+	}
+	return state, nil
+}
+
+func (st *bulkState) taken(in MockInput, exceptID string) bool {
+	for id, m := range st.byID {
+		if id != exceptID && sameRoute(m, in.Method, in.Path, in.Match) {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *bulkState) revisionNumber(ctx context.Context, repo domain.MockRepository, mock *domain.MockAPI) (int, error) {
+	if n, ok := st.nextRevision[mock.ID]; ok {
+		return n, nil
+	}
+	return nextRevision(ctx, repo, mock)
+}
+
+func (st *bulkState) apply(ctx context.Context, repo domain.MockRepository, userID string, op BulkOperation) (*domain.MockAPI, error) {
+	switch op.Action {
+	case BulkCreate:
+		if st.taken(op.Mock, "") {
+			return nil, domain.ErrMockAlreadyExists
+		}
+		if err := st.checkCount(len(st.byID)); err != nil {
+			return nil, err
+		}
+		mock := newMock(userID, op.Mock)
+		if err := repo.Save(ctx, mock); err != nil {
+			return nil, err
+		}
+		if err := repo.AddRevision(ctx, newRevision(mock, 1, domain.RevisionCreate, op.Mock.Author)); err != nil {
+			return nil, err
+		}
+		st.byID[mock.ID] = mock
+		st.nextRevision[mock.ID] = 2
+		return mock, nil
+
+	case BulkUpdate:
+		current, ok := st.byID[op.ID]
+		if !ok {
+			return nil, domain.ErrMockNotFound
+		}
+		if st.taken(op.Mock, op.ID) {
+			return nil, domain.ErrMockAlreadyExists
+		}
+		number, err := st.revisionNumber(ctx, repo, current)
+		if err != nil {
+			return nil, err
+		}
+		updated := *current
+		updated.Path = op.Mock.Path
+		updated.Method = op.Mock.Method
+		updated.Status = op.Mock.Status
+		updated.ResponseBody = op.Mock.ResponseBody
+		updated.RequestSchema = op.Mock.RequestSchema
+		updated.RateLimit = op.Mock.RateLimit
+		updated.Match = op.Mock.Match
+		updated.CORS = op.Mock.CORS
+		if err := repo.Update(ctx, &updated); err != nil {
+			return nil, err
+		}
+		if err := repo.AddRevision(ctx, newRevision(&updated, number, domain.RevisionUpdate, op.Mock.Author)); err != nil {
+			return nil, err
+		}
+		st.byID[op.ID] = &updated
+		st.nextRevision[op.ID] = number + 1
+		return &updated, nil
+
+	case BulkDelete:
+		current, ok := st.byID[op.ID]
+		if !ok {
+			return nil, domain.ErrMockNotFound
+		}
+		if err := repo.Delete(ctx, userID, op.ID); err != nil {
+			return nil, err
+		}
+		delete(st.byID, op.ID)
+		delete(st.nextRevision, op.ID)
+		return current, nil
+	}
+	return nil, fmt.Errorf("unknown action %q", op.Action)
+}
@@ -0,0 +1,117 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+// failingDelete is a repository whose deletes fail, in and out of
+// transactions.
+type failingDelete struct {
+	domain.MockRepository
+}
+
+func (r failingDelete) Delete(ctx context.Context, userID, id string) error {
+	return errors.New("disk full")
+}
+
+func (r failingDelete) WithinTx(ctx context.Context, fn func(domain.MockRepository) error) error {
+	return r.MockRepository.WithinTx(ctx, func(tx domain.MockRepository) error {
+		return fn(failingDelete{tx})
+	})
+}
+
+func TestApplyBulkRollsBack(t *testing.T) {
+	created := validMock()
+	created.Path = "/new"
+	changed := validMock()
+	changed.ResponseBody = `{"ok":false}`
+
+	tests := []struct {
+		name string
+		// ops are applied after a mock has been created; "existing" in
+		// their IDs stands for its ID.
+		ops     []usecase.BulkOperation
+		repo    func(domain.MockRepository) domain.MockRepository
+		results []string
+		wantErr error
+	}{
+		{
+			name: "a conflicting operation",
+			ops: []usecase.BulkOperation{
+				{Action: usecase.BulkCreate, Mock: created},
+				{Action: usecase.BulkUpdate, ID: "existing", Mock: changed},
+				{Action: usecase.BulkDelete, ID: "missing"},
+			},
+			results: []string{usecase.BulkRolledBack, usecase.BulkRolledBack, usecase.BulkFailed},
+			wantErr: domain.ErrBulkRejected,
+		},
+		{
+			name: "a repository failure",
+			ops: []usecase.BulkOperation{
+				{Action: usecase.BulkCreate, Mock: created},
+				{Action: usecase.BulkUpdate, ID: "existing", Mock: changed},
+				{Action: usecase.BulkDelete, ID: "existing"},
+			},
+			repo: func(r domain.MockRepository) domain.MockRepository { return failingDelete{r} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var repo domain.MockRepository = repository.NewInMemoryMockRepository()
+			if tt.repo != nil {
+				repo = tt.repo(repo)
+			}
+			svc := usecase.NewMockService(repo)
+			existing, err := svc.CreateMock(ctx, "u1", validMock())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.ops {
+				if tt.ops[i].ID == "existing" {
+					tt.ops[i].ID = existing.ID
+				}
+			}
+
+			results, err := svc.ApplyBulk(ctx, "u1", tt.ops)
+			if err == nil {
+				t.Fatal("ApplyBulk succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ApplyBulk: err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.results != nil {
+				if len(results) != len(tt.results) {
+					t.Fatalf("%d results, want %d", len(results), len(tt.results))
+				}
+				for i, res := range results {
+					if res.Status != tt.results[i] {
+						t.Errorf("results[%d] = %s, want %s", i, res.Status, tt.results[i])
+					}
+				}
+			}
+
+			// Nothing the batch did is left behind.
+			mocks, err := svc.GetMocks(ctx, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(mocks) != 1 || mocks[0].ID != existing.ID || mocks[0].ResponseBody != existing.ResponseBody {
+				t.Errorf("mocks = %+v, want only the unchanged mock", mocks)
+			}
+			revs, err := svc.ListRevisions(ctx, "u1", existing.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(revs) != 1 {
+				t.Errorf("%d revisions, want only the first", len(revs))
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// MaxCORSPolicyEntries bounds each list of a CORS policy.
+const MaxCORSPolicyEntries = 50
+
+// CORSPolicyTTL is how long served mocks may use a cached CORS policy. A
+// change made through another instance, such as another Worker isolate,
+// takes up to this long to be served.
+const CORSPolicyTTL = 10 * time.Second
+
+// maxCachedPolicies bounds the CORS policy cache, which is reset once it
+// grows past this size, like the schema cache.
+const maxCachedPolicies = 1024
+
+// policyCache holds the users' CORS policies for served mocks, nil ones
+// included, so that cross-origin requests don't each read the policy.
+type policyCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedPolicy
+}
+
+type cachedPolicy struct {
+	policy  *domain.CORSPolicy
+	expires time.Time
+}
+
+func newPolicyCache() *policyCache {
+	return &policyCache{entries: make(map[string]cachedPolicy)}
+}
+
+func (c *policyCache) get(userID string) (*domain.CORSPolicy, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[userID]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.policy, true
+}
+
+func (c *policyCache) put(userID string, policy *domain.CORSPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedPolicies {
+		c.entries = make(map[string]cachedPolicy)
+	}
+	c.entries[userID] = cachedPolicy{policy: policy, expires: time.Now().Add(CORSPolicyTTL)}
+}
+
+// GetCORSPolicy returns the user's CORS policy for served mocks, or nil
+// when the server's applies.
+func (s *MockService) GetCORSPolicy(ctx context.Context, userID string) (_ *domain.CORSPolicy, err error) {
+	ctx, span := startSpan(ctx, "GetCORSPolicy", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	return s.repo.GetCORSPolicy(ctx, userID)
+}
+
+// ServingCORSPolicy is GetCORSPolicy for served mocks: the policy may be
+// cached for up to CORSPolicyTTL.
+func (s *MockService) ServingCORSPolicy(ctx context.Context, userID string) (_ *domain.CORSPolicy, err error) {
+	if policy, ok := s.corsPolicies.get(userID); ok {
+		return policy, nil
+	}
+	policy, err := s.GetCORSPolicy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.corsPolicies.put(userID, policy)
+	return policy, nil
+}
+
+// SetCORSPolicy replaces the user's CORS policy for served mocks; nil goes
+// back to the server's. Policies are checked only for what cannot be sent
+// as headers, so broken ones can be reproduced.
+func (s *MockService) SetCORSPolicy(ctx context.Context, userID string, policy *domain.CORSPolicy) (err error) {
+	ctx, span := startSpan(ctx, "SetCORSPolicy", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	if policy != nil {
+		var errs fieldErrors
+		checkCORSPolicy(policy, &errs)
+		if len(errs) > 0 {
+			return domain.Invalid(domain.ErrValidation, errs...)
+		}
+	}
+	if err := s.repo.SetCORSPolicy(ctx, userID, policy); err != nil {
+		return err
+	}
+	s.corsPolicies.put(userID, policy)
+	return nil
+}
+
+func checkCORSPolicy(p *domain.CORSPolicy, errs *fieldErrors) {
+	if p.AllowedOrigins == nil {
+		errs.add("allowed_origins", "is required")
+	}
+	for _, list := range []struct {
+		field  string
+		values []string
+	}{
+		{"allowed_origins", p.AllowedOrigins},
+		{"allowed_methods", p.AllowedMethods},
+		{"allowed_headers", p.AllowedHeaders},
+		{"exposed_headers", p.ExposedHeaders},
+	} {
+		if len(list.values) > MaxCORSPolicyEntries {
+			errs.add(list.field, "must have at most %d entries", MaxCORSPolicyEntries)
+			continue
+		}
+		for i, v := range list.values {
+			field := fmt.Sprintf("%s[%d]", list.field, i)
+			switch {
+			case v == "":
+				errs.add(field, "must not be empty")
+			case !isHeaderValue(v):
+				errs.add(field, "must not contain control characters")
+			}
+		}
+	}
+	if p.MaxAge < 0 {
+		errs.add("max_age", "must not be negative")
+	}
+}
+
+// checkCORSOverride adds the problems of a mock's CORS override to errs.
+// Any value that can be sent as a header is allowed, so that broken CORS
+// configurations can be reproduced.
+func checkCORSOverride(c *domain.CORSOverride, errs *fieldErrors) {
+	if c == nil {
+		return
+	}
+	for _, h := range []struct {
+		field string
+		value *string
+	}{
+		{"cors.allow_origin", c.AllowOrigin},
+		{"cors.allow_methods", c.AllowMethods},
+		{"cors.allow_headers", c.AllowHeaders},
+		{"cors.expose_headers", c.ExposeHeaders},
+	} {
+		if h.value != nil && !isHeaderValue(*h.value) {
+			errs.add(h.field, "must not contain control characters")
+		}
+	}
+}
+
+// isHeaderValue reports whether s can be sent as a header value.
+func isHeaderValue(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return (r < ' ' && r != '\t') || r == 0x7f
+	}) < 0
+}
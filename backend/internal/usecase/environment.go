@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// MaxOverrideDelay bounds the delay an override may add to a response.
+const MaxOverrideDelay = 30 * time.Second
+
+// environmentName allows names like "happy-path" or "errors_v2". "active"
+// is taken by the management API's /api/environments/active.
+var environmentName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// OverrideInput carries the user-editable fields of an override.
+type OverrideInput struct {
+	Status       int
+	ResponseBody *string
+	DelayMs      int
+}
+
+// Environment is a named set of overrides. Environments exist while they
+// have overrides or are active.
+type Environment struct {
+	Name      string
+	Active    bool
+	Overrides []*domain.MockOverride
+}
+
+// ServedResponse is what the serving endpoint sends for a mock once the
+// environment in effect has been applied.
+type ServedResponse struct {
+	Status int
+	Body   string
+	Delay  time.Duration
+	// Environment is the environment whose override was applied, or "".
+	Environment string
+}
+
+func checkEnvironmentName(name string) error {
+	if !environmentName.MatchString(name) || name == "active" {
+		return fmt.Errorf("%w: %q must be 1-64 lowercase letters, digits, '-' or '_', and not \"active\"", domain.ErrInvalidEnvironment, name)
+	}
+	return nil
+}
+
+// ListEnvironments returns the user's environments ordered by name.
+func (s *MockService) ListEnvironments(ctx context.Context, userID string) (_ []Environment, err error) {
+	ctx, span := startSpan(ctx, "ListEnvironments", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	overrides, err := s.repo.GetOverrides(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.repo.GetActiveEnvironment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var envs []Environment
+	for _, o := range overrides {
+		if len(envs) == 0 || envs[len(envs)-1].Name != o.Environment {
+			envs = append(envs, Environment{Name: o.Environment, Active: o.Environment == active})
+		}
+		envs[len(envs)-1].Overrides = append(envs[len(envs)-1].Overrides, o)
+	}
+
+	if active != "" {
+		i := 0
+		for i < len(envs) && envs[i].Name < active {
+			i++
+		}
+		if i == len(envs) || envs[i].Name != active {
+			envs = append(envs[:i], append([]Environment{{Name: active, Active: true}}, envs[i:]...)...)
+		}
+	}
+	return envs, nil
+}
+
+// SetOverride creates or replaces the override of a mock in an environment.
+func (s *MockService) SetOverride(ctx context.Context, userID, environment, mockID string, in OverrideInput) (_ *domain.MockOverride, err error) {
+	ctx, span := startSpan(ctx, "SetOverride", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	if err := checkEnvironmentName(environment); err != nil {
+		return nil, err
+	}
+	mocks, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	mock := findMock(mocks, mockID)
+	if mock == nil {
+		return nil, domain.ErrMockNotFound
+	}
+
+	var errs fieldErrors
+	if in.Status != 0 && (in.Status < MinStatus || in.Status > MaxStatus) {
+		errs.add("status", "must be between %d and %d", MinStatus, MaxStatus)
+	}
+	if in.ResponseBody != nil {
+		// The body is served with the override's status, or else the
+		// mock's.
+		status := in.Status
+		if status == 0 {
+			status = mock.Status
+		}
+		if problem := checkResponseBody(status, *in.ResponseBody, false); problem != "" {
+			errs.add("response_body", "%s", problem)
+		}
+	}
+	if in.DelayMs < 0 || time.Duration(in.DelayMs)*time.Millisecond > MaxOverrideDelay {
+		errs.add("delay_ms", "must be between 0 and %d", MaxOverrideDelay.Milliseconds())
+	}
+	if len(errs) > 0 {
+		return nil, domain.Invalid(domain.ErrInvalidEnvironment, errs...)
+	}
+	if in.ResponseBody != nil {
+		if err := s.checkBodySize(*in.ResponseBody); err != nil {
+			return nil, err
+		}
+	}
+
+	o := &domain.MockOverride{
+		UserID:       userID,
+		Environment:  environment,
+		MockID:       mockID,
+		Status:       in.Status,
+		ResponseBody: in.ResponseBody,
+		DelayMs:      in.DelayMs,
+		UpdatedAt:    time.Now(),
+	}
+	if err := s.repo.SaveOverride(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// DeleteOverride removes the override of a mock in an environment.
+func (s *MockService) DeleteOverride(ctx context.Context, userID, environment, mockID string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteOverride", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	o, err := s.repo.GetOverride(ctx, userID, environment, mockID)
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		return domain.ErrOverrideNotFound
+	}
+	return s.repo.DeleteOverride(ctx, userID, environment, mockID)
+}
+
+// DeleteEnvironment removes every override in an environment and
+// deactivates it if it is active.
+func (s *MockService) DeleteEnvironment(ctx context.Context, userID, environment string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteEnvironment", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	return s.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		if err := repo.DeleteEnvironment(ctx, userID, environment); err != nil {
+			return err
+		}
+		active, err := repo.GetActiveEnvironment(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if active == environment {
+			return repo.SetActiveEnvironment(ctx, userID, "")
+		}
+		return nil
+	})
+}
+
+// ActivateEnvironment makes environment the one served when a request does
+// not ask for another. "" serves the mocks' own responses again.
+func (s *MockService) ActivateEnvironment(ctx context.Context, userID, environment string) (err error) {
+	ctx, span := startSpan(ctx, "ActivateEnvironment", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	if environment != "" {
+		if err := checkEnvironmentName(environment); err != nil {
+			return err
+		}
+	}
+	return s.repo.SetActiveEnvironment(ctx, userID, environment)
+}
+
+// ResolveResponse applies the environment in effect to mock. requested is
+// the environment a request asked for; "" means the user's active one. An
+// environment without an override for the mock leaves its response as is.
+func (s *MockService) ResolveResponse(ctx context.Context, userID, requested string, mock *domain.MockAPI) (_ *ServedResponse, err error) {
+	ctx, span := startSpan(ctx, "ResolveResponse", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	resp := &ServedResponse{Status: mock.Status, Body: mock.ResponseBody}
+
+	environment := requested
+	if environment == "" {
+		active, err := s.repo.GetActiveEnvironment(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		environment = active
+	}
+	if environment == "" {
+		return resp, nil
+	}
+
+	o, err := s.repo.GetOverride(ctx, userID, environment, mock.ID)
+	if err != nil || o == nil {
+		return resp, err
+	}
+	resp.Environment = environment
+	if o.Status != 0 {
+		resp.Status = o.Status
+	}
+	if o.ResponseBody != nil {
+		resp.Body = *o.ResponseBody
+	}
+	if bodyless(resp.Status) {
+		// The mock's status may have changed since the override was set.
+		resp.Body = ""
+	}
+	resp.Delay = time.Duration(o.DelayMs) * time.Millisecond
+	return resp, nil
+}
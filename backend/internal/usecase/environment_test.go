@@ -0,0 +1,63 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+func TestSetOverrideChecksBodyAgainstServedStatus(t *testing.T) {
+	ctx := context.Background()
+	svc := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	noContent, err := svc.CreateMock(ctx, "u1", usecase.MockInput{Path: "/items/:id", Method: "DELETE", Status: 204})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := svc.CreateMock(ctx, "u1", validMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"error":"boom"}`
+	tests := []struct {
+		name    string
+		mock    *domain.MockAPI
+		in      usecase.OverrideInput
+		invalid bool
+	}{
+		{"body for the mock's 204", noContent, usecase.OverrideInput{ResponseBody: &body}, true},
+		{"body with a status that has one", noContent, usecase.OverrideInput{Status: 500, ResponseBody: &body}, false},
+		{"body with an overriding 204", ok, usecase.OverrideInput{Status: 204, ResponseBody: &body}, true},
+		{"body for the mock's 200", ok, usecase.OverrideInput{ResponseBody: &body}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SetOverride(ctx, "u1", "errors", tt.mock.ID, tt.in)
+			if !tt.invalid {
+				if err != nil {
+					t.Fatalf("SetOverride: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, domain.ErrInvalidEnvironment) {
+				t.Fatalf("SetOverride: err = %v, want an invalid override", err)
+			}
+			if got := fieldNames(err); !slices.Equal(got, []string{"response_body"}) {
+				t.Errorf("fields = %q, want response_body", got)
+			}
+		})
+	}
+
+	resp, err := svc.ResolveResponse(ctx, "u1", "errors", noContent)
+	if err != nil {
+		t.Fatalf("ResolveResponse: %v", err)
+	}
+	if resp.Status != 500 || resp.Body != body {
+		t.Errorf("resolved = %d %q, want the override", resp.Status, resp.Body)
+	}
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"mock-api-backend/internal/domain"
+)
+
+// MaxMatchCandidates bounds the candidates in a MatchExplanation.
+const MaxMatchCandidates = 5
+
+// MatchExplanation describes how a request was matched against a user's
+// mocks. Request has its path cleaned as for serving.
+type MatchExplanation struct {
+	Request Request
+	// Mock is the mock that serves the request, or nil.
+	Mock *domain.MockAPI
+	// Candidates are the mocks closest to matching that were not served,
+	// closest first.
+	Candidates []domain.MatchCandidate
+	// Mocks counts the user's mocks, so an empty account, e.g. a request
+	// to the wrong subdomain, can be told apart from a near miss.
+	Mocks int
+}
+
+// ExplainMatch matches req against the user's mocks like GetMockForServing
+// and explains why the closest other mocks were not chosen. It does not
+// count as a hit.
+func (s *MockService) ExplainMatch(ctx context.Context, userID string, req Request) (_ *MatchExplanation, err error) {
+	ctx, span := startSpan(ctx, "ExplainMatch", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	mocks, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.Path = cleanPath(req.Path)
+	mock, candidates := s.matcher.Explain(mocks, req)
+	return &MatchExplanation{Request: req, Mock: mock, Candidates: candidates, Mocks: len(mocks)}, nil
+}
+
+// Explain returns the mock Match picks for req and, for up to
+// MaxMatchCandidates other mocks, why they were not picked. Candidates are
+// ordered by how far they are from matching: one step for a different
+// method, for each path segment that differs and for each rule that does
+// not hold.
+func (m *Matcher) Explain(mocks []*domain.MockAPI, req Request) (*domain.MockAPI, []domain.MatchCandidate) {
+	best := m.Match(mocks, req)
+
+	type scored struct {
+		mock      *domain.MockAPI
+		candidate domain.MatchCandidate
+		distance  int
+	}
+	var all []scored
+	for _, mock := range mocks {
+		if mock == best {
+			continue
+		}
+		mismatches, distance := m.mismatches(mock, req)
+		if len(mismatches) == 0 {
+			mismatches = []domain.MatchMismatch{{
+				Part:    "precedence",
+				Message: fmt.Sprintf("matches, but mock %s is more specific", best.ID),
+			}}
+		}
+		all = append(all, scored{
+			mock: mock,
+			candidate: domain.MatchCandidate{
+				MockID:     mock.ID,
+				Method:     mock.Method,
+				Path:       mock.Path,
+				Match:      mock.Match.String(),
+				Mismatches: mismatches,
+			},
+			distance: distance,
+		})
+	}
+	slices.SortFunc(all, func(a, b scored) int {
+		if c := cmp.Compare(a.distance, b.distance); c != 0 {
+			return c
+		}
+		if older(a.mock, b.mock) {
+			return -1
+		}
+		return 1
+	})
+
+	candidates := make([]domain.MatchCandidate, 0, min(len(all), MaxMatchCandidates))
+	for _, sc := range all[:min(len(all), MaxMatchCandidates)] {
+		candidates = append(candidates, sc.candidate)
+	}
+	return best, candidates
+}
+
+// mismatches lists every reason mock does not match req, and how far it is
+// from matching.
+func (m *Matcher) mismatches(mock *domain.MockAPI, req Request) ([]domain.MatchMismatch, int) {
+	var out []domain.MatchMismatch
+	distance := 0
+	if !answers(mock.Method, req.Method) {
+		out = append(out, domain.MatchMismatch{
+			Part:    "method",
+			Message: fmt.Sprintf("method is %s, want %s", req.Method, mock.Method),
+		})
+		distance++
+	}
+	if msg, d := pathMismatch(mock.Path, req.Path); d > 0 {
+		out = append(out, domain.MatchMismatch{Part: "path", Message: msg})
+		distance += d
+	}
+	for _, kind := range ruleKinds(mock.Match, req) {
+		for _, rule := range kind.rules {
+			values := kind.values[rule.Name]
+			if m.holds(rule, values) {
+				continue
+			}
+			out = append(out, domain.MatchMismatch{
+				Part:    kind.part,
+				Name:    rule.Name,
+				Message: fmt.Sprintf("%s %q %s", kind.noun, rule.Name, ruleMismatch(rule, values)),
+			})
+			distance++
+		}
+	}
+	return out, distance
+}
+
+// pathMismatch explains why path does not match pattern, as in MatchPath,
+// and returns the number of segments that differ; it is 0 on a match.
+func pathMismatch(pattern, path string) (string, int) {
+	if ok, _ := MatchPath(pattern, path); ok {
+		return "", 0
+	}
+	want, got := strings.Split(pattern, "/"), strings.Split(path, "/")
+	distance, first := 0, -1
+	for i := range min(len(want), len(got)) {
+		if ok, _ := MatchPath(want[i], got[i]); !ok {
+			distance++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if len(want) != len(got) {
+		distance += max(len(want), len(got)) - min(len(want), len(got))
+		return fmt.Sprintf("path %s has %s, want %d as in %s", path, segments(len(got)-1), len(want)-1, pattern), distance
+	}
+	return fmt.Sprintf("path segment %d is %q, want %q", first, got[first], want[first]), distance
+}
+
+func segments(n int) string {
+	if n == 1 {
+		return "1 segment"
+	}
+	return fmt.Sprintf("%d segments", n)
+}
+
+// ruleMismatch describes how values fail rule.
+func ruleMismatch(rule domain.MatchRule, values []string) string {
+	got := "is missing"
+	if values != nil {
+		got = fmt.Sprintf("is %q", strings.Join(values, ", "))
+	}
+	switch rule.Operator() {
+	case domain.MatchContains:
+		return fmt.Sprintf("%s, want it to contain %q", got, rule.Value)
+	case domain.MatchRegex:
+		return fmt.Sprintf("%s, want it to match %q", got, rule.Value)
+	case domain.MatchPresent:
+		return got
+	case domain.MatchAbsent:
+		return got + ", want it absent"
+	}
+	return fmt.Sprintf("%s, want %q", got, rule.Value)
+}
@@ -0,0 +1,78 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+func TestMatcherExplain(t *testing.T) {
+	mocks := newMocks(
+		domain.MockAPI{Method: "POST", Path: "/orders/:id"},
+		domain.MockAPI{Method: "GET", Path: "/orders/:id", Match: &domain.MatchRules{
+			Query:   []domain.MatchRule{{Name: "expand", Value: "items"}},
+			Headers: []domain.MatchRule{{Name: "X-Api-Version", Value: "2"}},
+		}},
+		domain.MockAPI{Method: "GET", Path: "/users/:id/items"},
+		domain.MockAPI{Method: "GET", Path: "/orders/:id", Match: &domain.MatchRules{
+			Cookies: []domain.MatchRule{{Name: "session", Op: domain.MatchPresent}},
+		}},
+	)
+	req := usecase.Request{
+		Method: "GET",
+		Path:   "/orders/7",
+		Query:  map[string][]string{"expand": {"customer"}},
+		Header: map[string][]string{"X-Api-Version": {"2"}},
+	}
+
+	served, candidates := usecase.NewMatcher().Explain(mocks, req)
+	if served != nil {
+		t.Fatalf("served %s, want no match", served.ID)
+	}
+	// One step each for the method, the rule and the cookie; two for the
+	// path, with a segment that differs and one too few. Ties go to the
+	// oldest mock.
+	var order []string
+	for _, c := range candidates {
+		order = append(order, c.MockID)
+	}
+	if got := strings.Join(order, ","); got != "a,b,d,c" {
+		t.Fatalf("candidates = %s, want a,b,d,c", got)
+	}
+
+	tests := []struct {
+		candidate domain.MatchCandidate
+		want      domain.MatchMismatch
+	}{
+		{candidates[0], domain.MatchMismatch{Part: "method", Message: "method is GET, want POST"}},
+		{candidates[1], domain.MatchMismatch{Part: "query", Name: "expand", Message: `query parameter "expand" is "customer", want "items"`}},
+		{candidates[2], domain.MatchMismatch{Part: "cookie", Name: "session", Message: `cookie "session" is missing`}},
+		{candidates[3], domain.MatchMismatch{Part: "path", Message: "path /orders/7 has 2 segments, want 3 as in /users/:id/items"}},
+	}
+	for _, tt := range tests {
+		// Only the failing rule is listed, not the header that holds.
+		if len(tt.candidate.Mismatches) != 1 || tt.candidate.Mismatches[0] != tt.want {
+			t.Errorf("%s mismatches = %+v, want %+v", tt.candidate.MockID, tt.candidate.Mismatches, tt.want)
+		}
+	}
+	if m := candidates[1].Match; m != "?expand=items header[X-Api-Version=2]" {
+		t.Errorf("match = %q", m)
+	}
+}
+
+func TestMatcherExplainPrecedence(t *testing.T) {
+	mocks := newMocks(
+		domain.MockAPI{Method: "GET", Path: "/users/:id"},
+		domain.MockAPI{Method: "GET", Path: "/users/me"},
+	)
+	served, candidates := usecase.NewMatcher().Explain(mocks, usecase.Request{Method: "GET", Path: "/users/me"})
+	if served == nil || served.ID != "b" {
+		t.Fatalf("served %v, want b", served)
+	}
+	want := domain.MatchMismatch{Part: "precedence", Message: "matches, but mock b is more specific"}
+	if len(candidates) != 1 || len(candidates[0].Mismatches) != 1 || candidates[0].Mismatches[0] != want {
+		t.Errorf("candidates = %+v, want a outranked by b", candidates)
+	}
+}
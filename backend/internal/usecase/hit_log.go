@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// maxHitUsers bounds how many users have a log at once; serving requests
+// without a known user would otherwise grow the map without limit.
+const maxHitUsers = 10000
+
+// HitLog keeps the most recent serving hits per user in memory. It is meant
+// for tailing, not auditing: hits are lost on restart, and each process
+// keeps its own log, so the Worker uses a store in D1 instead.
+type HitLog struct {
+	mu     sync.Mutex
+	seq    int64
+	byUser map[string][]domain.Hit
+}
+
+func NewHitLog() *HitLog {
+	return &HitLog{byUser: make(map[string][]domain.Hit)}
+}
+
+func (l *HitLog) Record(ctx context.Context, userID string, hit domain.Hit) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	hit.Seq = l.seq
+	if hit.Time.IsZero() {
+		hit.Time = time.Now()
+	}
+
+	if _, ok := l.byUser[userID]; !ok && len(l.byUser) >= maxHitUsers {
+		for evict := range l.byUser {
+			delete(l.byUser, evict)
+			break
+		}
+	}
+
+	hits := append(l.byUser[userID], hit)
+	if len(hits) > domain.HitsPerUser {
+		hits = append([]domain.Hit(nil), hits[len(hits)-domain.HitsPerUser:]...)
+	}
+	l.byUser[userID] = hits
+	return nil
+}
+
+func (l *HitLog) Since(ctx context.Context, userID string, after int64, limit int) ([]domain.Hit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []domain.Hit
+	for _, h := range l.byUser[userID] {
+		if h.Seq > after {
+			out = append(out, h)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
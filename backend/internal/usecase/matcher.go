@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"mock-api-backend/internal/domain"
+)
+
+// MaxMatchRules bounds the rules of a single mock, of all kinds together.
+const MaxMatchRules = 20
+
+// maxCachedPatterns bounds the compiled regular expression cache, which is
+// reset once it grows past this size, like the schema cache.
+const maxCachedPatterns = 1024
+
+// Request is what the matcher sees of a served request. Header keys are in
+// canonical form, as in http.Header.
+type Request struct {
+	Method  string
+	Path    string
+	Query   map[string][]string
+	Header  map[string][]string
+	Cookies map[string][]string
+}
+
+// Matcher picks the mock that answers a request. Compiled regular
+// expressions are cached by pattern.
+type Matcher struct {
+	mu    sync.Mutex
+	cache map[string]*regexp.Regexp
+}
+
+func NewMatcher() *Matcher {
+	return &Matcher{cache: make(map[string]*regexp.Regexp)}
+}
+
+// Match returns the mock among mocks that best matches req, or nil. A mock
+// matches when its method is the request's, its path matches as in
+// MatchPath and all of its rules hold. The most specific match wins:
+//
+//  1. an exact path over a path with parameters, and fewer parameters
+//     over more;
+//  2. more match rules over fewer, so a mock without rules is the
+//     fallback;
+//  3. more equals rules over regex, present and absent ones;
+//  4. the oldest mock, so adding a mock never changes which of two equally
+//     specific mocks is served.
+//
+// A HEAD request that no HEAD mock matches is matched as a GET request.
+func (m *Matcher) Match(mocks []*domain.MockAPI, req Request) *domain.MockAPI {
+	best := m.match(mocks, req)
+	if best == nil && req.Method == http.MethodHead {
+		req.Method = http.MethodGet
+		best = m.match(mocks, req)
+	}
+	return best
+}
+
+func (m *Matcher) match(mocks []*domain.MockAPI, req Request) *domain.MockAPI {
+	var best *domain.MockAPI
+	var bestRank matchRank
+	for _, mock := range mocks {
+		rank, ok := m.rank(mock, req)
+		if !ok {
+			continue
+		}
+		if best == nil || rank.compare(bestRank) < 0 ||
+			(rank.compare(bestRank) == 0 && older(mock, best)) {
+			best, bestRank = mock, rank
+		}
+	}
+	return best
+}
+
+// matchRank orders matching mocks by specificity.
+type matchRank struct {
+	params int
+	rules  int
+	equals int
+}
+
+// compare is negative when r is more specific than o.
+func (r matchRank) compare(o matchRank) int {
+	return cmp.Or(
+		cmp.Compare(r.params, o.params),
+		cmp.Compare(o.rules, r.rules),
+		cmp.Compare(o.equals, r.equals),
+	)
+}
+
+func older(a, b *domain.MockAPI) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// answers reports whether a mock for mockMethod may answer a request with
+// method, as a GET mock answers HEAD.
+func answers(mockMethod, method string) bool {
+	return mockMethod == method || (method == http.MethodHead && mockMethod == http.MethodGet)
+}
+
+// rank reports whether mock matches req and, if so, how specifically.
+func (m *Matcher) rank(mock *domain.MockAPI, req Request) (matchRank, bool) {
+	if mock.Method != req.Method {
+		return matchRank{}, false
+	}
+	ok, params := MatchPath(mock.Path, req.Path)
+	if !ok {
+		return matchRank{}, false
+	}
+	rank := matchRank{params: params}
+	for _, kind := range ruleKinds(mock.Match, req) {
+		for _, rule := range kind.rules {
+			if !m.holds(rule, kind.values[rule.Name]) {
+				return matchRank{}, false
+			}
+			rank.rules++
+			if rule.Operator() == domain.MatchEquals {
+				rank.equals++
+			}
+		}
+	}
+	return rank, true
+}
+
+// ruleKind pairs the rules on one part of a request with its values. field
+// names the rules in a mock definition and part names that part of the
+// request in diagnostics.
+type ruleKind struct {
+	field  string
+	part   string
+	noun   string
+	rules  []domain.MatchRule
+	values map[string][]string
+}
+
+func ruleKinds(rules *domain.MatchRules, req Request) []ruleKind {
+	if rules == nil {
+		return nil
+	}
+	return []ruleKind{
+		{"query", "query", "query parameter", rules.Query, req.Query},
+		{"headers", "header", "header", rules.Headers, req.Header},
+		{"cookies", "cookie", "cookie", rules.Cookies, req.Cookies},
+	}
+}
+
+// holds reports whether rule is satisfied by the values given for its name.
+// Equals, contains and regex rules hold when any value satisfies them; a
+// regex must match the whole value.
+func (m *Matcher) holds(rule domain.MatchRule, values []string) bool {
+	switch rule.Operator() {
+	case domain.MatchPresent:
+		return values != nil
+	case domain.MatchAbsent:
+		return values == nil
+	case domain.MatchContains:
+		return slices.ContainsFunc(values, func(v string) bool {
+			return strings.Contains(v, rule.Value)
+		})
+	case domain.MatchRegex:
+		re, err := m.compile(rule.Value)
+		if err != nil {
+			return false
+		}
+		return slices.ContainsFunc(values, re.MatchString)
+	}
+	return slices.Contains(values, rule.Value)
+}
+
+func (m *Matcher) compile(pattern string) (*regexp.Regexp, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if re, ok := m.cache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.cache) >= maxCachedPatterns {
+		m.cache = make(map[string]*regexp.Regexp)
+	}
+	m.cache[pattern] = re
+	return re, nil
+}
+
+// checkMatchRules adds the problems of a mock's match rules to errs and
+// puts header names in canonical form. rules must not be shared.
+func checkMatchRules(rules *domain.MatchRules, errs *fieldErrors) {
+	if rules == nil {
+		return
+	}
+	if rules.Count() > MaxMatchRules {
+		errs.add("match", "must have at most %d rules", MaxMatchRules)
+		return
+	}
+	for i := range rules.Headers {
+		rules.Headers[i].Name = http.CanonicalHeaderKey(rules.Headers[i].Name)
+	}
+	for _, kind := range ruleKinds(rules, Request{}) {
+		seen := make(map[domain.MatchRule]bool, len(kind.rules))
+		for i, rule := range kind.rules {
+			field := fmt.Sprintf("match.%s[%d]", kind.field, i)
+			switch {
+			case rule.Name == "":
+				errs.add(field+".name", "is required")
+			case kind.field != "query" && !isToken(rule.Name):
+				errs.add(field+".name", "must be a valid %s name", kind.noun)
+			}
+			switch rule.Operator() {
+			case domain.MatchEquals, domain.MatchContains:
+			case domain.MatchRegex:
+				if _, err := regexp.Compile(rule.Value); err != nil {
+					errs.add(field+".value", "is not a valid regular expression: %v", err)
+				}
+			case domain.MatchPresent, domain.MatchAbsent:
+				if rule.Value != "" {
+					errs.add(field+".value", "must be empty for op %q", rule.Op)
+				}
+			default:
+				errs.add(field+".op", "must be %q, %q, %q, %q or %q", domain.MatchEquals, domain.MatchContains,
+					domain.MatchRegex, domain.MatchPresent, domain.MatchAbsent)
+			}
+			key := domain.MatchRule{Name: rule.Name, Op: rule.Operator(), Value: rule.Value}
+			if seen[key] {
+				errs.add(field, "duplicates an earlier rule")
+			} else {
+				seen[key] = true
+			}
+		}
+	}
+}
+
+// isToken reports whether s is an HTTP token, the syntax of header and
+// cookie names.
+func isToken(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	}) < 0
+}
+
+// sameRoute reports whether two mocks would compete for the same requests
+// with the same specificity: same method, path and match rules.
+func sameRoute(m *domain.MockAPI, method, path string, rules *domain.MatchRules) bool {
+	return m.Method == method && m.Path == path && m.Match.Key() == rules.Key()
+}
@@ -0,0 +1,231 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+// newMocks returns mocks created a second apart in the order given, with
+// their index as ID.
+func newMocks(mocks ...domain.MockAPI) []*domain.MockAPI {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]*domain.MockAPI, len(mocks))
+	for i, m := range mocks {
+		m.ID = string(rune('a' + i))
+		m.CreatedAt = start.Add(time.Duration(i) * time.Second)
+		out[i] = &m
+	}
+	return out
+}
+
+func query(rules ...domain.MatchRule) *domain.MatchRules {
+	return &domain.MatchRules{Query: rules}
+}
+
+func TestMatcherMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		mocks []*domain.MockAPI
+		req   usecase.Request
+		want  string // ID of the mock served, or "" for none
+	}{
+		{
+			name: "literal path over a parameter",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/users/:id"},
+				domain.MockAPI{Method: "GET", Path: "/users/me"},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/users/me"},
+			want: "b",
+		},
+		{
+			name: "fewer parameters over more",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/:kind/:id"},
+				domain.MockAPI{Method: "GET", Path: "/users/{id}"},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/users/7"},
+			want: "b",
+		},
+		{
+			name: "fewer parameters win over more rules",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/users/:id", Match: query(domain.MatchRule{Name: "v", Value: "1"})},
+				domain.MockAPI{Method: "GET", Path: "/users/7"},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/users/7", Query: map[string][]string{"v": {"1"}}},
+			want: "b",
+		},
+		{
+			name: "more rules over fewer",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/search"},
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(
+					domain.MatchRule{Name: "q", Op: domain.MatchPresent},
+					domain.MatchRule{Name: "page", Op: domain.MatchPresent},
+				)},
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "q", Value: "go"})},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/search", Query: map[string][]string{"q": {"go"}, "page": {"2"}}},
+			want: "b",
+		},
+		{
+			name: "equals over other operators with as many rules",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "q", Op: domain.MatchRegex, Value: "g.*"})},
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "q", Value: "go"})},
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "q", Op: domain.MatchContains, Value: "o"})},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/search", Query: map[string][]string{"q": {"go"}}},
+			want: "b",
+		},
+		{
+			name: "the oldest of equally specific mocks",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "q", Op: domain.MatchPresent})},
+				domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "page", Op: domain.MatchAbsent})},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/search", Query: map[string][]string{"q": {"go"}}},
+			want: "a",
+		},
+		{
+			name:  "the fallback when a rule fails",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/search"}, domain.MockAPI{Method: "GET", Path: "/search", Match: query(domain.MatchRule{Name: "q", Value: "go"})}),
+			req:   usecase.Request{Method: "GET", Path: "/search", Query: map[string][]string{"q": {"rust"}}},
+			want:  "a",
+		},
+		{
+			name:  "a regex matches the whole value",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/items", Match: query(domain.MatchRule{Name: "id", Op: domain.MatchRegex, Value: "[0-9]+"})}),
+			req:   usecase.Request{Method: "GET", Path: "/items", Query: map[string][]string{"id": {"12a"}}},
+		},
+		{
+			name:  "an anchored alternation still matches the whole value",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/items", Match: query(domain.MatchRule{Name: "id", Op: domain.MatchRegex, Value: "a|b"})}),
+			req:   usecase.Request{Method: "GET", Path: "/items", Query: map[string][]string{"id": {"ab"}}},
+		},
+		{
+			name:  "a regex holds for any of several values",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/items", Match: query(domain.MatchRule{Name: "id", Op: domain.MatchRegex, Value: "a|b"})}),
+			req:   usecase.Request{Method: "GET", Path: "/items", Query: map[string][]string{"id": {"c", "b"}}},
+			want:  "a",
+		},
+		{
+			name:  "present holds for an empty value",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/items", Match: query(domain.MatchRule{Name: "debug", Op: domain.MatchPresent})}),
+			req:   usecase.Request{Method: "GET", Path: "/items", Query: map[string][]string{"debug": {""}}},
+			want:  "a",
+		},
+		{
+			name:  "absent fails for an empty value",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/items", Match: query(domain.MatchRule{Name: "debug", Op: domain.MatchAbsent})}),
+			req:   usecase.Request{Method: "GET", Path: "/items", Query: map[string][]string{"debug": {""}}},
+		},
+		{
+			name: "header rules",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/me"},
+				domain.MockAPI{Method: "GET", Path: "/me", Match: &domain.MatchRules{Headers: []domain.MatchRule{
+					{Name: "Accept", Op: domain.MatchContains, Value: "json"},
+					{Name: "X-Api-Version", Value: "2"},
+				}}},
+			),
+			req: usecase.Request{Method: "GET", Path: "/me", Header: map[string][]string{
+				"Accept":        {"application/json"},
+				"X-Api-Version": {"2"},
+			}},
+			want: "b",
+		},
+		{
+			name: "cookie rules",
+			mocks: newMocks(
+				domain.MockAPI{Method: "GET", Path: "/cart", Match: &domain.MatchRules{Cookies: []domain.MatchRule{{Name: "session", Op: domain.MatchAbsent}}}},
+				domain.MockAPI{Method: "GET", Path: "/cart", Match: &domain.MatchRules{Cookies: []domain.MatchRule{{Name: "session", Op: domain.MatchRegex, Value: "s-[0-9]+"}}}},
+			),
+			req:  usecase.Request{Method: "GET", Path: "/cart", Cookies: map[string][]string{"session": {"s-42"}}},
+			want: "b",
+		},
+		{
+			name:  "a rule on one part ignores the others",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/me", Match: &domain.MatchRules{Cookies: []domain.MatchRule{{Name: "v", Value: "2"}}}}),
+			req:   usecase.Request{Method: "GET", Path: "/me", Query: map[string][]string{"v": {"2"}}, Header: map[string][]string{"V": {"2"}}},
+		},
+		{
+			name:  "HEAD falls back to GET",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/me"}, domain.MockAPI{Method: "POST", Path: "/me"}),
+			req:   usecase.Request{Method: "HEAD", Path: "/me"},
+			want:  "a",
+		},
+		{
+			name:  "other methods do not",
+			mocks: newMocks(domain.MockAPI{Method: "GET", Path: "/me"}),
+			req:   usecase.Request{Method: "POST", Path: "/me"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := usecase.NewMatcher().Match(tt.mocks, tt.req)
+			var id string
+			if got != nil {
+				id = got.ID
+			}
+			if id != tt.want {
+				t.Errorf("Match = %q, want %q", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	// Both rules format as a*=b, but match different requests.
+	starName := domain.MatchRule{Name: "a*", Value: "b"}
+	contains := domain.MatchRule{Name: "a", Op: domain.MatchContains, Value: "b"}
+
+	tests := []struct {
+		name     string
+		first    *domain.MatchRules
+		second   *domain.MatchRules
+		conflict bool
+	}{
+		{"rules that format alike", query(starName), query(contains), false},
+		{"the same rules", query(contains), query(contains), true},
+		{"the same rules in another order", query(starName, contains), query(contains, starName), true},
+		{"the default operator spelled out", query(domain.MatchRule{Name: "a", Value: "b"}),
+			query(domain.MatchRule{Name: "a", Op: domain.MatchEquals, Value: "b"}), true},
+		{"the same rule on another part", query(contains), &domain.MatchRules{Headers: []domain.MatchRule{contains}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := usecase.NewMockService(repository.NewInMemoryMockRepository())
+			first, second := validMock(), validMock()
+			first.Match, second.Match = tt.first, tt.second
+			if _, err := svc.CreateMock(ctx, "u1", first); err != nil {
+				t.Fatalf("CreateMock: %v", err)
+			}
+			_, err := svc.CreateMock(ctx, "u1", second)
+			if got := errors.Is(err, domain.ErrMockAlreadyExists); got != tt.conflict {
+				t.Errorf("CreateMock: err = %v, want conflict %v", err, tt.conflict)
+			}
+
+			_, err = svc.PlanApply(ctx, "u2", []usecase.MockInput{first, second}, false)
+			if got := errors.Is(err, domain.ErrBulkRejected); got != tt.conflict {
+				t.Errorf("PlanApply: err = %v, want conflict %v", err, tt.conflict)
+			}
+		})
+	}
+
+	// Rules of one mock that format alike are not duplicates either.
+	svc := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	in := validMock()
+	in.Match = query(starName, contains)
+	if _, err := svc.CreateMock(context.Background(), "u1", in); err != nil {
+		t.Errorf("CreateMock: %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+	"slices"
 	"time"
 
 	"mock-api-backend/internal/domain"
@@ -9,125 +11,255 @@ import (
 )
 
 type MockService struct {
-	repo domain.MockRepository
+	repo         domain.MockRepository
+	validator    *RequestValidator
+	matcher      *Matcher
+	hits         domain.HitStore
+	rateLimits   domain.RateLimitStore
+	quotas       Quotas
+	corsPolicies *policyCache
+}
+
+// MockInput carries the user-editable fields of a mock for create and update.
+type MockInput struct {
+	Path          string
+	Method        string
+	Status        int
+	ResponseBody  string
+	RequestSchema *domain.RequestSchema
+	RateLimit     *domain.RateLimit
+	Match         *domain.MatchRules
+	CORS          *domain.CORSOverride
+	// Author is recorded in the mock's revision history. Empty means the
+	// user ID.
+	Author string
 }
 
 func NewMockService(repo domain.MockRepository) *MockService {
-	return &MockService{repo: repo}
+	return &MockService{
+		repo:         repo,
+		validator:    NewRequestValidator(),
+		matcher:      NewMatcher(),
+		hits:         NewHitLog(),
+		rateLimits:   newMemoryRateLimitStore(),
+		corsPolicies: newPolicyCache(),
+	}
 }
 
-func (s *MockService) CreateMock(userID, path, method, responseBody string, status int) (*domain.MockAPI, error) {
-	// Check for duplicate
-	existing, err := s.repo.GetByPathAndMethod(userID, path, method)
+func (s *MockService) CreateMock(ctx context.Context, userID string, in MockInput) (_ *domain.MockAPI, err error) {
+	ctx, span := startSpan(ctx, "CreateMock", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	in, err = s.checkInput(in)
 	if err != nil {
 		return nil, err
 	}
-	if existing != nil {
-		return nil, domain.ErrMockAlreadyExists
-	}
 
-	mock := &domain.MockAPI{
-		ID:           uuid.New().String(),
-		UserID:       userID,
-		Path:         path,
-		Method:       method,
-		Status:       status,
-		ResponseBody: responseBody,
-		CreatedAt:    time.Now(),
-		ExpiresAt:    time.Now().Add(10 * time.Minute), // 10 minutes TTL
-		HitCount:     0,
-	}
+	mock := newMock(userID, in)
+	err = s.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		mocks, err := repo.GetByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if routeTaken(mocks, in, "") {
+			return domain.ErrMockAlreadyExists
+		}
+		if err := s.checkMockCount(len(mocks)); err != nil {
+			return err
+		}
 
-	if err := s.repo.Save(mock); err != nil {
+		if err := repo.Save(ctx, mock); err != nil {
+			return err
+		}
+		return repo.AddRevision(ctx, newRevision(mock, 1, domain.RevisionCreate, in.Author))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return mock, nil
 }
 
-func (s *MockService) UpdateMock(userID, id, path, method, responseBody string, status int) (*domain.MockAPI, error) {
-	// Verify ownership and existence
-	// Since we don't have GetByIDAndUser, we can list by user and find, or just try to update if we had that query.
-	// But we have UpdateMock query that checks ID and UserID.
-	// However, we need to return the updated mock.
-	// Let's construct the object. We might need to fetch it first to get CreatedAt etc if we want to return full object,
-	// or just return what we updated.
-	// The UpdateMock query returns * so we can use that if we exposed it in repo.
-	// But repo Update takes *domain.MockAPI.
-	// Let's fetch first to ensure it exists and belongs to user (or just rely on repo update affecting 0 rows if not found?
-	// Repo Update returns error if something fails, but standard Update usually doesn't return "not found" as error unless we check rows affected.
-	// Let's assume for now we want to be safe.
-	// Actually, the repo Update implementation uses `queries.UpdateMock` which returns `*`, but the repo method signature is `Update(mock *domain.MockAPI) error`.
-	// It doesn't return the updated object from DB.
-	// So we should probably fetch it first to make sure we are updating the right thing and preserving other fields like CreatedAt.
-
-	// Wait, `GetByPathAndMethod` is not enough to find by ID.
-	// We have `GetByUser`.
-	// Let's add `GetByID` to repo if we want to be precise, or just iterate `GetByUser`.
-	// For MVP, iterating `GetByUser` is fine as list is small.
-
-	mocks, err := s.repo.GetByUser(userID)
+func newMock(userID string, in MockInput) *domain.MockAPI {
+	return &domain.MockAPI{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		Path:          in.Path,
+		Method:        in.Method,
+		Status:        in.Status,
+		ResponseBody:  in.ResponseBody,
+		RequestSchema: in.RequestSchema,
+		RateLimit:     in.RateLimit,
+		Match:         in.Match,
+		CORS:          in.CORS,
+		CreatedAt:     time.Now(),
+		ExpiresAt:     time.Now().Add(10 * time.Minute), // 10 minutes TTL
+		HitCount:      0,
+	}
+}
+
+func (s *MockService) UpdateMock(ctx context.Context, userID, id string, in MockInput) (_ *domain.MockAPI, err error) {
+	ctx, span := startSpan(ctx, "UpdateMock", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	return s.updateMock(ctx, userID, id, in, domain.RevisionUpdate)
+}
+
+// updateMock applies in to the mock and records the change as a revision
+// with the given action.
+func (s *MockService) updateMock(ctx context.Context, userID, id string, in MockInput, action string) (*domain.MockAPI, error) {
+	in, err := s.checkInput(in)
 	if err != nil {
 		return nil, err
 	}
 
 	var targetMock *domain.MockAPI
-	for _, m := range mocks {
-		if m.ID == id {
-			targetMock = m
-			break
+	err = s.repo.WithinTx(ctx, func(repo domain.MockRepository) error {
+		mocks, err := repo.GetByUser(ctx, userID)
+		if err != nil {
+			return err
 		}
-	}
 
-	if targetMock == nil {
-		return nil, domain.ErrMockNotFound
-	}
+		targetMock = findMock(mocks, id)
+		if targetMock == nil {
+			return domain.ErrMockNotFound
+		}
 
-	// Check for duplicate path/method if changed
-	if targetMock.Path != path || targetMock.Method != method {
-		existing, err := s.repo.GetByPathAndMethod(userID, path, method)
-		if err != nil {
-			return nil, err
+		if routeTaken(mocks, in, id) {
+			return domain.ErrMockAlreadyExists
 		}
-		if existing != nil && existing.ID != id {
-			return nil, domain.ErrMockAlreadyExists
+
+		number, err := nextRevision(ctx, repo, targetMock)
+		if err != nil {
+			return err
 		}
-	}
 
-	targetMock.Path = path
-	targetMock.Method = method
-	targetMock.Status = status
-	targetMock.ResponseBody = responseBody
+		targetMock.Path = in.Path
+		targetMock.Method = in.Method
+		targetMock.Status = in.Status
+		targetMock.ResponseBody = in.ResponseBody
+		targetMock.RequestSchema = in.RequestSchema
+		targetMock.RateLimit = in.RateLimit
+		targetMock.Match = in.Match
+		targetMock.CORS = in.CORS
 
-	if err := s.repo.Update(targetMock); err != nil {
+		if err := repo.Update(ctx, targetMock); err != nil {
+			return err
+		}
+		return repo.AddRevision(ctx, newRevision(targetMock, number, action, in.Author))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return targetMock, nil
 }
 
-func (s *MockService) GetMocks(userID string) ([]*domain.MockAPI, error) {
-	return s.repo.GetByUser(userID)
+// routeTaken reports whether a mock other than exceptID already answers
+// the same requests as in.
+func routeTaken(mocks []*domain.MockAPI, in MockInput, exceptID string) bool {
+	return slices.ContainsFunc(mocks, func(m *domain.MockAPI) bool {
+		return m.ID != exceptID && sameRoute(m, in.Method, in.Path, in.Match)
+	})
+}
+
+func findMock(mocks []*domain.MockAPI, id string) *domain.MockAPI {
+	for _, m := range mocks {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
 }
 
-func (s *MockService) GetMockForServing(userID, path, method string) (*domain.MockAPI, error) {
-	mock, err := s.repo.GetByPathAndMethod(userID, path, method)
+func (s *MockService) GetMocks(ctx context.Context, userID string) (_ []*domain.MockAPI, err error) {
+	ctx, span := startSpan(ctx, "GetMocks", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	return s.repo.GetByUser(ctx, userID)
+}
+
+// GetMockForServing returns the user's mock that best matches req, as
+// chosen by Matcher.Match, or nil when none does. It fails when the mock has
+// been served its quota of hits, but doesn't count this one; see CountHit.
+func (s *MockService) GetMockForServing(ctx context.Context, userID string, req Request) (_ *domain.MockAPI, err error) {
+	ctx, span := startSpan(ctx, "GetMockForServing", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	mocks, err := s.repo.GetByUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	// Mock paths are stored cleaned, so /users/ finds /users.
+	req.Path = cleanPath(req.Path)
+	mock := s.matcher.Match(mocks, req)
 	if mock != nil {
-		_ = s.repo.IncrementHitCount(mock.ID)
+		if err := s.checkHits(mock); err != nil {
+			return nil, err
+		}
 	}
 	return mock, nil
 }
 
-func (s *MockService) CleanupExpired() error {
-	return s.repo.DeleteExpired()
+// ValidateRequest checks an incoming request against the mock's request
+// schema. It returns nil when the mock has no schema or the request conforms.
+func (s *MockService) ValidateRequest(mock *domain.MockAPI, body []byte, query, headers map[string][]string) ([]domain.SchemaViolation, error) {
+	return s.validator.Validate(mock.RequestSchema, body, query, headers)
+}
+
+// SetHitStore replaces the in-memory hit log, for example with a store
+// shared between Worker isolates.
+func (s *MockService) SetHitStore(store domain.HitStore) {
+	s.hits = store
 }
 
-func (s *MockService) DeleteMock(userID, id string) error {
+// RecordHit logs a request received by the serving endpoint.
+func (s *MockService) RecordHit(ctx context.Context, userID string, hit domain.Hit) (err error) {
+	ctx, span := startSpan(ctx, "RecordHit", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	return s.hits.Record(ctx, userID, hit)
+}
+
+// RecentHits returns up to limit of the user's hits after the given
+// sequence number, oldest first.
+func (s *MockService) RecentHits(ctx context.Context, userID string, after int64, limit int) (_ []domain.Hit, err error) {
+	ctx, span := startSpan(ctx, "RecentHits", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	return s.hits.Since(ctx, userID, after, limit)
+}
+
+// CleanupExpired deletes every user's expired mocks and returns how many
+// it deleted.
+func (s *MockService) CleanupExpired(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "CleanupExpired")
+	defer func() { endSpan(span, err) }()
+
+	stats, err := s.repo.Stats(ctx)
+	if err != nil || stats.Expired == 0 {
+		return 0, err
+	}
+	if err := s.repo.DeleteExpired(ctx); err != nil {
+		return 0, err
+	}
+	return stats.Expired, nil
+}
+
+// Stats counts the mocks of every user.
+func (s *MockService) Stats(ctx context.Context) (_ domain.MockStats, err error) {
+	ctx, span := startSpan(ctx, "Stats")
+	defer func() { endSpan(span, err) }()
+
+	return s.repo.Stats(ctx)
+}
+
+func (s *MockService) DeleteMock(ctx context.Context, userID, id string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteMock", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
 	// Verify ownership by checking if the mock exists and belongs to the user
-	mocks, err := s.repo.GetByUser(userID)
+	mocks, err := s.repo.GetByUser(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -144,5 +276,5 @@ func (s *MockService) DeleteMock(userID, id string) error {
 		return domain.ErrMockNotFound
 	}
 
-	return s.repo.Delete(userID, id)
+	return s.repo.Delete(ctx, userID, id)
 }
@@ -0,0 +1,47 @@
+package usecase
+
+import "strings"
+
+// isPathParam reports whether a pattern segment is a parameter: ":id" or
+// "{id}".
+func isPathParam(segment string) bool {
+	return (strings.HasPrefix(segment, ":") && len(segment) > 1) ||
+		(strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(segment) > 2)
+}
+
+// HasPathParams reports whether a mock path contains parameter segments.
+func HasPathParams(pattern string) bool {
+	for _, seg := range strings.Split(pattern, "/") {
+		if isPathParam(seg) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath reports whether a request path matches a mock path pattern.
+// Parameter segments match any single non-empty segment; all other
+// segments must be equal. It also returns the number of parameters used,
+// so callers can prefer the most specific pattern.
+func MatchPath(pattern, path string) (bool, int) {
+	patternSegs := strings.Split(pattern, "/")
+	pathSegs := strings.Split(path, "/")
+	if len(patternSegs) != len(pathSegs) {
+		return false, 0
+	}
+
+	params := 0
+	for i, seg := range patternSegs {
+		if isPathParam(seg) {
+			if pathSegs[i] == "" {
+				return false, 0
+			}
+			params++
+			continue
+		}
+		if seg != pathSegs[i] {
+			return false, 0
+		}
+	}
+	return true, params
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Request kinds limited by Quotas.ManagementRate and Quotas.ServingRate.
+const (
+	QuotaManagement = "management"
+	QuotaServing    = "serving"
+)
+
+// Quotas bound what a single user may store and send. Zero values and nil
+// rates mean no limit.
+type Quotas struct {
+	// MaxMocks is the number of mocks a user may have at once.
+	MaxMocks int
+	// MaxBodyBytes bounds a mock's response body, and an override's.
+	MaxBodyBytes int
+	// MaxRequestBytes bounds the body of a management API request.
+	MaxRequestBytes int64
+	// MaxHitsPerMock is how often a mock may be served before it stops
+	// responding.
+	MaxHitsPerMock int
+	// ManagementRate and ServingRate limit the requests a user may send to
+	// the management API and to their serving subdomain.
+	ManagementRate *domain.RateLimit
+	ServingRate    *domain.RateLimit
+}
+
+// Usage is a user's consumption of their quotas.
+type Usage struct {
+	Mocks       int
+	LargestBody int
+	MostHits    int
+	Quotas      Quotas
+}
+
+// SetQuotas replaces the limits applied to every user.
+func (s *MockService) SetQuotas(q Quotas) {
+	s.quotas = q
+}
+
+// Quotas returns the limits applied to every user.
+func (s *MockService) Quotas() Quotas {
+	return s.quotas
+}
+
+func (s *MockService) checkBodySize(body string) error {
+	if s.quotas.MaxBodyBytes > 0 && len(body) > s.quotas.MaxBodyBytes {
+		return fmt.Errorf("%w: response body is %d bytes, the limit is %d", domain.ErrPayloadTooLarge, len(body), s.quotas.MaxBodyBytes)
+	}
+	return nil
+}
+
+// checkMockCount fails when a user with count mocks may not create more.
+func (s *MockService) checkMockCount(count int) error {
+	if s.quotas.MaxMocks > 0 && count >= s.quotas.MaxMocks {
+		return fmt.Errorf("%w: at most %d mocks are allowed", domain.ErrQuotaExceeded, s.quotas.MaxMocks)
+	}
+	return nil
+}
+
+// checkHits fails when a mock has been served as often as it may be.
+func (s *MockService) checkHits(mock *domain.MockAPI) error {
+	if s.quotas.MaxHitsPerMock > 0 && mock.HitCount >= s.quotas.MaxHitsPerMock {
+		return fmt.Errorf("%w: mock has been served its limit of %d times", domain.ErrQuotaExceeded, s.quotas.MaxHitsPerMock)
+	}
+	return nil
+}
+
+// CountHit counts a request served by mock toward its MaxHitsPerMock
+// quota. It is called once the request has passed the mock's rate limit
+// and schema, so requests turned away don't use up the mock.
+func (s *MockService) CountHit(ctx context.Context, mock *domain.MockAPI) (err error) {
+	ctx, span := startSpan(ctx, "CountHit", userAttr(mock.UserID))
+	defer func() { endSpan(span, err) }()
+
+	return s.repo.IncrementHitCount(ctx, mock.ID)
+}
+
+// TakeRequestQuota spends one of the user's management or serving
+// requests. It returns nil when that kind of request is not limited.
+func (s *MockService) TakeRequestQuota(ctx context.Context, kind, key string) (_ *domain.RateLimitDecision, err error) {
+	ctx, span := startSpan(ctx, "TakeRequestQuota")
+	defer func() { endSpan(span, err) }()
+
+	limit := s.quotas.ManagementRate
+	if kind == QuotaServing {
+		limit = s.quotas.ServingRate
+	}
+	if limit == nil {
+		return nil, nil
+	}
+	d, err := s.rateLimits.Take(ctx, kind+":"+key, *limit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Usage reports how much of their quotas a user has consumed.
+func (s *MockService) Usage(ctx context.Context, userID string) (_ *Usage, err error) {
+	ctx, span := startSpan(ctx, "Usage", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	mocks, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u := &Usage{Mocks: len(mocks), Quotas: s.quotas}
+	for _, m := range mocks {
+		u.LargestBody = max(u.LargestBody, len(m.ResponseBody))
+		u.MostHits = max(u.MostHits, m.HitCount)
+	}
+	return u, nil
+}
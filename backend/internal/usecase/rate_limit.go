@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Bounds of a mock's rate limit.
+const (
+	MaxRateLimitRequests = 1000000
+	MaxRateLimitPeriod   = 24 * time.Hour
+)
+
+// maxRateLimitBuckets bounds the in-memory store. When it is full, buckets
+// that have refilled completely are dropped; they start full anyway.
+const maxRateLimitBuckets = 100000
+
+func checkRateLimit(l *domain.RateLimit) error {
+	if l == nil {
+		return nil
+	}
+	if l.Requests < 1 || l.Requests > MaxRateLimitRequests {
+		return domain.Invalid(domain.ErrInvalidRateLimit, domain.FieldError{
+			Field:   "rate_limit.requests",
+			Message: fmt.Sprintf("must be between 1 and %d", MaxRateLimitRequests),
+		})
+	}
+	if l.PeriodSeconds < 1 || time.Duration(l.PeriodSeconds)*time.Second > MaxRateLimitPeriod {
+		return domain.Invalid(domain.ErrInvalidRateLimit, domain.FieldError{
+			Field:   "rate_limit.period_seconds",
+			Message: fmt.Sprintf("must be between 1 and %d", int(MaxRateLimitPeriod.Seconds())),
+		})
+	}
+	switch l.Scope {
+	case "", domain.RateLimitScopeMock, domain.RateLimitScopeUser:
+		return nil
+	}
+	return domain.Invalid(domain.ErrInvalidRateLimit, domain.FieldError{
+		Field:   "rate_limit.scope",
+		Message: fmt.Sprintf("must be %q or %q", domain.RateLimitScopeMock, domain.RateLimitScopeUser),
+	})
+}
+
+// SetRateLimitStore replaces the in-memory token buckets, for example with
+// a store shared between Worker isolates.
+func (s *MockService) SetRateLimitStore(store domain.RateLimitStore) {
+	s.rateLimits = store
+}
+
+// TakeRateLimit spends a token from the bucket of a rate-limited mock. It
+// returns nil for mocks without a limit.
+func (s *MockService) TakeRateLimit(ctx context.Context, mock *domain.MockAPI) (_ *domain.RateLimitDecision, err error) {
+	ctx, span := startSpan(ctx, "TakeRateLimit")
+	defer func() { endSpan(span, err) }()
+
+	if mock.RateLimit == nil {
+		return nil, nil
+	}
+	key := "mock:" + mock.ID
+	if mock.RateLimit.Scope == domain.RateLimitScopeUser {
+		key = "user:" + mock.UserID
+	}
+	d, err := s.rateLimits.Take(ctx, key, *mock.RateLimit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// memoryRateLimitStore keeps token buckets in process memory.
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled completely.
+	full time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+func (m *memoryRateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	capacity := float64(limit.Requests)
+	b, ok := m.buckets[key]
+	if !ok {
+		if len(m.buckets) >= maxRateLimitBuckets {
+			m.dropFull(now)
+		}
+		b = &tokenBucket{tokens: capacity, updated: now}
+		m.buckets[key] = b
+	}
+
+	elapsed := max(0, now.Sub(b.updated).Seconds())
+	b.tokens = math.Min(capacity, b.tokens+elapsed*limit.Rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	d := limit.Decide(b.tokens, allowed)
+	b.full = now.Add(d.Reset)
+	return d, nil
+}
+
+func (m *memoryRateLimitStore) dropFull(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"mock-api-backend/internal/domain"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// maxCachedSchemas bounds the compiled schema cache. Schemas are cheap to
+// recompile, so the cache is simply reset once it grows past this size.
+const maxCachedSchemas = 1024
+
+// schemaURL is the in-memory location every user schema is compiled under.
+const schemaURL = "mem:///schema.json"
+
+// RequestValidator compiles the JSON Schemas attached to mocks and validates
+// incoming requests against them. Compiled schemas are cached by content.
+type RequestValidator struct {
+	mu    sync.Mutex
+	cache map[string]*jsonschema.Schema
+}
+
+func NewRequestValidator() *RequestValidator {
+	return &RequestValidator{cache: make(map[string]*jsonschema.Schema)}
+}
+
+// Check reports whether every schema in rs compiles and the error status is
+// supported. The returned error wraps domain.ErrInvalidRequestSchema.
+func (v *RequestValidator) Check(rs *domain.RequestSchema) error {
+	if rs == nil {
+		return nil
+	}
+	if rs.ErrorStatus != 0 && rs.ErrorStatus != http.StatusBadRequest && rs.ErrorStatus != http.StatusUnprocessableEntity {
+		return domain.Invalid(domain.ErrInvalidRequestSchema, domain.FieldError{Field: "request_schema.error_status", Message: "must be 400 or 422"})
+	}
+	for _, part := range schemaParts(rs) {
+		if _, err := v.compile(part.raw); err != nil {
+			return domain.Invalid(domain.ErrInvalidRequestSchema, domain.FieldError{Field: "request_schema." + part.location, Message: err.Error()})
+		}
+	}
+	return nil
+}
+
+// Validate checks a request against rs and returns every violation found.
+// A nil slice means the request conforms.
+func (v *RequestValidator) Validate(rs *domain.RequestSchema, body []byte, query, headers map[string][]string) ([]domain.SchemaViolation, error) {
+	if rs == nil {
+		return nil, nil
+	}
+
+	var violations []domain.SchemaViolation
+	for _, part := range schemaParts(rs) {
+		sch, err := v.compile(part.raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", domain.ErrInvalidRequestSchema, part.location, err)
+		}
+
+		var instance any
+		switch part.location {
+		case "body":
+			instance, err = jsonschema.UnmarshalJSON(bytes.NewReader(body))
+			if err != nil {
+				violations = append(violations, domain.SchemaViolation{
+					Location: part.location,
+					Pointer:  "",
+					Message:  "request body is not valid JSON",
+				})
+				continue
+			}
+		case "query":
+			instance = valuesInstance(query, false)
+		case "headers":
+			instance = valuesInstance(headers, true)
+		}
+
+		violations = append(violations, violationsOf(part.location, sch.Validate(instance))...)
+	}
+	return violations, nil
+}
+
+type schemaPart struct {
+	location string
+	raw      []byte
+}
+
+func schemaParts(rs *domain.RequestSchema) []schemaPart {
+	var parts []schemaPart
+	if len(rs.Body) > 0 {
+		parts = append(parts, schemaPart{location: "body", raw: rs.Body})
+	}
+	if len(rs.Query) > 0 {
+		parts = append(parts, schemaPart{location: "query", raw: rs.Query})
+	}
+	if len(rs.Headers) > 0 {
+		parts = append(parts, schemaPart{location: "headers", raw: rs.Headers})
+	}
+	return parts
+}
+
+func (v *RequestValidator) compile(raw []byte) (*jsonschema.Schema, error) {
+	sum := sha256.Sum256(raw)
+	key := hex.EncodeToString(sum[:])
+
+	v.mu.Lock()
+	sch, ok := v.cache[key]
+	v.mu.Unlock()
+	if ok {
+		return sch, nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	// Never resolve remote or file references on behalf of users.
+	c.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	sch, err = c.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	if len(v.cache) >= maxCachedSchemas {
+		v.cache = make(map[string]*jsonschema.Schema)
+	}
+	v.cache[key] = sch
+	v.mu.Unlock()
+	return sch, nil
+}
+
+// valuesInstance turns query parameters or headers into a JSON object.
+// Single values become strings and repeated values become arrays.
+func valuesInstance(values map[string][]string, lowerKeys bool) map[string]any {
+	out := make(map[string]any, len(values))
+	for k, vs := range values {
+		if lowerKeys {
+			k = strings.ToLower(k)
+		}
+		if len(vs) == 1 {
+			out[k] = vs[0]
+			continue
+		}
+		items := make([]any, len(vs))
+		for i, s := range vs {
+			items[i] = s
+		}
+		out[k] = items
+	}
+	return out
+}
+
+func violationsOf(location string, err error) []domain.SchemaViolation {
+	if err == nil {
+		return nil
+	}
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return []domain.SchemaViolation{{Location: location, Message: err.Error()}}
+	}
+
+	var out []domain.SchemaViolation
+	for _, unit := range ve.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		out = append(out, domain.SchemaViolation{
+			Location: location,
+			Pointer:  unit.InstanceLocation,
+			Message:  unit.Error.String(),
+		})
+	}
+	if len(out) == 0 {
+		out = append(out, domain.SchemaViolation{Location: location, Message: ve.Error()})
+	}
+	return out
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Body diff line operations.
+const (
+	DiffEqual  = " "
+	DiffRemove = "-"
+	DiffAdd    = "+"
+)
+
+// maxDiffCells bounds the work of a line diff. Bodies with more line pairs
+// are shown as a whole-body replacement instead.
+const maxDiffCells = 1 << 20
+
+// DiffLine is one line of a body diff.
+type DiffLine struct {
+	Op   string
+	Text string
+}
+
+// RevisionDiff compares two revisions of a mock. Changes lists the fields
+// that differ; BodyDiff is a line diff of the response bodies.
+type RevisionDiff struct {
+	From     *domain.MockRevision
+	To       *domain.MockRevision
+	Changes  []string
+	BodyDiff []DiffLine
+}
+
+func newRevision(mock *domain.MockAPI, number int, action, author string) *domain.MockRevision {
+	if author == "" {
+		author = mock.UserID
+	}
+	return &domain.MockRevision{
+		MockID:        mock.ID,
+		Number:        number,
+		UserID:        mock.UserID,
+		Author:        author,
+		Action:        action,
+		Path:          mock.Path,
+		Method:        mock.Method,
+		Status:        mock.Status,
+		ResponseBody:  mock.ResponseBody,
+		RequestSchema: mock.RequestSchema,
+		RateLimit:     mock.RateLimit,
+		Match:         mock.Match,
+		CORS:          mock.CORS,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// nextRevision returns the number for the next revision of current. Mocks
+// created before history was kept have their current state recorded first,
+// so the content an edit replaces can always be restored.
+func nextRevision(ctx context.Context, repo domain.MockRepository, current *domain.MockAPI) (int, error) {
+	revs, err := repo.GetRevisions(ctx, current.UserID, current.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(revs) > 0 {
+		return revs[len(revs)-1].Number + 1, nil
+	}
+	baseline := newRevision(current, 1, domain.RevisionCreate, "")
+	baseline.CreatedAt = current.CreatedAt
+	if err := repo.AddRevision(ctx, baseline); err != nil {
+		return 0, err
+	}
+	return 2, nil
+}
+
+// ListRevisions returns a mock's revisions, oldest first.
+func (s *MockService) ListRevisions(ctx context.Context, userID, id string) (_ []*domain.MockRevision, err error) {
+	ctx, span := startSpan(ctx, "ListRevisions", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	mocks, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if findMock(mocks, id) == nil {
+		return nil, domain.ErrMockNotFound
+	}
+	return s.repo.GetRevisions(ctx, userID, id)
+}
+
+func (s *MockService) getRevision(ctx context.Context, userID, id string, number int) (*domain.MockRevision, error) {
+	revs, err := s.ListRevisions(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, rev := range revs {
+		if rev.Number == number {
+			return rev, nil
+		}
+	}
+	return nil, domain.ErrRevisionNotFound
+}
+
+// DiffRevisions compares revision from with revision to of a mock.
+func (s *MockService) DiffRevisions(ctx context.Context, userID, id string, from, to int) (_ *RevisionDiff, err error) {
+	ctx, span := startSpan(ctx, "DiffRevisions", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	a, err := s.getRevision(ctx, userID, id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.getRevision(ctx, userID, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	if a.Method != b.Method {
+		changes = append(changes, "method")
+	}
+	if a.Path != b.Path {
+		changes = append(changes, "path")
+	}
+	if a.Status != b.Status {
+		changes = append(changes, "status")
+	}
+	if a.ResponseBody != b.ResponseBody {
+		changes = append(changes, "response_body")
+	}
+	if !sameJSON(a.RequestSchema, b.RequestSchema) {
+		changes = append(changes, "request_schema")
+	}
+	if !sameJSON(a.RateLimit, b.RateLimit) {
+		changes = append(changes, "rate_limit")
+	}
+	if a.Match.Key() != b.Match.Key() {
+		changes = append(changes, "match")
+	}
+	if !sameJSON(a.CORS, b.CORS) {
+		changes = append(changes, "cors")
+	}
+
+	return &RevisionDiff{
+		From:     a,
+		To:       b,
+		Changes:  changes,
+		BodyDiff: diffLines(a.ResponseBody, b.ResponseBody),
+	}, nil
+}
+
+// RestoreRevision makes an earlier revision the mock's current content. It
+// is recorded as a new revision, so a restore can itself be undone.
+func (s *MockService) RestoreRevision(ctx context.Context, userID, id string, number int, author string) (_ *domain.MockAPI, err error) {
+	ctx, span := startSpan(ctx, "RestoreRevision", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	rev, err := s.getRevision(ctx, userID, id, number)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateMock(ctx, userID, id, MockInput{
+		Path:          rev.Path,
+		Method:        rev.Method,
+		Status:        rev.Status,
+		ResponseBody:  rev.ResponseBody,
+		RequestSchema: rev.RequestSchema,
+		RateLimit:     rev.RateLimit,
+		Match:         rev.Match,
+		CORS:          rev.CORS,
+		Author:        author,
+	}, domain.RevisionRestore)
+}
+
+// diffLines returns a line diff turning a into b, based on their longest
+// common subsequence of lines.
+func diffLines(a, b string) []DiffLine {
+	if a == b {
+		return nil
+	}
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	if len(x)*len(y) > maxDiffCells {
+		out := make([]DiffLine, 0, len(x)+len(y))
+		for _, line := range x {
+			out = append(out, DiffLine{Op: DiffRemove, Text: line})
+		}
+		for _, line := range y {
+			out = append(out, DiffLine{Op: DiffAdd, Text: line})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []DiffLine
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, DiffLine{Op: DiffEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, DiffLine{Op: DiffRemove, Text: x[i]})
+			i++
+		default:
+			out = append(out, DiffLine{Op: DiffAdd, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		out = append(out, DiffLine{Op: DiffRemove, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		out = append(out, DiffLine{Op: DiffAdd, Text: y[j]})
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Route describes the mocks whose path matches a request path, whatever
+// their method and match rules. It answers OPTIONS requests that no
+// OPTIONS mock matches.
+type Route struct {
+	// Methods are the methods the path answers, sorted: those mocked,
+	// HEAD when GET is, and OPTIONS.
+	Methods []string
+	// Mocks are ordered by specificity of their path, most specific first,
+	// then by age.
+	Mocks []*domain.MockAPI
+}
+
+// GetRoute returns the route of path among the user's mocks, with no
+// mocks when none matches it. It does not count as a hit.
+func (s *MockService) GetRoute(ctx context.Context, userID, path string) (_ *Route, err error) {
+	ctx, span := startSpan(ctx, "GetRoute", userAttr(userID))
+	defer func() { endSpan(span, err) }()
+
+	mocks, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	path = cleanPath(path)
+	route := &Route{}
+	params := make(map[*domain.MockAPI]int)
+	for _, mock := range mocks {
+		if ok, n := MatchPath(mock.Path, path); ok {
+			route.Mocks = append(route.Mocks, mock)
+			params[mock] = n
+		}
+	}
+	if len(route.Mocks) == 0 {
+		return route, nil
+	}
+	slices.SortFunc(route.Mocks, func(a, b *domain.MockAPI) int {
+		if c := cmp.Compare(params[a], params[b]); c != 0 {
+			return c
+		}
+		if older(a, b) {
+			return -1
+		}
+		return 1
+	})
+
+	route.Methods = []string{http.MethodOptions}
+	for _, mock := range route.Mocks {
+		route.Methods = append(route.Methods, mock.Method)
+		if mock.Method == http.MethodGet {
+			route.Methods = append(route.Methods, http.MethodHead)
+		}
+	}
+	slices.Sort(route.Methods)
+	route.Methods = slices.Compact(route.Methods)
+	return route, nil
+}
+
+// Preflight returns the mock whose CORS override answers a preflight
+// request for method, or nil. A preflight carries none of the actual
+// request's match values, so it is the first of the route's mocks
+// answering method that has an override.
+func (r *Route) Preflight(method string) *domain.MockAPI {
+	for _, mock := range r.Mocks {
+		if mock.CORS != nil && answers(mock.Method, method) {
+			return mock
+		}
+	}
+	return nil
+}
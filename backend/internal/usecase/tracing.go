@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of MockService operations. It uses the global
+// tracer provider, so spans go nowhere unless the binary installs one.
+var tracer = otel.Tracer("mock-api-backend/internal/usecase")
+
+// startSpan begins a span named after a MockService operation.
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "MockService."+operation, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func userAttr(userID string) attribute.KeyValue {
+	return attribute.String("user.id", userID)
+}
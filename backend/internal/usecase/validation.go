@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"mock-api-backend/internal/domain"
+)
+
+// Limits of a mock definition, which apply whatever the quotas.
+const (
+	MinStatus            = 200
+	MaxStatus            = 599
+	MaxPathLength        = 1024
+	MaxResponseBodyBytes = 1 << 20
+)
+
+// MockMethods are the methods a mock may answer.
+var MockMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// fieldErrors collects what is wrong with an input, so that every invalid
+// field is reported at once.
+type fieldErrors []domain.FieldError
+
+func (e *fieldErrors) add(field, format string, args ...any) {
+	*e = append(*e, domain.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkInput validates a mock definition and returns it normalized, with
+// the method in upper case, the path cleaned by normalizePath, header
+// names in canonical form and empty match rules removed. Invalid
+// fields are reported together as a domain.ErrValidation; the quotas, the
+// request schema and the rate limit are checked after them.
+func (s *MockService) checkInput(in MockInput) (MockInput, error) {
+	var errs fieldErrors
+
+	if p, problem := normalizePath(in.Path); problem != "" {
+		errs.add("path", "%s", problem)
+	} else {
+		in.Path = p
+	}
+
+	in.Method = strings.ToUpper(strings.TrimSpace(in.Method))
+	switch {
+	case in.Method == "":
+		errs.add("method", "is required")
+	case !slices.Contains(MockMethods, in.Method):
+		errs.add("method", "must be one of %s", strings.Join(MockMethods, ", "))
+	}
+
+	switch {
+	case in.Status == 0:
+		errs.add("status", "is required")
+	case in.Status < MinStatus || in.Status > MaxStatus:
+		errs.add("status", "must be between %d and %d", MinStatus, MaxStatus)
+	}
+
+	// Statuses without a body never send one. Mocks saved before bodies
+	// were checked may still have one, so it is dropped rather than
+	// refused, and such mocks can still be updated or restored.
+	if bodyless(in.Status) {
+		in.ResponseBody = ""
+	}
+	if problem := checkResponseBody(in.Status, in.ResponseBody, true); problem != "" {
+		errs.add("response_body", "%s", problem)
+	}
+
+	if in.Match.Empty() {
+		in.Match = nil
+	}
+	in.Match = in.Match.Clone()
+	checkMatchRules(in.Match, &errs)
+
+	if in.CORS != nil && *in.CORS == (domain.CORSOverride{}) {
+		in.CORS = nil
+	}
+	checkCORSOverride(in.CORS, &errs)
+
+	if len(errs) > 0 {
+		return in, domain.Invalid(domain.ErrValidation, errs...)
+	}
+	if err := s.checkBodySize(in.ResponseBody); err != nil {
+		return in, err
+	}
+	if err := s.validator.Check(in.RequestSchema); err != nil {
+		return in, err
+	}
+	return in, checkRateLimit(in.RateLimit)
+}
+
+// normalizePath returns the form of a mock path that request paths are
+// compared with: percent-encoding decoded, as in a request's URL.Path, and
+// cleaned by cleanPath. For an invalid path it returns the problem instead.
+func normalizePath(p string) (string, string) {
+	if p == "" {
+		return "", "is required"
+	}
+	if strings.ContainsAny(p, "?#") {
+		return "", "must not contain a query string or fragment"
+	}
+	decoded, err := url.PathUnescape(p)
+	if err != nil {
+		return "", "has an invalid percent-encoding"
+	}
+	if strings.IndexFunc(decoded, unicode.IsControl) >= 0 {
+		return "", "must not contain control characters"
+	}
+	cleaned := cleanPath(decoded)
+	if len(cleaned) > MaxPathLength {
+		return "", fmt.Sprintf("must be at most %d bytes", MaxPathLength)
+	}
+	return cleaned, ""
+}
+
+// cleanPath adds a leading slash and removes trailing and duplicate slashes
+// and dot segments, so /users/ and //users match /users.
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// checkResponseBody returns why body cannot be served with status, or "".
+// Statuses without a body must have an empty one. Unless required, an
+// empty body is allowed for any status.
+func checkResponseBody(status int, body string, required bool) string {
+	switch {
+	case bodyless(status):
+		if body != "" {
+			return fmt.Sprintf("must be empty for status %d", status)
+		}
+	case body == "":
+		if required {
+			return "is required"
+		}
+	case len(body) > MaxResponseBodyBytes:
+		return fmt.Sprintf("must be at most %d bytes", MaxResponseBodyBytes)
+	}
+	return ""
+}
+
+// bodyless reports whether responses with status never have a body.
+func bodyless(status int) bool {
+	return status == http.StatusNoContent || status == http.StatusNotModified
+}
+
+// ResponseContentType returns the media type a response body is served
+// with, so that it always describes the body: application/json for valid
+// JSON and plain text for anything else.
+func ResponseContentType(body string) string {
+	if body == "" || json.Valid([]byte(body)) {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// prefixFields returns err with prefix added to the names of its invalid
+// fields, for an input nested in a larger request.
+func prefixFields(err error, prefix string) error {
+	var de *domain.Error
+	if !errors.As(err, &de) || len(de.Fields) == 0 {
+		return err
+	}
+	fields := make([]domain.FieldError, len(de.Fields))
+	for i, f := range de.Fields {
+		fields[i] = domain.FieldError{Field: prefix + f.Field, Message: f.Message}
+	}
+	return domain.Invalid(de, fields...)
+}
@@ -0,0 +1,183 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+// validMock returns an input that passes validation, for tests to break one
+// field of.
+func validMock() usecase.MockInput {
+	return usecase.MockInput{Path: "/users", Method: "GET", Status: 200, ResponseBody: `{"ok":true}`}
+}
+
+// fieldNames returns the fields of a validation error, or nil if err is
+// not one.
+func fieldNames(err error) []string {
+	var de *domain.Error
+	if !errors.As(err, &de) {
+		return nil
+	}
+	var names []string
+	for _, f := range de.Fields {
+		names = append(names, f.Field)
+	}
+	return names
+}
+
+func TestCreateMockFieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(in *usecase.MockInput)
+		fields []string
+	}{
+		{"missing path", func(in *usecase.MockInput) { in.Path = "" }, []string{"path"}},
+		{"query string in path", func(in *usecase.MockInput) { in.Path = "/users?id=1" }, []string{"path"}},
+		{"fragment in path", func(in *usecase.MockInput) { in.Path = "/users#top" }, []string{"path"}},
+		{"bad percent-encoding", func(in *usecase.MockInput) { in.Path = "/users/%zz" }, []string{"path"}},
+		{"control character in path", func(in *usecase.MockInput) { in.Path = "/users/%0a" }, []string{"path"}},
+		{"path too long", func(in *usecase.MockInput) { in.Path = "/" + strings.Repeat("a", usecase.MaxPathLength) }, []string{"path"}},
+		{"missing method", func(in *usecase.MockInput) { in.Method = " " }, []string{"method"}},
+		{"unknown method", func(in *usecase.MockInput) { in.Method = "FOO" }, []string{"method"}},
+		{"missing status", func(in *usecase.MockInput) { in.Status = 0 }, []string{"status"}},
+		{"status too low", func(in *usecase.MockInput) { in.Status = 42 }, []string{"status"}},
+		{"status too high", func(in *usecase.MockInput) { in.Status = 600 }, []string{"status"}},
+		{"missing body", func(in *usecase.MockInput) { in.ResponseBody = "" }, []string{"response_body"}},
+		{"body too large", func(in *usecase.MockInput) {
+			in.ResponseBody = strings.Repeat("a", usecase.MaxResponseBodyBytes+1)
+		}, []string{"response_body"}},
+		{"match rule without a name", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Query: []domain.MatchRule{{Value: "a"}}}
+		}, []string{"match.query[0].name"}},
+		{"invalid header name", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Headers: []domain.MatchRule{{Name: "X Api", Value: "a"}}}
+		}, []string{"match.headers[0].name"}},
+		{"invalid cookie name", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Cookies: []domain.MatchRule{{Name: "a;b", Value: "a"}}}
+		}, []string{"match.cookies[0].name"}},
+		{"invalid regex", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Query: []domain.MatchRule{{Name: "q", Op: domain.MatchRegex, Value: "("}}}
+		}, []string{"match.query[0].value"}},
+		{"value with present", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Query: []domain.MatchRule{{Name: "q", Op: domain.MatchPresent, Value: "a"}}}
+		}, []string{"match.query[0].value"}},
+		{"unknown operator", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Query: []domain.MatchRule{{Name: "q", Op: "like", Value: "a"}}}
+		}, []string{"match.query[0].op"}},
+		{"duplicate rule", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{Headers: []domain.MatchRule{{Name: "x-a", Value: "1"}, {Name: "X-A", Value: "1"}}}
+		}, []string{"match.headers[1]"}},
+		{"too many rules", func(in *usecase.MockInput) {
+			in.Match = &domain.MatchRules{}
+			for i := range usecase.MaxMatchRules + 1 {
+				in.Match.Query = append(in.Match.Query, domain.MatchRule{Name: "q", Value: strings.Repeat("a", i)})
+			}
+		}, []string{"match"}},
+		{"control character in a CORS override", func(in *usecase.MockInput) {
+			origin := "https://a.test\r\nX-Evil: 1"
+			in.CORS = &domain.CORSOverride{AllowOrigin: &origin}
+		}, []string{"cors.allow_origin"}},
+		{"every invalid field at once", func(in *usecase.MockInput) {
+			*in = usecase.MockInput{Path: "no-query?", Method: "FOO", Status: 42}
+		}, []string{"path", "method", "status", "response_body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := usecase.NewMockService(repository.NewInMemoryMockRepository())
+			in := validMock()
+			tt.edit(&in)
+			_, err := svc.CreateMock(context.Background(), "u1", in)
+			if !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("CreateMock: err = %v, want a validation error", err)
+			}
+			if got := fieldNames(err); !slices.Equal(got, tt.fields) {
+				t.Errorf("fields = %q, want %q", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestCreateMockNormalizes(t *testing.T) {
+	svc := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	in := usecase.MockInput{
+		Path:         "users//%7Bid%7D/",
+		Method:       "post",
+		Status:       204,
+		ResponseBody: `{"dropped":true}`,
+		Match:        &domain.MatchRules{Headers: []domain.MatchRule{{Name: "x-api-version", Value: "2"}}},
+	}
+	mock, err := svc.CreateMock(context.Background(), "u1", in)
+	if err != nil {
+		t.Fatalf("CreateMock: %v", err)
+	}
+	if mock.Path != "/users/{id}" || mock.Method != "POST" {
+		t.Errorf("route = %s %s, want POST /users/{id}", mock.Method, mock.Path)
+	}
+	if mock.ResponseBody != "" {
+		t.Errorf("body = %q, want it dropped for status 204", mock.ResponseBody)
+	}
+	if name := mock.Match.Headers[0].Name; name != "X-Api-Version" {
+		t.Errorf("header name = %q, want X-Api-Version", name)
+	}
+
+	// Text is served as text, so it needs no JSON.
+	text := validMock()
+	text.Path = "/text"
+	text.ResponseBody = "plain text"
+	if _, err := svc.CreateMock(context.Background(), "u1", text); err != nil {
+		t.Errorf("CreateMock(text body): %v", err)
+	}
+}
+
+// Mocks saved before bodies were checked may have a body for 204, which
+// was required then. They can still be updated and restored.
+func TestUpdateMockSavedBeforeValidation(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryMockRepository()
+	legacy := &domain.MockAPI{
+		ID: "m1", UserID: "u1", Path: "/gone", Method: "DELETE", Status: 204, ResponseBody: "deleted",
+		CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.Save(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+	svc := usecase.NewMockService(repo)
+
+	mock, err := svc.UpdateMock(ctx, "u1", "m1", usecase.MockInput{Path: "/gone", Method: "DELETE", Status: 204, ResponseBody: "deleted"})
+	if err != nil {
+		t.Fatalf("UpdateMock: %v", err)
+	}
+	if mock.ResponseBody != "" {
+		t.Errorf("body = %q, want it dropped", mock.ResponseBody)
+	}
+	if _, err := svc.RestoreRevision(ctx, "u1", "m1", 1, ""); err != nil {
+		t.Errorf("RestoreRevision: %v", err)
+	}
+}
+
+func TestResponseContentType(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"a":1}`, "application/json"},
+		{`[1, 2]`, "application/json"},
+		{`"text"`, "application/json"},
+		{"", "application/json"},
+		{"plain text", "text/plain; charset=utf-8"},
+		{`{"a":1,}`, "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		if got := usecase.ResponseContentType(tt.body); got != tt.want {
+			t.Errorf("ResponseContentType(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
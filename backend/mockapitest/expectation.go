@@ -0,0 +1,169 @@
+package mockapitest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"mock-api-backend/internal/domain"
+	"mock-api-backend/internal/usecase"
+)
+
+// Expectation is a mock defined on a Server. Paths may contain parameter
+// segments such as /users/:id or /users/{id}.
+type Expectation struct {
+	srv    *Server
+	method string
+	path   string
+	match  *domain.MatchRules
+	id     string
+}
+
+// On starts defining a mock for method and path. Nothing is served until
+// Reply is called.
+func (s *Server) On(method, p string) *Expectation {
+	// Clean the path as the service does, so replies find the mock again.
+	return &Expectation{srv: s, method: strings.ToUpper(method), path: path.Clean("/" + p)}
+}
+
+// WithQuery makes the mock answer only requests whose query has name set
+// to value. It must be called before Reply; the most specific mock for a
+// request is served, so an expectation without rules is a fallback.
+func (e *Expectation) WithQuery(name, value string) *Expectation {
+	e.rules().Query = append(e.rules().Query, domain.MatchRule{Name: name, Value: value})
+	return e
+}
+
+// WithHeader makes the mock answer only requests with header name set to
+// value. Like WithQuery, it must be called before Reply.
+func (e *Expectation) WithHeader(name, value string) *Expectation {
+	e.rules().Headers = append(e.rules().Headers, domain.MatchRule{Name: http.CanonicalHeaderKey(name), Value: value})
+	return e
+}
+
+// WithCookie makes the mock answer only requests with cookie name set to
+// value. Like WithQuery, it must be called before Reply.
+func (e *Expectation) WithCookie(name, value string) *Expectation {
+	e.rules().Cookies = append(e.rules().Cookies, domain.MatchRule{Name: name, Value: value})
+	return e
+}
+
+func (e *Expectation) rules() *domain.MatchRules {
+	if e.match == nil {
+		e.match = &domain.MatchRules{}
+	}
+	return e.match
+}
+
+// Reply makes the mock answer with status and body. A string or []byte
+// body is sent verbatim and must be JSON; any other value is encoded as
+// JSON. Replying again on the same method, path and rules replaces the
+// earlier response.
+func (e *Expectation) Reply(status int, body any) *Expectation {
+	t := e.srv.t
+	t.Helper()
+
+	responseBody, err := encodeBody(body)
+	if err != nil {
+		t.Fatalf("mockapitest: encoding reply for %s %s: %v", e.method, e.path, err)
+	}
+	in := usecase.MockInput{
+		Path:         e.path,
+		Method:       e.method,
+		Status:       status,
+		ResponseBody: responseBody,
+		Match:        e.match,
+	}
+
+	ctx := t.Context()
+	id, err := e.srv.existingMockID(ctx, e.method, e.path, e.match)
+	if err != nil {
+		t.Fatalf("mockapitest: %v", err)
+	}
+	if id != "" {
+		_, err = e.srv.service.UpdateMock(ctx, userID, id, in)
+	} else {
+		mock, createErr := e.srv.service.CreateMock(ctx, userID, in)
+		if createErr == nil {
+			id = mock.ID
+		}
+		err = createErr
+	}
+	if err != nil {
+		t.Fatalf("mockapitest: defining %s %s: %v", e.method, e.path, err)
+	}
+
+	if e.id == "" {
+		e.srv.mu.Lock()
+		e.srv.expectations = append(e.srv.expectations, e)
+		e.srv.mu.Unlock()
+	}
+	e.id = id
+	return e
+}
+
+// Calls returns the requests this mock answered, oldest first.
+func (e *Expectation) Calls() []Call {
+	var out []Call
+	for _, c := range e.srv.Calls() {
+		if e.id != "" && c.MockID == e.id {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// AssertCalled fails the test unless the mock was called at least once.
+func (e *Expectation) AssertCalled(t testing.TB) {
+	t.Helper()
+	if len(e.Calls()) == 0 {
+		t.Errorf("mockapitest: %s %s was never called", e.method, e.path)
+	}
+}
+
+// AssertCalledTimes fails the test unless the mock was called exactly n
+// times.
+func (e *Expectation) AssertCalledTimes(t testing.TB, n int) {
+	t.Helper()
+	if got := len(e.Calls()); got != n {
+		t.Errorf("mockapitest: %s %s was called %d times, want %d", e.method, e.path, got, n)
+	}
+}
+
+// AssertNotCalled fails the test if the mock was called.
+func (e *Expectation) AssertNotCalled(t testing.TB) {
+	t.Helper()
+	if got := len(e.Calls()); got != 0 {
+		t.Errorf("mockapitest: %s %s was called %d times, want none", e.method, e.path, got)
+	}
+}
+
+func (s *Server) existingMockID(ctx context.Context, method, path string, match *domain.MatchRules) (string, error) {
+	mocks, err := s.service.GetMocks(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range mocks {
+		if m.Method == method && m.Path == path && m.Match.Key() == match.Key() {
+			return m.ID, nil
+		}
+	}
+	return "", nil
+}
+
+func encodeBody(body any) (string, error) {
+	switch b := body.(type) {
+	case string:
+		return b, nil
+	case []byte:
+		return string(b), nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
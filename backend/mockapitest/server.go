@@ -0,0 +1,178 @@
+// Package mockapitest runs the mock API in-process for Go tests. It wires
+// the in-memory repository, the mock service and the HTTP routers into
+// httptest servers, so no database or container is needed:
+//
+//	srv := mockapitest.NewServer(t)
+//	users := srv.On("GET", "/users/:id").Reply(200, `{"id":"42"}`)
+//
+//	client := NewUsersClient(srv.URL)
+//	...
+//	users.AssertCalledTimes(t, 1)
+package mockapitest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	mockhttp "mock-api-backend/internal/infrastructure/http"
+	"mock-api-backend/internal/infrastructure/repository"
+	"mock-api-backend/internal/usecase"
+)
+
+// userID owns every mock the server defines.
+const userID = "mockapitest"
+
+// servingHost is the Host the serving router sees, so requests to the
+// loopback address resolve to userID like a real subdomain would.
+const servingHost = userID + ".mockapitest.local"
+
+// Call is one request received by the serving endpoint. MockID is empty
+// when no mock matched.
+type Call struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+	Status int
+	MockID string
+}
+
+// Server is an in-process mock API. URL serves the mocks; ManagementURL
+// exposes the management API (/api/mocks and friends) for the same user.
+type Server struct {
+	URL           string
+	ManagementURL string
+
+	t          testing.TB
+	service    *usecase.MockService
+	serving    *httptest.Server
+	management *httptest.Server
+
+	// mu serializes served requests so each call can be paired with the
+	// hit it produced.
+	mu           sync.Mutex
+	lastSeq      int64
+	calls        []Call
+	expectations []*Expectation
+}
+
+// NewServer starts a mock server and closes it when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	service := usecase.NewMockService(repository.NewInMemoryMockRepository())
+	handler := mockhttp.NewMockHandler(service, "http", "mockapitest.local")
+	allowedOrigins := []string{"*"}
+
+	s := &Server{t: t, service: service}
+
+	servingRouter := mockhttp.NewServingRouter(handler, allowedOrigins)
+	s.serving = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.serve(servingRouter, w, r)
+	}))
+
+	managementRouter := mockhttp.NewManagementRouter(handler, allowedOrigins)
+	s.management = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests without credentials act as the server's user.
+		if r.Header.Get("Authorization") == "" {
+			if _, err := r.Cookie(mockhttp.UserIDCookie); err != nil {
+				r.AddCookie(&http.Cookie{Name: mockhttp.UserIDCookie, Value: userID})
+			}
+		}
+		managementRouter.ServeHTTP(w, r)
+	}))
+
+	s.URL = s.serving.URL
+	s.ManagementURL = s.management.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down. It is called automatically at test cleanup.
+func (s *Server) Close() {
+	s.serving.Close()
+	s.management.Close()
+}
+
+// Client returns an HTTP client for the serving endpoint.
+func (s *Server) Client() *http.Client {
+	return s.serving.Client()
+}
+
+func (s *Server) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		mockhttp.WriteProblem(w, r, http.StatusBadRequest, "invalid_body", "Failed to read the request body")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.Host = servingHost
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next.ServeHTTP(w, r)
+
+	call := Call{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	// The in-memory hit log never fails.
+	hits, _ := s.service.RecentHits(r.Context(), userID, s.lastSeq, 0)
+	for _, hit := range hits {
+		s.lastSeq = hit.Seq
+		call.Status = hit.Status
+		call.MockID = hit.MockID
+	}
+	s.calls = append(s.calls, call)
+}
+
+// Calls returns every request served so far, oldest first.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// Unmatched returns the requests no mock matched.
+func (s *Server) Unmatched() []Call {
+	var out []Call
+	for _, c := range s.Calls() {
+		if c.MockID == "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// AssertNoUnmatched fails the test if any request did not match a mock.
+func (s *Server) AssertNoUnmatched(t testing.TB) {
+	t.Helper()
+	for _, c := range s.Unmatched() {
+		t.Errorf("mockapitest: unexpected request %s %s", c.Method, c.Path)
+	}
+}
+
+// AssertExpectations fails the test for every mock defined with On that
+// was never called.
+func (s *Server) AssertExpectations(t testing.TB) {
+	t.Helper()
+	s.mu.Lock()
+	expectations := append([]*Expectation(nil), s.expectations...)
+	s.mu.Unlock()
+
+	for _, e := range expectations {
+		if e.id != "" && len(e.Calls()) == 0 {
+			t.Errorf("mockapitest: %s %s was never called", e.method, e.path)
+		}
+	}
+}
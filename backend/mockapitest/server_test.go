@@ -0,0 +1,137 @@
+package mockapitest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"mock-api-backend/mockapitest"
+)
+
+// recorder collects the failures reported through it, to check that
+// assertions fail when they should.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func get(t *testing.T, client *http.Client, url string, header http.Header) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, strings.TrimSpace(string(body))
+}
+
+func TestServer(t *testing.T) {
+	srv := mockapitest.NewServer(t)
+	user := srv.On("GET", "/users/:id").Reply(200, map[string]string{"id": "42"})
+	admins := srv.On("GET", "/users").WithQuery("role", "admin").Reply(200, []string{"42"})
+	all := srv.On("GET", "/users").Reply(200, `["1","42"]`)
+	v2 := srv.On("GET", "/me").WithHeader("X-Api-Version", "2").Reply(200, map[string]int{"v": 2})
+	unused := srv.On("DELETE", "/users/:id").Reply(204, "")
+
+	tests := []struct {
+		path   string
+		header http.Header
+		status int
+		body   string
+	}{
+		{path: "/users/7", status: 200, body: `{"id":"42"}`},
+		{path: "/users?role=admin", status: 200, body: `["42"]`},
+		{path: "/users", status: 200, body: `["1","42"]`},
+		{path: "/me", header: http.Header{"X-Api-Version": {"2"}}, status: 200, body: `{"v":2}`},
+		{path: "/me", status: 404},
+	}
+	for _, tt := range tests {
+		status, body := get(t, srv.Client(), srv.URL+tt.path, tt.header)
+		if status != tt.status {
+			t.Errorf("GET %s: status = %d, want %d: %s", tt.path, status, tt.status, body)
+		}
+		if tt.body != "" && body != tt.body {
+			t.Errorf("GET %s: body = %s, want %s", tt.path, body, tt.body)
+		}
+	}
+
+	for _, e := range []*mockapitest.Expectation{user, admins, all, v2} {
+		e.AssertCalledTimes(t, 1)
+	}
+	unused.AssertNotCalled(t)
+
+	calls := srv.Calls()
+	if len(calls) != len(tests) {
+		t.Fatalf("%d calls recorded, want %d", len(calls), len(tests))
+	}
+	if c := calls[1]; c.Path != "/users" || c.Query.Get("role") != "admin" || c.Status != 200 || c.MockID == "" {
+		t.Errorf("call = %+v, want GET /users?role=admin served", c)
+	}
+	if u := srv.Unmatched(); len(u) != 1 || u[0].Path != "/me" || u[0].Status != 404 {
+		t.Errorf("Unmatched() = %+v, want the GET /me without a version", u)
+	}
+
+	// The assertions fail for the unmatched request, the unused mock and
+	// wrong call counts.
+	rec := &recorder{TB: t}
+	srv.AssertNoUnmatched(rec)
+	srv.AssertExpectations(rec)
+	user.AssertCalledTimes(rec, 2)
+	user.AssertNotCalled(rec)
+	unused.AssertCalled(rec)
+	if len(rec.failures) != 5 {
+		t.Errorf("failures = %q, want 5", rec.failures)
+	}
+}
+
+func TestReplyReplaces(t *testing.T) {
+	srv := mockapitest.NewServer(t)
+	first := srv.On("GET", "/status").Reply(200, `{"ok":true}`)
+	srv.On("GET", "/status").Reply(503, `{"ok":false}`)
+
+	status, body := get(t, srv.Client(), srv.URL+"/status", nil)
+	if status != 503 || body != `{"ok":false}` {
+		t.Errorf("GET /status = %d %s, want the second reply", status, body)
+	}
+	first.AssertCalledTimes(t, 1)
+}
+
+func TestManagementURL(t *testing.T) {
+	srv := mockapitest.NewServer(t)
+	srv.On("POST", "/orders").Reply(201, map[string]string{"id": "o1"})
+
+	status, body := get(t, http.DefaultClient, srv.ManagementURL+"/api/mocks", nil)
+	if status != 200 {
+		t.Fatalf("GET /api/mocks: status = %d: %s", status, body)
+	}
+	var mocks []struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(body), &mocks); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	if len(mocks) != 1 || mocks[0].Method != "POST" || mocks[0].Path != "/orders" {
+		t.Errorf("mocks = %+v, want POST /orders", mocks)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
+
+	"mock-api-backend/internal/infrastructure/migrate"
+	sqlfiles "mock-api-backend/sql"
 )
 
 func main() {
@@ -50,7 +52,7 @@ func main() {
 
 	conn.Close(context.Background())
 
-	// 2. Connect to the new database to run schema
+	// 2. Connect to the new database to run migrations
 	dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, password, host, port, dbName)
 	conn, err = pgx.Connect(context.Background(), dsn)
 	if err != nil {
@@ -58,25 +60,19 @@ func main() {
 	}
 	defer conn.Close(context.Background())
 
-	// Read schema file
-	schema, err := os.ReadFile("sql/schema/001_init.sql")
+	// Apply the schema migrations
+	migrations, err := migrate.Load(sqlfiles.PostgresMigrations())
 	if err != nil {
-		log.Fatalf("Failed to read schema file: %v\n", err)
+		log.Fatalf("Failed to load migrations: %v\n", err)
 	}
 
-	// Execute schema
-	// Split by ; to execute multiple statements if needed, but pgx might handle it.
-	// pgx.Exec can handle multiple statements.
-	fmt.Println("Running schema migration...")
-	_, err = conn.Exec(context.Background(), string(schema))
+	fmt.Println("Running schema migrations...")
+	applied, err := migrate.New(migrate.NewPostgres(conn), migrations).Up(context.Background())
+	for _, m := range applied {
+		fmt.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
+	}
 	if err != nil {
-		// Ignore "relation already exists" errors if re-running
-		if !strings.Contains(err.Error(), "already exists") {
-			log.Fatalf("Failed to execute schema: %v\n", err)
-		} else {
-			fmt.Println("Schema already applied (or partial error):", err)
-		}
-	} else {
-		fmt.Println("Schema applied successfully.")
+		log.Fatalf("Failed to migrate: %v\n", err)
 	}
+	fmt.Println("Schema is up to date.")
 }
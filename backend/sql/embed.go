@@ -0,0 +1,29 @@
+// Package sql embeds the SQL migrations so binaries can manage schemas
+// without reading them from disk.
+package sql
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+// PostgresMigrations holds the Postgres migrations.
+func PostgresMigrations() fs.FS {
+	return subdir("migrations/postgres")
+}
+
+// SQLiteMigrations holds the migrations shared by SQLite and D1.
+func SQLiteMigrations() fs.FS {
+	return subdir("migrations/sqlite")
+}
+
+func subdir(dir string) fs.FS {
+	sub, err := fs.Sub(migrations, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
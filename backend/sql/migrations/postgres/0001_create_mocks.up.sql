@@ -1,4 +1,4 @@
-CREATE TABLE mocks (
+CREATE TABLE IF NOT EXISTS mocks (
     id UUID PRIMARY KEY,
     user_id TEXT NOT NULL,
     method VARCHAR(10) NOT NULL,
@@ -10,5 +10,5 @@ CREATE TABLE mocks (
     expires_at TIMESTAMP
 );
 
-CREATE INDEX idx_mocks_path_method ON mocks (path, method);
-CREATE INDEX idx_mocks_user_id ON mocks (user_id);
+CREATE INDEX IF NOT EXISTS idx_mocks_path_method ON mocks (path, method);
+CREATE INDEX IF NOT EXISTS idx_mocks_user_id ON mocks (user_id);
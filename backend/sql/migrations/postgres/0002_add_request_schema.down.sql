@@ -0,0 +1 @@
+ALTER TABLE mocks DROP COLUMN IF EXISTS request_schema;
@@ -0,0 +1 @@
+ALTER TABLE mocks ADD COLUMN IF NOT EXISTS request_schema TEXT;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS mock_revisions (
+    mock_id UUID NOT NULL,
+    number INT NOT NULL,
+    user_id TEXT NOT NULL,
+    author TEXT NOT NULL,
+    action TEXT NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    response_status INT NOT NULL,
+    response_body TEXT NOT NULL,
+    request_schema TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (mock_id, number)
+);
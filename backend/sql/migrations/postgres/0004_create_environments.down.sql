@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS active_environments;
+DROP TABLE IF EXISTS mock_overrides;
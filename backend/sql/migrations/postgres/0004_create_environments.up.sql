@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS mock_overrides (
+    user_id TEXT NOT NULL,
+    environment TEXT NOT NULL,
+    mock_id UUID NOT NULL,
+    response_status INT NOT NULL DEFAULT 0,
+    response_body TEXT,
+    delay_ms INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, environment, mock_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mock_overrides_mock_id ON mock_overrides(mock_id);
+
+CREATE TABLE IF NOT EXISTS active_environments (
+    user_id TEXT PRIMARY KEY,
+    environment TEXT NOT NULL
+);
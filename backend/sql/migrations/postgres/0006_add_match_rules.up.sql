@@ -0,0 +1,2 @@
+ALTER TABLE mocks ADD COLUMN IF NOT EXISTS match_rules TEXT;
+ALTER TABLE mock_revisions ADD COLUMN IF NOT EXISTS match_rules TEXT;
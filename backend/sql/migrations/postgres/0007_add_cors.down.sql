@@ -0,0 +1,2 @@
+ALTER TABLE mock_revisions DROP COLUMN IF EXISTS cors;
+ALTER TABLE mocks DROP COLUMN IF EXISTS cors;
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS cors_policies (
+    user_id TEXT PRIMARY KEY,
+    policy TEXT NOT NULL
+);
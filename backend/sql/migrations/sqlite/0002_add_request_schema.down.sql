@@ -0,0 +1 @@
+ALTER TABLE mocks DROP COLUMN request_schema;
@@ -0,0 +1 @@
+ALTER TABLE mocks ADD COLUMN request_schema TEXT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mock_revisions;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS mock_revisions (
+    mock_id TEXT NOT NULL,
+    number INTEGER NOT NULL,
+    user_id TEXT NOT NULL,
+    author TEXT NOT NULL,
+    action TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    response_status INTEGER NOT NULL,
+    response_body TEXT NOT NULL,
+    request_schema TEXT,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (mock_id, number)
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS mock_overrides (
+    user_id TEXT NOT NULL,
+    environment TEXT NOT NULL,
+    mock_id TEXT NOT NULL,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    response_body TEXT,
+    delay_ms INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, environment, mock_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mock_overrides_mock_id ON mock_overrides(mock_id);
+
+CREATE TABLE IF NOT EXISTS active_environments (
+    user_id TEXT PRIMARY KEY,
+    environment TEXT NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rate_limit_buckets;
+ALTER TABLE mock_revisions DROP COLUMN rate_limit;
+ALTER TABLE mocks DROP COLUMN rate_limit;
@@ -0,0 +1,10 @@
+ALTER TABLE mocks ADD COLUMN rate_limit TEXT;
+ALTER TABLE mock_revisions ADD COLUMN rate_limit TEXT;
+
+-- Token buckets for the worker, which has no memory shared between
+-- isolates. updated_at is in Unix milliseconds.
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens REAL NOT NULL,
+    updated_at INTEGER NOT NULL
+);
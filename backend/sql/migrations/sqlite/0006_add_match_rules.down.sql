@@ -0,0 +1,2 @@
+ALTER TABLE mock_revisions DROP COLUMN match_rules;
+ALTER TABLE mocks DROP COLUMN match_rules;
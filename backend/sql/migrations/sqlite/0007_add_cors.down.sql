@@ -0,0 +1,2 @@
+ALTER TABLE mock_revisions DROP COLUMN cors;
+ALTER TABLE mocks DROP COLUMN cors;
@@ -0,0 +1,2 @@
+ALTER TABLE mocks ADD COLUMN cors TEXT;
+ALTER TABLE mock_revisions ADD COLUMN cors TEXT;
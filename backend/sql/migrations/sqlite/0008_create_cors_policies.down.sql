@@ -0,0 +1 @@
+DROP TABLE IF EXISTS cors_policies;
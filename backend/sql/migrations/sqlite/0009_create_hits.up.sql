@@ -0,0 +1,16 @@
+-- Recent serving hits for tailing, shared by every Worker isolate. seq
+-- never reuses a deleted value, so tail cursors stay valid. time is in
+-- Unix milliseconds; mock_id and environment are '' when unset.
+CREATE TABLE IF NOT EXISTS hits (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    time INTEGER NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    mock_id TEXT NOT NULL DEFAULT '',
+    environment TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_hits_user_seq ON hits(user_id, seq);
+CREATE INDEX IF NOT EXISTS idx_hits_time ON hits(time);
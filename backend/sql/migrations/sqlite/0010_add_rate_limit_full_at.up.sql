@@ -0,0 +1,6 @@
+-- When each token bucket will have refilled, in Unix milliseconds. A full
+-- bucket is the same as none, so rows past full_at are pruned. Existing
+-- rows get 0 and are pruned first.
+ALTER TABLE rate_limit_buckets ADD COLUMN full_at INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);
@@ -1,18 +1,12 @@
 -- name: CreateMock :one
-INSERT INTO mocks (id, user_id, method, path, response_status, response_body, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO mocks (id, user_id, method, path, response_status, response_body, expires_at, request_schema, created_at, hit_count, rate_limit, match_rules, cors)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 RETURNING *;
 
 -- name: GetMock :one
 SELECT * FROM mocks
 WHERE id = $1 LIMIT 1;
 
--- name: GetMockByPathAndMethod :one
-SELECT * FROM mocks
-WHERE user_id = $1 AND path = $2 AND method = $3
-ORDER BY created_at DESC
-LIMIT 1;
-
 -- name: ListMocksByUser :many
 SELECT * FROM mocks
 WHERE user_id = $1
@@ -27,12 +21,94 @@ WHERE id = $1;
 DELETE FROM mocks
 WHERE expires_at < NOW();
 
+-- name: CountMocks :one
+SELECT COUNT(*) FILTER (WHERE expires_at >= NOW()) AS active,
+       COUNT(*) FILTER (WHERE expires_at < NOW()) AS expired
+FROM mocks;
+
 -- name: DeleteMock :exec
 DELETE FROM mocks
 WHERE id = $1 AND user_id = $2;
 
 -- name: UpdateMock :one
 UPDATE mocks
-SET method = $3, path = $4, response_status = $5, response_body = $6
+SET method = $3, path = $4, response_status = $5, response_body = $6, request_schema = $7, rate_limit = $8, match_rules = $9, cors = $10
 WHERE id = $1 AND user_id = $2
 RETURNING *;
+
+-- name: CreateMockRevision :exec
+INSERT INTO mock_revisions (mock_id, number, user_id, author, action, method, path, response_status, response_body, request_schema, created_at, rate_limit, match_rules, cors)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+
+-- name: ListMockRevisions :many
+SELECT * FROM mock_revisions
+WHERE mock_id = $1 AND user_id = $2
+ORDER BY number;
+
+-- name: DeleteMockRevisions :exec
+DELETE FROM mock_revisions
+WHERE mock_id = $1 AND user_id = $2;
+
+-- name: DeleteExpiredRevisions :exec
+DELETE FROM mock_revisions
+WHERE mock_id IN (SELECT id FROM mocks WHERE expires_at < NOW());
+
+-- name: SaveMockOverride :exec
+INSERT INTO mock_overrides (user_id, environment, mock_id, response_status, response_body, delay_ms, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id, environment, mock_id) DO UPDATE SET
+    response_status = EXCLUDED.response_status,
+    response_body = EXCLUDED.response_body,
+    delay_ms = EXCLUDED.delay_ms,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: GetMockOverride :one
+SELECT * FROM mock_overrides
+WHERE user_id = $1 AND environment = $2 AND mock_id = $3;
+
+-- name: ListMockOverridesByUser :many
+SELECT * FROM mock_overrides
+WHERE user_id = $1
+ORDER BY environment, mock_id;
+
+-- name: DeleteMockOverride :exec
+DELETE FROM mock_overrides
+WHERE user_id = $1 AND environment = $2 AND mock_id = $3;
+
+-- name: DeleteEnvironment :exec
+DELETE FROM mock_overrides
+WHERE user_id = $1 AND environment = $2;
+
+-- name: DeleteOverridesOfMock :exec
+DELETE FROM mock_overrides
+WHERE mock_id = $1 AND user_id = $2;
+
+-- name: DeleteExpiredOverrides :exec
+DELETE FROM mock_overrides
+WHERE mock_id IN (SELECT id FROM mocks WHERE expires_at < NOW());
+
+-- name: SetActiveEnvironment :exec
+INSERT INTO active_environments (user_id, environment)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET environment = EXCLUDED.environment;
+
+-- name: ClearActiveEnvironment :exec
+DELETE FROM active_environments
+WHERE user_id = $1;
+
+-- name: GetActiveEnvironment :one
+SELECT environment FROM active_environments
+WHERE user_id = $1;
+
+-- name: SetCORSPolicy :exec
+INSERT INTO cors_policies (user_id, policy)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET policy = EXCLUDED.policy;
+
+-- name: ClearCORSPolicy :exec
+DELETE FROM cors_policies
+WHERE user_id = $1;
+
+-- name: GetCORSPolicy :one
+SELECT policy FROM cors_policies
+WHERE user_id = $1;
@@ -23,7 +23,7 @@ export function CreateApiModal({ isOpen, onClose, onSuccess, editingMock }: Crea
         path: "/my-endpoint",
         method: "GET",
         status: 200,
-        response_body: "Hello World",
+        response_body: '{"message": "Hello World"}',
     });
 
     useEffect(() => {
@@ -39,7 +39,7 @@ export function CreateApiModal({ isOpen, onClose, onSuccess, editingMock }: Crea
                 path: "/my-endpoint",
                 method: "GET",
                 status: 200,
-                response_body: "Hello World",
+                response_body: '{"message": "Hello World"}',
             });
         }
         setError("");
@@ -34,14 +34,13 @@ export async function requestJson<T = unknown>(path: string, init?: RequestInit)
     });
 
     const contentType = res.headers.get("content-type") || "";
-    const isJson = contentType.includes("application/json");
+    // Errors are RFC 7807 problem details (application/problem+json).
+    const isJson = contentType.includes("application/json") || contentType.includes("+json");
     const body = isJson ? await res.json().catch(() => null) : await res.text();
 
     if (!res.ok) {
-        const errorMessage =
-            isJson && body && typeof body === "object" && "error" in (body as Record<string, unknown>)
-                ? (body as Record<string, unknown>).error as string
-                : undefined;
+        const problem = isJson && body && typeof body === "object" ? (body as Record<string, unknown>) : undefined;
+        const errorMessage = typeof problem?.detail === "string" ? problem.detail : undefined;
         const message = errorMessage || (typeof body === "string" && body) || `Request failed (${res.status})`;
         throw new BackendError(message, res.status, body, url);
     }